@@ -0,0 +1,328 @@
+// Package admin provides an `http.Handler` for inspecting and controlling the targets
+// of a running Logr, with JSON request and response bodies.
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mattermost/logr/v2"
+	"github.com/mattermost/logr/v2/config"
+)
+
+const (
+	// DefaultFlushTimeout is the default maximum time spent flushing targets.
+	DefaultFlushTimeout = 10 * time.Second
+
+	// MaxBodySize is the maximum size of a request body.
+	MaxBodySize = 1 << 20
+)
+
+// stdLevels are the levels that can be specified by name.
+var stdLevels = []logr.Level{logr.Panic, logr.Fatal, logr.Error, logr.Warn, logr.Info, logr.Debug, logr.Trace}
+
+// Options provides optional parameters for a Handler.
+type Options struct {
+	// Reconciler, if not nil, is used to read and apply target configs, and to change
+	// the levels of targets it manages so the change is kept in its config. Without a
+	// Reconciler the config endpoints respond with 501 Not Implemented.
+	Reconciler *config.Reconciler
+
+	// FlushTimeout is the maximum time spent flushing targets. Defaults to
+	// DefaultFlushTimeout.
+	FlushTimeout time.Duration
+}
+
+// Handler is an `http.Handler` providing the following endpoints, relative to where it
+// is mounted (use `http.StripPrefix` when mounting under a path):
+//
+//	GET  /targets                 status of all targets, see `logr.TargetStatus`.
+//	GET  /targets/{name}          status of a target.
+//	PUT  /targets/{name}/levels   set enabled levels: {"level":"debug"} or {"levels":[{"id":5,"name":"debug"},...]}.
+//	POST /targets/{name}/pause    stop output to a target.
+//	POST /targets/{name}/resume   resume output to a paused target.
+//	PUT  /targets/{name}/config   apply a `config.TargetCfg` to one target, adding it if new.
+//	POST /flush                   flush all targets.
+//	GET  /config                  the current config, a map of name->`config.TargetCfg`.
+//	PUT  /config                  apply a new config, replacing the current config.
+//
+// Configs in responses have the redaction `hash_secret` and credential-bearing target
+// options, such as `headers` and `cert`, masked as `logr.RedactedValue`. Masked values in
+// a config applied via PUT are replaced by the current values, so a config can be read,
+// changed and applied again.
+//
+// Errors are returned as {"error":"..."}. The handler does not authenticate requests;
+// mount it behind the application's authorization.
+type Handler struct {
+	lgr          *logr.Logr
+	reconciler   *config.Reconciler
+	flushTimeout time.Duration
+	mux          *http.ServeMux
+}
+
+// NewHandler creates a Handler for the targets of lgr.
+func NewHandler(lgr *logr.Logr, opts Options) *Handler {
+	h := &Handler{
+		lgr:          lgr,
+		reconciler:   opts.Reconciler,
+		flushTimeout: opts.FlushTimeout,
+		mux:          http.NewServeMux(),
+	}
+	if h.flushTimeout <= 0 {
+		h.flushTimeout = DefaultFlushTimeout
+	}
+
+	h.mux.HandleFunc("GET /targets", h.getTargets)
+	h.mux.HandleFunc("GET /targets/{name}", h.getTarget)
+	h.mux.HandleFunc("PUT /targets/{name}/levels", h.putLevels)
+	h.mux.HandleFunc("POST /targets/{name}/pause", h.pause)
+	h.mux.HandleFunc("POST /targets/{name}/resume", h.resume)
+	h.mux.HandleFunc("PUT /targets/{name}/config", h.putTargetConfig)
+	h.mux.HandleFunc("POST /flush", h.flush)
+	h.mux.HandleFunc("GET /config", h.getConfig)
+	h.mux.HandleFunc("PUT /config", h.putConfig)
+	return h
+}
+
+// ServeHTTP dispatches the request to the endpoint for its method and path.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// LevelsRequest is the body of a request to set a target's levels. Level enables the
+// named standard level and all more severe levels; otherwise Levels lists the levels
+// to enable, including custom levels.
+type LevelsRequest struct {
+	Level  string       `json:"level,omitempty"`
+	Levels []logr.Level `json:"levels,omitempty"`
+}
+
+// ErrorResponse is the body of a response for a failed request.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+func (h *Handler) getTargets(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.lgr.TargetStatuses())
+}
+
+func (h *Handler) getTarget(w http.ResponseWriter, r *http.Request) {
+	status, ok := h.targetStatus(r.PathValue("name"))
+	if !ok {
+		writeNotFound(w, r.PathValue("name"))
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+func (h *Handler) putLevels(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if _, ok := h.targetStatus(name); !ok {
+		writeNotFound(w, name)
+		return
+	}
+
+	var req LevelsRequest
+	if err := readJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	levels, err := req.levels()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// keep the change in the reconciler's config so it is not lost on the next apply.
+	if h.reconciler != nil {
+		if tcfg, ok := h.reconciler.Config()[name]; ok {
+			tcfg.Levels = levels
+			if err := h.reconciler.ApplyTarget(name, tcfg); err != nil {
+				writeError(w, http.StatusInternalServerError, err)
+				return
+			}
+			h.writeTarget(w, name)
+			return
+		}
+	}
+
+	if err := h.lgr.SetTargetLevels(name, levels...); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	h.writeTarget(w, name)
+}
+
+func (h *Handler) pause(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if err := h.lgr.PauseTarget(name); err != nil {
+		writeNotFound(w, name)
+		return
+	}
+	h.writeTarget(w, name)
+}
+
+func (h *Handler) resume(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if err := h.lgr.ResumeTarget(name); err != nil {
+		writeNotFound(w, name)
+		return
+	}
+	h.writeTarget(w, name)
+}
+
+func (h *Handler) putTargetConfig(w http.ResponseWriter, r *http.Request) {
+	if !h.checkReconciler(w) {
+		return
+	}
+	name := r.PathValue("name")
+
+	var tcfg config.TargetCfg
+	if err := readJSON(w, r, &tcfg); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if prev, ok := h.reconciler.Config()[name]; ok {
+		tcfg = unmaskTargetCfg(tcfg, prev)
+	}
+	if err := h.reconciler.ApplyTarget(name, tcfg); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if status, ok := h.targetStatus(name); ok {
+		writeJSON(w, http.StatusOK, status)
+		return
+	}
+	// targets of type "none" are not added.
+	writeJSON(w, http.StatusOK, maskTargetCfg(h.reconciler.Config()[name]))
+}
+
+func (h *Handler) flush(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), h.flushTimeout)
+	defer cancel()
+
+	if err := h.lgr.FlushWithTimeout(ctx); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, h.lgr.TargetStatuses())
+}
+
+func (h *Handler) getConfig(w http.ResponseWriter, r *http.Request) {
+	if !h.checkReconciler(w) {
+		return
+	}
+	writeJSON(w, http.StatusOK, maskConfig(h.reconciler.Config()))
+}
+
+func (h *Handler) putConfig(w http.ResponseWriter, r *http.Request) {
+	if !h.checkReconciler(w) {
+		return
+	}
+
+	var cfg map[string]config.TargetCfg
+	if err := readJSON(w, r, &cfg); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	unmaskConfig(cfg, h.reconciler.Config())
+	if err := h.reconciler.Apply(cfg); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, maskConfig(h.reconciler.Config()))
+}
+
+// targetStatus returns the status of the first target with the name.
+func (h *Handler) targetStatus(name string) (logr.TargetStatus, bool) {
+	for _, status := range h.lgr.TargetStatuses() {
+		if status.Name == name {
+			return status, true
+		}
+	}
+	return logr.TargetStatus{}, false
+}
+
+// writeTarget responds with the status of the named target.
+func (h *Handler) writeTarget(w http.ResponseWriter, name string) {
+	status, ok := h.targetStatus(name)
+	if !ok {
+		writeNotFound(w, name) // removed concurrently.
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+func (h *Handler) checkReconciler(w http.ResponseWriter) bool {
+	if h.reconciler == nil {
+		writeError(w, http.StatusNotImplemented, errors.New("config changes require a reconciler"))
+		return false
+	}
+	return true
+}
+
+// levels returns the levels to enable for the request.
+func (req LevelsRequest) levels() ([]logr.Level, error) {
+	if req.Level != "" && len(req.Levels) > 0 {
+		return nil, errors.New("specify either level or levels")
+	}
+
+	if req.Level != "" {
+		var lvl *logr.Level
+		for i := range stdLevels {
+			if strings.EqualFold(req.Level, stdLevels[i].Name) {
+				lvl = &stdLevels[i]
+			}
+		}
+		if lvl == nil {
+			return nil, fmt.Errorf("invalid level '%s'", req.Level)
+		}
+		var levels []logr.Level
+		for _, level := range stdLevels {
+			if level.ID <= lvl.ID {
+				levels = append(levels, level)
+			}
+		}
+		return levels, nil
+	}
+
+	if len(req.Levels) == 0 {
+		return nil, errors.New("no levels specified")
+	}
+	for _, level := range req.Levels {
+		if level.ID > logr.MaxLevelID {
+			return nil, fmt.Errorf("level id %d exceeds max of %d", level.ID, logr.MaxLevelID)
+		}
+	}
+	return req.Levels, nil
+}
+
+// readJSON decodes the request body into v, rejecting unknown fields.
+func readJSON(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, MaxBodySize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, ErrorResponse{Error: err.Error()})
+}
+
+func writeNotFound(w http.ResponseWriter, name string) {
+	writeError(w, http.StatusNotFound, fmt.Errorf("target %s not found", name))
+}
@@ -0,0 +1,197 @@
+package admin_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/logr/v2"
+	"github.com/mattermost/logr/v2/admin"
+	"github.com/mattermost/logr/v2/config"
+	"github.com/mattermost/logr/v2/formatters"
+	"github.com/mattermost/logr/v2/targets"
+	"github.com/mattermost/logr/v2/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlerTargets(t *testing.T) {
+	lgr, err := logr.New()
+	require.NoError(t, err)
+	defer lgr.Shutdown()
+
+	buf := &test.Buffer{}
+	err = lgr.AddTarget(targets.NewWriterTarget(buf), "buf", &logr.StdFilter{Lvl: logr.Info}, &formatters.Plain{}, 100)
+	require.NoError(t, err)
+
+	handler := admin.NewHandler(lgr, admin.Options{})
+	logger := lgr.NewLogger()
+
+	t.Run("list", func(t *testing.T) {
+		var statuses []logr.TargetStatus
+		code := do(t, handler, http.MethodGet, "/targets", "", &statuses)
+		require.Equal(t, http.StatusOK, code)
+		require.Len(t, statuses, 1)
+		assert.Equal(t, "buf", statuses[0].Name)
+		assert.Equal(t, 100, statuses[0].QueueCapacity)
+		assert.True(t, statuses[0].Healthy)
+	})
+
+	t.Run("set level", func(t *testing.T) {
+		var status logr.TargetStatus
+		code := do(t, handler, http.MethodPut, "/targets/buf/levels", `{"level":"debug"}`, &status)
+		require.Equal(t, http.StatusOK, code)
+		assert.Len(t, status.Levels, 6)
+		assert.True(t, logger.IsLevelEnabled(logr.Debug))
+		assert.False(t, logger.IsLevelEnabled(logr.Trace))
+	})
+
+	t.Run("set levels", func(t *testing.T) {
+		var status logr.TargetStatus
+		code := do(t, handler, http.MethodPut, "/targets/buf/levels", `{"levels":[{"id":2,"name":"error"},{"id":100,"name":"audit"}]}`, &status)
+		require.Equal(t, http.StatusOK, code)
+		require.Len(t, status.Levels, 2)
+		assert.Equal(t, "audit", status.Levels[1].Name)
+		assert.False(t, logger.IsLevelEnabled(logr.Info))
+	})
+
+	t.Run("pause and resume", func(t *testing.T) {
+		var status logr.TargetStatus
+		require.Equal(t, http.StatusOK, do(t, handler, http.MethodPost, "/targets/buf/pause", "", &status))
+		assert.True(t, status.Paused)
+		logger.Error("while paused")
+
+		require.Equal(t, http.StatusOK, do(t, handler, http.MethodPost, "/targets/buf/resume", "", &status))
+		assert.False(t, status.Paused)
+		logger.Error("after resume")
+
+		var statuses []logr.TargetStatus
+		require.Equal(t, http.StatusOK, do(t, handler, http.MethodPost, "/flush", "", &statuses))
+		assert.Equal(t, 0, statuses[0].QueueSize)
+		assert.NotContains(t, buf.String(), "while paused")
+		assert.Contains(t, buf.String(), "after resume")
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		var resp admin.ErrorResponse
+		assert.Equal(t, http.StatusNotFound, do(t, handler, http.MethodGet, "/targets/missing", "", &resp))
+		assert.Equal(t, "target missing not found", resp.Error)
+		assert.Equal(t, http.StatusNotFound, do(t, handler, http.MethodPost, "/targets/missing/pause", "", &resp))
+		assert.Equal(t, http.StatusBadRequest, do(t, handler, http.MethodPut, "/targets/buf/levels", `{"level":"loud"}`, &resp))
+		assert.Equal(t, http.StatusBadRequest, do(t, handler, http.MethodPut, "/targets/buf/levels", `{}`, &resp))
+		assert.Equal(t, http.StatusBadRequest, do(t, handler, http.MethodPut, "/targets/buf/levels", `{"lvl":"info"}`, &resp))
+		assert.Equal(t, http.StatusNotImplemented, do(t, handler, http.MethodGet, "/config", "", &resp))
+	})
+}
+
+func TestHandlerLevelsKeepsMatchFilter(t *testing.T) {
+	lgr, err := logr.New()
+	require.NoError(t, err)
+
+	buf := &test.Buffer{}
+	filter := logr.NewMatchFilter(&logr.StdFilter{Lvl: logr.Info}, logr.FieldEquals("tenant", "a"))
+	err = lgr.AddTarget(targets.NewWriterTarget(buf), "buf", filter, &formatters.Plain{}, 100)
+	require.NoError(t, err)
+
+	handler := admin.NewHandler(lgr, admin.Options{})
+	var status logr.TargetStatus
+	require.Equal(t, http.StatusOK, do(t, handler, http.MethodPut, "/targets/buf/levels", `{"level":"debug"}`, &status))
+	assert.Len(t, status.Levels, 6)
+
+	logger := lgr.NewLogger()
+	logger.Debug("debug a", logr.String("tenant", "a"))
+	logger.Debug("debug b", logr.String("tenant", "b"))
+	require.NoError(t, lgr.Shutdown())
+
+	assert.Contains(t, buf.String(), "debug a")
+	assert.NotContains(t, buf.String(), "debug b")
+}
+
+func TestHandlerConfig(t *testing.T) {
+	lgr, err := logr.New()
+	require.NoError(t, err)
+	defer lgr.Shutdown()
+
+	handler := admin.NewHandler(lgr, admin.Options{Reconciler: config.NewReconciler(lgr, nil)})
+
+	var cfg map[string]config.TargetCfg
+	code := do(t, handler, http.MethodPut, "/config", `{
+		"ring": {"type": "ring", "format": "json", "levels": [{"id": 2, "name": "error"}]}
+	}`, &cfg)
+	require.Equal(t, http.StatusOK, code)
+	require.Contains(t, cfg, "ring")
+	assert.Equal(t, "ring", lgr.TargetInfos()[0].Name)
+
+	// level changes are kept in the reconciler's config.
+	var status logr.TargetStatus
+	require.Equal(t, http.StatusOK, do(t, handler, http.MethodPut, "/targets/ring/levels", `{"level":"info"}`, &status))
+	require.Equal(t, http.StatusOK, do(t, handler, http.MethodGet, "/config", "", &cfg))
+	assert.Len(t, cfg["ring"].Levels, 5)
+	ring := lgr.Target("ring")
+
+	require.Equal(t, http.StatusOK, do(t, handler, http.MethodPut, "/targets/ring2/config",
+		`{"type": "ring", "format": "plain", "levels": [{"id": 4, "name": "info"}]}`, &status))
+	assert.Equal(t, "ring2", status.Name)
+	assert.Equal(t, "*formatters.Plain", status.Formatter)
+	assert.Len(t, lgr.TargetInfos(), 2)
+	assert.Same(t, ring, lgr.Target("ring"), "other targets are not recreated")
+
+	var resp admin.ErrorResponse
+	code = do(t, handler, http.MethodPut, "/config", `{"bad": {"type": "ring", "format": "bogus"}}`, &resp)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.NotEmpty(t, resp.Error)
+	assert.Len(t, lgr.TargetInfos(), 2)
+}
+
+func TestHandlerConfigMasksSecrets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	lgr, err := logr.New()
+	require.NoError(t, err)
+	defer lgr.Shutdown()
+
+	handler := admin.NewHandler(lgr, admin.Options{Reconciler: config.NewReconciler(lgr, nil)})
+
+	var cfg map[string]config.TargetCfg
+	code := do(t, handler, http.MethodPut, "/config", `{
+		"web": {"type": "http", "format": "json", "levels": [{"id": 2, "name": "error"}],
+			"options": {"url": "`+server.URL+`", "headers": {"Authorization": "Bearer abc123"}},
+			"redact": {"hash_keys": ["user"], "hash_secret": "s3cret"}}
+	}`, &cfg)
+	require.Equal(t, http.StatusOK, code)
+	web := lgr.Target("web")
+	require.NotNil(t, web)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/config", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	assert.NotContains(t, body, "abc123")
+	assert.NotContains(t, body, "s3cret")
+	assert.Contains(t, body, server.URL)
+	assert.Contains(t, body, "Authorization")
+
+	// a masked config applied again keeps the current secrets.
+	require.Equal(t, http.StatusOK, do(t, handler, http.MethodPut, "/config", body, &cfg))
+	assert.Same(t, web, lgr.Target("web"), "unchanged target is not recreated")
+	assert.Equal(t, logr.RedactedValue, cfg["web"].Redact.HashSecret)
+
+	var status logr.TargetStatus
+	require.Equal(t, http.StatusOK, do(t, handler, http.MethodPut, "/targets/web/levels", `{"level":"info"}`, &status))
+	require.Equal(t, http.StatusOK, do(t, handler, http.MethodGet, "/config", "", &cfg))
+	assert.Equal(t, logr.RedactedValue, cfg["web"].Redact.HashSecret)
+	assert.NotContains(t, string(cfg["web"].Options), "abc123")
+}
+
+// do sends a request to handler and decodes the JSON response into v.
+func do(t *testing.T, handler http.Handler, method string, path string, body string, v interface{}) int {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), v), rec.Body.String())
+	return rec.Code
+}
@@ -0,0 +1,141 @@
+package admin
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/mattermost/logr/v2"
+	"github.com/mattermost/logr/v2/config"
+)
+
+// secretOptionKeys are substrings of target option keys whose values may hold
+// credentials, such as http `headers` or TLS `cert`. Keys are compared lower case.
+var secretOptionKeys = []string{"header", "cert", "key", "password", "passwd", "secret", "token", "auth", "credential"}
+
+func isSecretOptionKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range secretOptionKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// maskConfig returns a copy of cfg with the redaction hash secret and the values of
+// credential-bearing target options replaced by `logr.RedactedValue`, so the config can
+// be returned to clients.
+func maskConfig(cfg map[string]config.TargetCfg) map[string]config.TargetCfg {
+	masked := make(map[string]config.TargetCfg, len(cfg))
+	for name, tcfg := range cfg {
+		masked[name] = maskTargetCfg(tcfg)
+	}
+	return masked
+}
+
+func maskTargetCfg(tcfg config.TargetCfg) config.TargetCfg {
+	if tcfg.Redact != nil && tcfg.Redact.HashSecret != "" {
+		redact := *tcfg.Redact
+		redact.HashSecret = logr.RedactedValue
+		tcfg.Redact = &redact
+	}
+	tcfg.Options = rewriteOptions(tcfg.Options, nil, func(v interface{}, _ interface{}) interface{} {
+		return maskValue(v)
+	})
+	return tcfg
+}
+
+// unmaskConfig replaces values of cfg masked by maskConfig with the values in current,
+// so a config read from the handler can be changed and applied again.
+func unmaskConfig(cfg map[string]config.TargetCfg, current map[string]config.TargetCfg) {
+	for name, tcfg := range cfg {
+		if prev, ok := current[name]; ok {
+			cfg[name] = unmaskTargetCfg(tcfg, prev)
+		}
+	}
+}
+
+func unmaskTargetCfg(tcfg config.TargetCfg, prev config.TargetCfg) config.TargetCfg {
+	if tcfg.Redact != nil && tcfg.Redact.HashSecret == logr.RedactedValue && prev.Redact != nil {
+		redact := *tcfg.Redact
+		redact.HashSecret = prev.Redact.HashSecret
+		tcfg.Redact = &redact
+	}
+	tcfg.Options = rewriteOptions(tcfg.Options, prev.Options, unmaskValue)
+	return tcfg
+}
+
+// rewriteOptions calls fn for the value of each credential-bearing key of a JSON object,
+// passing the value of the same key in prev, if any. Options that are not a JSON object
+// are returned unchanged.
+func rewriteOptions(options json.RawMessage, prev json.RawMessage, fn func(v interface{}, prev interface{}) interface{}) json.RawMessage {
+	var m map[string]interface{}
+	if len(options) == 0 || json.Unmarshal(options, &m) != nil {
+		return options
+	}
+	var pm map[string]interface{}
+	if len(prev) != 0 {
+		_ = json.Unmarshal(prev, &pm)
+	}
+
+	var changed bool
+	for key, v := range m {
+		if isSecretOptionKey(key) {
+			m[key] = fn(v, pm[key])
+			changed = true
+		}
+	}
+	if !changed {
+		return options
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return options
+	}
+	return data
+}
+
+// maskValue replaces all strings within v by `logr.RedactedValue`. Object keys, such
+// as header names, are kept.
+func maskValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		if v == "" {
+			return v
+		}
+		return logr.RedactedValue
+	case map[string]interface{}:
+		for key, val := range v {
+			v[key] = maskValue(val)
+		}
+	case []interface{}:
+		for i, val := range v {
+			v[i] = maskValue(val)
+		}
+	}
+	return v
+}
+
+// unmaskValue replaces strings within v equal to `logr.RedactedValue` by the string at
+// the same position in prev.
+func unmaskValue(v interface{}, prev interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		if p, ok := prev.(string); ok && v == logr.RedactedValue {
+			return p
+		}
+	case map[string]interface{}:
+		pm, _ := prev.(map[string]interface{})
+		for key, val := range v {
+			v[key] = unmaskValue(val, pm[key])
+		}
+	case []interface{}:
+		ps, _ := prev.([]interface{})
+		for i, val := range v {
+			if i < len(ps) {
+				v[i] = unmaskValue(val, ps[i])
+			}
+		}
+	}
+	return v
+}
@@ -0,0 +1,122 @@
+package logr
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
+
+// batch collects formatted log records for a `BatchTarget`.
+type batch struct {
+	target     BatchTarget
+	maxRecords int
+	maxLatency time.Duration
+
+	recs  []*LogRec
+	bufs  []*bytes.Buffer
+	timer *time.Timer
+}
+
+func newBatch(target BatchTarget) *batch {
+	maxRecords, maxLatency := target.BatchSize()
+	if maxRecords < 2 {
+		return nil
+	}
+	return &batch{
+		target:     target,
+		maxRecords: maxRecords,
+		maxLatency: maxLatency,
+		recs:       make([]*LogRec, 0, maxRecords),
+		bufs:       make([]*bytes.Buffer, 0, maxRecords),
+	}
+}
+
+// enabled returns true if records should be batched.
+func (b *batch) enabled() bool {
+	return b != nil
+}
+
+// timeout returns a channel that fires when the oldest record in a partial
+// batch has waited the maximum latency. A nil channel is returned when no
+// records are pending, which blocks forever in a select.
+func (b *batch) timeout() <-chan time.Time {
+	if b == nil || len(b.recs) == 0 || b.timer == nil {
+		return nil
+	}
+	return b.timer.C
+}
+
+// addToBatch formats a log record and adds it to the current batch. The batch
+// is written once full.
+func (h *TargetHost) addToBatch(rec *LogRec) {
+	b := h.batch
+	lgr := rec.Logger().Logr()
+
+	buf, err := h.formatRec(rec, lgr.BorrowBuffer())
+	if err != nil {
+		h.incErrorCounter()
+		h.incFormatErrorCounter()
+		h.setLastError(err)
+		lgr.ReportError(err)
+		return
+	}
+
+	b.recs = append(b.recs, rec)
+	b.bufs = append(b.bufs, buf)
+
+	if len(b.recs) >= b.maxRecords {
+		h.writeBatch()
+		return
+	}
+
+	if len(b.recs) == 1 {
+		if b.timer == nil {
+			b.timer = time.NewTimer(b.maxLatency)
+		} else {
+			b.timer.Reset(b.maxLatency)
+		}
+	}
+}
+
+// writeBatch outputs any pending records to the target.
+func (h *TargetHost) writeBatch() {
+	b := h.batch
+	if b == nil || len(b.recs) == 0 {
+		return
+	}
+
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+
+	lgr := b.recs[0].Logger().Logr()
+
+	bufs := make([][]byte, len(b.bufs))
+	for i, buf := range b.bufs {
+		bufs[i] = buf.Bytes()
+	}
+
+	count := float64(len(b.recs))
+	start := h.writeStart()
+	err := b.target.WriteBatch(b.recs, bufs)
+	h.observeWriteLatency(start)
+	if err != nil {
+		h.addErrorCounter(count)
+		h.addWriteErrorCounter(count)
+		h.setLastError(err)
+		lgr.ReportError(fmt.Errorf("target %s batch write error: %w", h.name, err))
+	} else {
+		h.addLoggedCounter(count)
+		for i, rec := range b.recs {
+			h.addWritten(rec, len(bufs[i]))
+		}
+	}
+
+	for i, buf := range b.bufs {
+		lgr.ReleaseBuffer(buf)
+		b.bufs[i] = nil
+		b.recs[i] = nil
+	}
+	b.bufs = b.bufs[:0]
+	b.recs = b.recs[:0]
+}
@@ -0,0 +1,137 @@
+package logr_test
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr/v2"
+	"github.com/mattermost/logr/v2/formatters"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type batchTarget struct {
+	mux        sync.Mutex
+	buf        bytes.Buffer
+	batches    []int
+	writes     int
+	maxRecords int
+	maxLatency time.Duration
+}
+
+func (bt *batchTarget) Init() error {
+	return nil
+}
+
+func (bt *batchTarget) Write(p []byte, rec *logr.LogRec) (int, error) {
+	bt.mux.Lock()
+	defer bt.mux.Unlock()
+	bt.writes++
+	return bt.buf.Write(p)
+}
+
+func (bt *batchTarget) Shutdown() error {
+	return nil
+}
+
+func (bt *batchTarget) BatchSize() (int, time.Duration) {
+	return bt.maxRecords, bt.maxLatency
+}
+
+func (bt *batchTarget) WriteBatch(recs []*logr.LogRec, bufs [][]byte) error {
+	bt.mux.Lock()
+	defer bt.mux.Unlock()
+	if len(recs) != len(bufs) {
+		panic("recs and bufs length mismatch")
+	}
+	bt.batches = append(bt.batches, len(recs))
+	for _, b := range bufs {
+		bt.buf.Write(b)
+	}
+	return nil
+}
+
+func (bt *batchTarget) get() (string, []int, int) {
+	bt.mux.Lock()
+	defer bt.mux.Unlock()
+	batches := make([]int, len(bt.batches))
+	copy(batches, bt.batches)
+	return bt.buf.String(), batches, bt.writes
+}
+
+func TestBatchTarget(t *testing.T) {
+	formatter := &formatters.Plain{DisableTimestamp: true}
+	filter := &logr.StdFilter{Lvl: logr.Info}
+
+	t.Run("flush writes full and partial batches", func(t *testing.T) {
+		target := &batchTarget{maxRecords: 10, maxLatency: time.Hour}
+		lgr, _ := logr.New()
+		err := lgr.AddTarget(target, "batch", filter, formatter, 1000)
+		require.NoError(t, err)
+
+		logger := lgr.NewLogger()
+		for i := 0; i < 25; i++ {
+			logger.Info("batched", logr.Int("i", i))
+		}
+		require.NoError(t, lgr.Flush())
+
+		output, batches, writes := target.get()
+		assert.Zero(t, writes)
+		total := 0
+		for _, n := range batches {
+			assert.LessOrEqual(t, n, 10)
+			total += n
+		}
+		assert.Equal(t, 25, total)
+		assert.Contains(t, output, "i=0")
+		assert.Contains(t, output, "i=24")
+
+		require.NoError(t, lgr.Shutdown())
+	})
+
+	t.Run("partial batch written after max latency", func(t *testing.T) {
+		target := &batchTarget{maxRecords: 100, maxLatency: 50 * time.Millisecond}
+		lgr, _ := logr.New()
+		err := lgr.AddTarget(target, "batch", filter, formatter, 1000)
+		require.NoError(t, err)
+
+		lgr.NewLogger().Info("waiting for batch")
+
+		require.Eventually(t, func() bool {
+			_, batches, _ := target.get()
+			return len(batches) == 1
+		}, 2*time.Second, 10*time.Millisecond)
+
+		require.NoError(t, lgr.Shutdown())
+	})
+
+	t.Run("shutdown writes partial batch", func(t *testing.T) {
+		target := &batchTarget{maxRecords: 100, maxLatency: time.Hour}
+		lgr, _ := logr.New()
+		err := lgr.AddTarget(target, "batch", filter, formatter, 1000)
+		require.NoError(t, err)
+
+		lgr.NewLogger().Info("last words")
+		require.NoError(t, lgr.Shutdown())
+
+		output, _, _ := target.get()
+		assert.Contains(t, output, "last words")
+	})
+
+	t.Run("batching disabled", func(t *testing.T) {
+		target := &batchTarget{maxRecords: 1}
+		lgr, _ := logr.New()
+		err := lgr.AddTarget(target, "batch", filter, formatter, 1000)
+		require.NoError(t, err)
+
+		lgr.NewLogger().Info("one")
+		lgr.NewLogger().Info("two")
+		require.NoError(t, lgr.Shutdown())
+
+		_, batches, writes := target.get()
+		assert.Empty(t, batches)
+		assert.Equal(t, 2, writes)
+	})
+}
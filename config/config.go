@@ -6,8 +6,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/mattermost/logr/v2"
 	"github.com/mattermost/logr/v2/formatters"
@@ -15,12 +17,71 @@ import (
 )
 
 type TargetCfg struct {
-	Type          string          `json:"type"` // one of "console", "file", "tcp", "syslog", "none".
+	Type          string          `json:"type"` // one of "console", "file", "tcp", "syslog", "journald", "http", "udp", "otlp", "ring", "none".
 	Options       json.RawMessage `json:"options,omitempty"`
-	Format        string          `json:"format"` // one of "json", "plain", "gelf"
+	Format        string          `json:"format"` // one of "json", "plain", "pretty", "gelf", "otlp", "ecs"
 	FormatOptions json.RawMessage `json:"format_options,omitempty"`
 	Levels        []logr.Level    `json:"levels"`
 	MaxQueueSize  int             `json:"maxqueuesize,omitempty"`
+
+	// Spill optionally wraps the target with a disk-backed spill queue so records
+	// survive outages of network targets such as tcp and syslog.
+	Spill *targets.SpillOptions `json:"spill,omitempty"`
+
+	// Match optionally filters records by fields, message or caller, after level filtering.
+	Match *MatchCfg `json:"match,omitempty"`
+
+	// Sampling optionally suppresses records from high volume log sites.
+	Sampling *SamplingCfg `json:"sampling,omitempty"`
+
+	// Redact optionally masks, drops, hashes or scrubs sensitive data before formatting.
+	Redact *RedactCfg `json:"redact,omitempty"`
+
+	// FlightRecorder optionally buffers records at levels not enabled for the target, for
+	// loggers or contexts with a recorder, until a record at a trigger level is logged.
+	FlightRecorder *FlightRecorderCfg `json:"flight_recorder,omitempty"`
+
+	// LevelOverrides, when true, outputs records enabled by a level override even at
+	// levels excluded by `Levels`. See `logr.Logr.SetLevelOverride`.
+	LevelOverrides bool `json:"level_overrides,omitempty"`
+}
+
+// SamplingCfg configures sampling and rate limiting for a target. Sampling outputs the
+// first `First` records with the same level and message per interval, then every
+// `Thereafter`th record. Rate limiting caps the total records per second output to the
+// target. Either can be disabled by leaving its fields zero.
+type SamplingCfg struct {
+	IntervalMillis int64 `json:"interval_millis,omitempty"` // defaults to 1000
+	First          int   `json:"first,omitempty"`
+	Thereafter     int   `json:"thereafter,omitempty"`
+
+	RatePerSecond float64 `json:"rate_per_second,omitempty"`
+	Burst         int     `json:"burst,omitempty"` // defaults to RatePerSecond
+}
+
+func (sc SamplingCfg) CheckValid() error {
+	if sc.IntervalMillis < 0 {
+		return errors.New("interval_millis cannot be less than zero")
+	}
+	if sc.First < 0 || sc.Thereafter < 0 {
+		return errors.New("first and thereafter cannot be less than zero")
+	}
+	if sc.RatePerSecond < 0 {
+		return errors.New("rate_per_second cannot be less than zero")
+	}
+	if sc.Burst < 0 {
+		return errors.New("burst cannot be less than zero")
+	}
+	return nil
+}
+
+// FlightRecorderCfg configures a target to buffer records at levels up to `Capture` that
+// are not enabled, per Logger or context with a recorder, and output them marked as
+// backfill when a record at `Trigger` or more severe is logged through the same Logger
+// or context. See `logr.FlightRecorder`.
+type FlightRecorderCfg struct {
+	Capture *logr.Level `json:"capture,omitempty"` // defaults to trace
+	Trigger *logr.Level `json:"trigger,omitempty"` // defaults to error
 }
 
 type ConsoleOptions struct {
@@ -56,39 +117,173 @@ func ConfigureTargets(lgr *logr.Logr, config map[string]TargetCfg, factories *Fa
 	}
 
 	for name, tcfg := range config {
-		target, err := newTarget(tcfg.Type, tcfg.Options, factories.TargetFactory)
+		bt, err := buildTarget(name, tcfg, factories)
 		if err != nil {
-			return fmt.Errorf("error creating log target %s: %w", name, err)
+			return err
 		}
-
-		if target == nil {
+		if bt == nil {
 			continue
 		}
 
-		formatter, err := newFormatter(tcfg.Format, tcfg.FormatOptions, factories.FormatterFactory)
-		if err != nil {
-			return fmt.Errorf("error creating formatter for log target %s: %w", name, err)
+		if err = bt.add(lgr, name); err != nil {
+			return err
 		}
+	}
+	return nil
+}
+
+// redactingTarget is implemented by targets that output record fields without the
+// formatter, such as `targets.Ring`, and so apply a Redactor themselves.
+type redactingTarget interface {
+	SetRedactor(redactor *logr.Redactor)
+}
+
+// builtTarget holds everything needed to add a target configured via TargetCfg.
+type builtTarget struct {
+	target    logr.Target
+	filter    logr.Filter
+	formatter logr.Formatter
+	qSize     int
+	overrides bool
+}
 
-		filter := newFilter(tcfg.Levels)
-		qSize := tcfg.MaxQueueSize
-		if qSize == 0 {
-			qSize = logr.DefaultMaxQueueSize
+// buildTarget creates the target, filter and formatter for a TargetCfg without
+// initializing the target. Returns nil for targets of type "none".
+func buildTarget(name string, tcfg TargetCfg, factories *Factories) (*builtTarget, error) {
+	target, err := newTarget(tcfg.Type, tcfg.Options, factories.TargetFactory)
+	if err != nil {
+		return nil, fmt.Errorf("error creating log target %s: %w", name, err)
+	}
+
+	if target == nil {
+		return nil, nil
+	}
+
+	formatter, err := newFormatter(tcfg.Format, tcfg.FormatOptions, factories.FormatterFactory)
+	if err != nil {
+		return nil, fmt.Errorf("error creating formatter for log target %s: %w", name, err)
+	}
+
+	// an OTLP formatter without an explicit encoding uses the OTLP target's encoding.
+	if ot, ok := target.(*targets.OTLP); ok {
+		if of, ok := formatter.(*formatters.OTLP); ok && of.Encoding == "" {
+			of.Encoding = ot.Encoding()
+		}
+	}
+
+	// a pretty formatter on the console detects color support from the chosen stream.
+	if pf, ok := formatter.(*formatters.Pretty); ok && pf.Out == nil && strings.EqualFold(tcfg.Type, "console") {
+		c := ConsoleOptions{}
+		if len(tcfg.Options) != 0 {
+			_ = json.Unmarshal(tcfg.Options, &c) // already validated by newTarget
+		}
+		if c.Out == "stderr" {
+			pf.Out = os.Stderr
+		} else {
+			pf.Out = os.Stdout
+		}
+	}
+
+	if tcfg.Redact != nil {
+		redactor, err := newRedactor(*tcfg.Redact)
+		if err != nil {
+			return nil, fmt.Errorf("error creating redactor for log target %s: %w", name, err)
+		}
+		formatter = logr.NewRedactingFormatter(formatter, redactor)
+		// targets that output fields without the formatter redact records themselves.
+		if rt, ok := target.(redactingTarget); ok {
+			rt.SetRedactor(redactor)
 		}
+	}
 
-		if err = lgr.AddTarget(target, name, filter, formatter, qSize); err != nil {
-			return fmt.Errorf("error adding log target %s: %w", name, err)
+	if tcfg.Spill != nil {
+		if target, err = targets.NewSpillTarget(target, name, *tcfg.Spill); err != nil {
+			return nil, fmt.Errorf("error creating spill queue for log target %s: %w", name, err)
 		}
 	}
+
+	filter, err := newFilter(tcfg.Levels, tcfg.Match, tcfg.Sampling, tcfg.FlightRecorder)
+	if err != nil {
+		return nil, fmt.Errorf("error creating filter for log target %s: %w", name, err)
+	}
+	qSize := tcfg.MaxQueueSize
+	if qSize == 0 {
+		qSize = logr.DefaultMaxQueueSize
+	}
+
+	return &builtTarget{
+		target:    target,
+		filter:    filter,
+		formatter: formatter,
+		qSize:     qSize,
+		overrides: tcfg.LevelOverrides,
+	}, nil
+}
+
+func (bt *builtTarget) add(lgr *logr.Logr, name string) error {
+	if err := lgr.AddTarget(bt.target, name, bt.filter, bt.formatter, bt.qSize); err != nil {
+		return fmt.Errorf("error adding log target %s: %w", name, err)
+	}
+	if bt.overrides {
+		return lgr.SetTargetLevelOverrides(name, true)
+	}
 	return nil
 }
 
-func newFilter(levels []logr.Level) logr.Filter {
-	filter := &logr.CustomFilter{}
-	for _, lvl := range levels {
-		filter.Add(lvl)
+func newFilter(levels []logr.Level, match *MatchCfg, sampling *SamplingCfg, recorder *FlightRecorderCfg) (logr.Filter, error) {
+	filter, err := newSampledFilter(levels, match, sampling)
+	if err != nil || recorder == nil {
+		return filter, err
 	}
-	return filter
+
+	capture, trigger := logr.Trace, logr.Error
+	if recorder.Capture != nil {
+		capture = *recorder.Capture
+	}
+	if recorder.Trigger != nil {
+		trigger = *recorder.Trigger
+	}
+	return logr.NewFlightRecorderFilter(filter, capture, trigger), nil
+}
+
+func newSampledFilter(levels []logr.Level, match *MatchCfg, sampling *SamplingCfg) (logr.Filter, error) {
+	var filter logr.Filter = logr.NewCustomFilter(levels...)
+
+	if match != nil {
+		matcher, err := newMatcher(*match)
+		if err != nil {
+			return nil, fmt.Errorf("invalid match: %w", err)
+		}
+		filter = logr.NewMatchFilter(filter, matcher)
+	}
+
+	if sampling == nil {
+		return filter, nil
+	}
+	if err := sampling.CheckValid(); err != nil {
+		return nil, err
+	}
+
+	var samplers []logr.RecordSampler
+	if sampling.First > 0 || sampling.Thereafter > 0 {
+		interval := sampling.IntervalMillis
+		if interval == 0 {
+			interval = 1000
+		}
+		samplers = append(samplers, logr.NewSampler(time.Millisecond*time.Duration(interval), sampling.First, sampling.Thereafter))
+	}
+	if sampling.RatePerSecond > 0 {
+		burst := sampling.Burst
+		if burst == 0 {
+			burst = int(math.Ceil(sampling.RatePerSecond))
+		}
+		samplers = append(samplers, logr.NewRateLimiter(sampling.RatePerSecond, burst))
+	}
+
+	if len(samplers) == 0 {
+		return filter, nil
+	}
+	return logr.NewSampledFilter(filter, samplers...), nil
 }
 
 func newTarget(targetType string, options json.RawMessage, factory TargetFactory) (logr.Target, error) {
@@ -146,6 +341,64 @@ func newTarget(targetType string, options json.RawMessage, factory TargetFactory
 			return nil, fmt.Errorf("invalid SysLog target options: %w", err)
 		}
 		return targets.NewSyslogTarget(&so)
+	case "journald":
+		jo := targets.JournaldOptions{}
+		if len(options) != 0 {
+			if err := json.Unmarshal(options, &jo); err != nil {
+				return nil, fmt.Errorf("error decoding Journald target options: %w", err)
+			}
+		}
+		if err := jo.CheckValid(); err != nil {
+			return nil, fmt.Errorf("invalid Journald target options: %w", err)
+		}
+		return targets.NewJournaldTarget(&jo)
+	case "ring":
+		ro := targets.RingOptions{}
+		if len(options) != 0 {
+			if err := json.Unmarshal(options, &ro); err != nil {
+				return nil, fmt.Errorf("error decoding Ring target options: %w", err)
+			}
+		}
+		if err := ro.CheckValid(); err != nil {
+			return nil, fmt.Errorf("invalid Ring target options: %w", err)
+		}
+		return targets.NewRingTarget(ro), nil
+	case "http":
+		ho := targets.HttpOptions{}
+		if len(options) == 0 {
+			return nil, errors.New("missing HTTP target options")
+		}
+		if err := json.Unmarshal(options, &ho); err != nil {
+			return nil, fmt.Errorf("error decoding HTTP target options: %w", err)
+		}
+		if err := ho.CheckValid(); err != nil {
+			return nil, fmt.Errorf("invalid HTTP target options: %w", err)
+		}
+		return targets.NewHttpTarget(&ho), nil
+	case "udp":
+		uo := targets.UdpOptions{}
+		if len(options) == 0 {
+			return nil, errors.New("missing UDP target options")
+		}
+		if err := json.Unmarshal(options, &uo); err != nil {
+			return nil, fmt.Errorf("error decoding UDP target options: %w", err)
+		}
+		if err := uo.CheckValid(); err != nil {
+			return nil, fmt.Errorf("invalid UDP target options: %w", err)
+		}
+		return targets.NewUdpTarget(&uo), nil
+	case "otlp":
+		oo := targets.OTLPOptions{}
+		if len(options) == 0 {
+			return nil, errors.New("missing OTLP target options")
+		}
+		if err := json.Unmarshal(options, &oo); err != nil {
+			return nil, fmt.Errorf("error decoding OTLP target options: %w", err)
+		}
+		if err := oo.CheckValid(); err != nil {
+			return nil, fmt.Errorf("invalid OTLP target options: %w", err)
+		}
+		return targets.NewOTLPTarget(&oo), nil
 	case "none":
 		return nil, nil
 	default:
@@ -184,6 +437,17 @@ func newFormatter(format string, options json.RawMessage, factory FormatterFacto
 			}
 		}
 		return &p, nil
+	case "pretty":
+		p := &formatters.Pretty{}
+		if len(options) != 0 {
+			if err := json.Unmarshal(options, p); err != nil {
+				return nil, fmt.Errorf("error decoding Pretty formatter options: %w", err)
+			}
+			if err := p.CheckValid(); err != nil {
+				return nil, fmt.Errorf("invalid pretty formatter options: %w", err)
+			}
+		}
+		return p, nil
 	case "gelf":
 		g := formatters.Gelf{}
 		if len(options) != 0 {
@@ -195,6 +459,28 @@ func newFormatter(format string, options json.RawMessage, factory FormatterFacto
 			}
 		}
 		return &g, nil
+	case "otlp":
+		o := formatters.OTLP{}
+		if len(options) != 0 {
+			if err := json.Unmarshal(options, &o); err != nil {
+				return nil, fmt.Errorf("error decoding OTLP formatter options: %w", err)
+			}
+			if err := o.CheckValid(); err != nil {
+				return nil, fmt.Errorf("invalid OTLP formatter options: %w", err)
+			}
+		}
+		return &o, nil
+	case "ecs":
+		e := formatters.ECS{}
+		if len(options) != 0 {
+			if err := json.Unmarshal(options, &e); err != nil {
+				return nil, fmt.Errorf("error decoding ECS formatter options: %w", err)
+			}
+			if err := e.CheckValid(); err != nil {
+				return nil, fmt.Errorf("invalid ECS formatter options: %w", err)
+			}
+		}
+		return &e, nil
 
 	default:
 		if factory != nil {
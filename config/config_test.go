@@ -5,6 +5,10 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
 
 	"github.com/mattermost/logr/v2"
@@ -115,3 +119,178 @@ func customFormatFactory(format string, options json.RawMessage) (logr.Formatter
 	return &formatters.Plain{Delim: " / "}, nil
 
 }
+
+func TestConfigureHttpTarget(t *testing.T) {
+	buf := &test.Buffer{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(buf, r.Body)
+	}))
+	defer server.Close()
+
+	str := fmt.Sprintf(`{    "sample-http": {
+        "type": "http",
+        "options": {
+            "url": "%s",
+            "batch_size": 5
+        },
+        "format": "json",
+        "levels": [
+            {"id": 4, "name": "info"}
+        ]
+    } }`, server.URL)
+
+	var cfg map[string]TargetCfg
+	err := json.Unmarshal([]byte(str), &cfg)
+	require.NoError(t, err, "should unmarshall without error")
+
+	lgr, err := logr.New()
+	require.NoError(t, err)
+
+	err = ConfigureTargets(lgr, cfg, nil)
+	require.NoError(t, err)
+
+	lgr.NewLogger().Info("Unique http", logr.String("test", "posted"))
+
+	err = lgr.Shutdown()
+	require.NoError(t, err)
+
+	assert.Contains(t, buf.String(), "Unique http")
+	assert.Contains(t, buf.String(), "posted")
+}
+
+func TestConfigureOTLPTarget(t *testing.T) {
+	buf := &test.Buffer{}
+	var contentType atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType.Store(r.Header.Get("Content-Type"))
+		_, _ = io.Copy(buf, r.Body)
+	}))
+	defer server.Close()
+
+	// the formatter inherits the target's encoding.
+	str := fmt.Sprintf(`{    "sample-otlp": {
+        "type": "otlp",
+        "options": {
+            "url": "%s",
+            "encoding": "protobuf",
+            "service_name": "config-test"
+        },
+        "format": "otlp",
+        "levels": [
+            {"id": 4, "name": "info"}
+        ]
+    } }`, server.URL)
+
+	var cfg map[string]TargetCfg
+	err := json.Unmarshal([]byte(str), &cfg)
+	require.NoError(t, err, "should unmarshall without error")
+
+	lgr, err := logr.New()
+	require.NoError(t, err)
+
+	err = ConfigureTargets(lgr, cfg, nil)
+	require.NoError(t, err)
+
+	lgr.NewLogger().Info("Unique otlp", logr.String("test", "exported"))
+
+	err = lgr.Shutdown()
+	require.NoError(t, err)
+
+	assert.Equal(t, targets.ContentTypeProtobuf, contentType.Load())
+	assert.Contains(t, buf.String(), "Unique otlp")
+	assert.Contains(t, buf.String(), "config-test")
+}
+
+func TestConfigureSampling(t *testing.T) {
+	str := `{    "sampled": {
+        "type": "custom",
+        "format": "plain",
+        "format_options": {"disable_timestamp": true},
+        "levels": [
+            {"id": 5, "name": "debug"}
+        ],
+        "sampling": {
+            "interval_millis": 60000,
+            "first": 2,
+            "thereafter": 0
+        }
+    } }`
+
+	var cfg map[string]TargetCfg
+	err := json.Unmarshal([]byte(str), &cfg)
+	require.NoError(t, err, "should unmarshall without error")
+
+	buf := &test.Buffer{}
+	factories := &Factories{
+		TargetFactory: func(targetType string, options json.RawMessage) (logr.Target, error) {
+			return targets.NewWriterTarget(buf), nil
+		},
+	}
+
+	lgr, err := logr.New()
+	require.NoError(t, err)
+
+	err = ConfigureTargets(lgr, cfg, factories)
+	require.NoError(t, err)
+
+	logger := lgr.NewLogger()
+	for i := 0; i < 10; i++ {
+		logger.Debug("retrying")
+	}
+
+	err = lgr.Shutdown()
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, strings.Count(buf.String(), "retrying"))
+}
+
+func TestSamplingCfgInvalid(t *testing.T) {
+	_, err := newFilter(nil, nil, &SamplingCfg{RatePerSecond: -1}, nil)
+	require.Error(t, err)
+}
+
+func TestConfigureFlightRecorder(t *testing.T) {
+	str := `{    "recorded": {
+        "type": "custom",
+        "format": "plain",
+        "format_options": {"disable_timestamp": true},
+        "levels": [
+            {"id": 4, "name": "info"},
+            {"id": 3, "name": "warn"},
+            {"id": 2, "name": "error"}
+        ],
+        "flight_recorder": {
+            "capture": {"id": 5, "name": "debug"},
+            "trigger": {"id": 3, "name": "warn"}
+        }
+    } }`
+
+	var cfg map[string]TargetCfg
+	err := json.Unmarshal([]byte(str), &cfg)
+	require.NoError(t, err, "should unmarshall without error")
+
+	buf := &test.Buffer{}
+	factories := &Factories{
+		TargetFactory: func(targetType string, options json.RawMessage) (logr.Target, error) {
+			return targets.NewWriterTarget(buf), nil
+		},
+	}
+
+	lgr, err := logr.New()
+	require.NoError(t, err)
+
+	err = ConfigureTargets(lgr, cfg, factories)
+	require.NoError(t, err)
+
+	logger := lgr.NewLogger().WithRecorder(10)
+	logger.Trace("not captured")
+	logger.Debug("cache miss")
+	logger.Warn("slow request")
+
+	err = lgr.Shutdown()
+	require.NoError(t, err)
+
+	assert.NotContains(t, buf.String(), "not captured")
+	assert.Contains(t, buf.String(), "debug cache miss backfill=true")
+	assert.Less(t, strings.Index(buf.String(), "cache miss"), strings.Index(buf.String(), "slow request"))
+}
@@ -0,0 +1,52 @@
+//go:build linux
+// +build linux
+
+package config
+
+import (
+	"fmt"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigureRedactJournald(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	cfg := parseCfg(t, fmt.Sprintf(`{
+		"journal": {"type": "journald", "options": {"socket_path": %q}, "format": "plain",
+			"levels": [{"id": 4, "name": "info"}],
+			"redact": {"drop_keys": ["email"], "mask_keys": ["*password*"], "scrub": [{"builtin": "email"}]}}
+	}`, path))
+
+	lgr, err := logr.New()
+	require.NoError(t, err)
+	require.NoError(t, ConfigureTargets(lgr, cfg, nil))
+
+	lgr.NewLogger().Info("user bob@example.com logged in",
+		logr.String("email", "bob@example.com"),
+		logr.String("password", "hunter2"),
+		logr.String("user", "bob"),
+	)
+	require.NoError(t, lgr.Shutdown())
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	buf := make([]byte, 64*1024)
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	entry := string(buf[:n])
+
+	assert.NotContains(t, entry, "bob@example.com")
+	assert.NotContains(t, entry, "hunter2")
+	assert.NotContains(t, entry, "EMAIL=")
+	assert.Contains(t, entry, "PASSWORD="+logr.RedactedValue)
+	assert.Contains(t, entry, "USER=bob")
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/mattermost/logr/v2"
+)
+
+// MatchCfg configures record-level filtering for a target. Each MatchCfg is exactly one
+// of: a composition via `and`, `or` or `not`; a field match via `field` with optional
+// `equals` or `regex` (field presence if neither); a message match via `msg_regex`; or a
+// caller match via `caller_prefix`.
+//
+// For example, to output only plugin records that are not health checks:
+//
+//	"match": {"and": [
+//	    {"field": "component", "equals": "plugin"},
+//	    {"not": {"msg_regex": "^health check"}}
+//	]}
+type MatchCfg struct {
+	And []MatchCfg `json:"and,omitempty"`
+	Or  []MatchCfg `json:"or,omitempty"`
+	Not *MatchCfg  `json:"not,omitempty"`
+
+	Field  string  `json:"field,omitempty"`
+	Equals *string `json:"equals,omitempty"`
+	Regex  string  `json:"regex,omitempty"`
+
+	MsgRegex     string `json:"msg_regex,omitempty"`
+	CallerPrefix string `json:"caller_prefix,omitempty"`
+}
+
+// newMatcher creates a RecordMatcher from the config.
+func newMatcher(mc MatchCfg) (logr.RecordMatcher, error) {
+	var kinds int
+	for _, set := range []bool{mc.And != nil, mc.Or != nil, mc.Not != nil, mc.Field != "", mc.MsgRegex != "", mc.CallerPrefix != ""} {
+		if set {
+			kinds++
+		}
+	}
+	if kinds != 1 {
+		return nil, errors.New("match must specify exactly one of and, or, not, field, msg_regex, caller_prefix")
+	}
+	if mc.Field == "" && (mc.Equals != nil || mc.Regex != "") {
+		return nil, errors.New("equals and regex require field")
+	}
+
+	switch {
+	case mc.And != nil:
+		matchers, err := newMatchers(mc.And)
+		if err != nil {
+			return nil, err
+		}
+		return logr.And(matchers...), nil
+
+	case mc.Or != nil:
+		matchers, err := newMatchers(mc.Or)
+		if err != nil {
+			return nil, err
+		}
+		return logr.Or(matchers...), nil
+
+	case mc.Not != nil:
+		matcher, err := newMatcher(*mc.Not)
+		if err != nil {
+			return nil, err
+		}
+		return logr.Not(matcher), nil
+
+	case mc.Field != "":
+		if mc.Equals != nil && mc.Regex != "" {
+			return nil, fmt.Errorf("field %s cannot specify both equals and regex", mc.Field)
+		}
+		if mc.Equals != nil {
+			return logr.FieldEquals(mc.Field, *mc.Equals), nil
+		}
+		if mc.Regex != "" {
+			re, err := regexp.Compile(mc.Regex)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regex for field %s: %w", mc.Field, err)
+			}
+			return logr.FieldRegex(mc.Field, re), nil
+		}
+		return logr.FieldExists(mc.Field), nil
+
+	case mc.MsgRegex != "":
+		re, err := regexp.Compile(mc.MsgRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid msg_regex: %w", err)
+		}
+		return logr.MsgRegex(re), nil
+
+	default:
+		return logr.CallerPackagePrefix(mc.CallerPrefix), nil
+	}
+}
+
+func newMatchers(cfgs []MatchCfg) ([]logr.RecordMatcher, error) {
+	if len(cfgs) == 0 {
+		return nil, errors.New("and/or requires at least one match")
+	}
+	matchers := make([]logr.RecordMatcher, 0, len(cfgs))
+	for _, mc := range cfgs {
+		matcher, err := newMatcher(mc)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, matcher)
+	}
+	return matchers, nil
+}
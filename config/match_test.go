@@ -0,0 +1,78 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mattermost/logr/v2"
+	"github.com/mattermost/logr/v2/targets"
+	"github.com/mattermost/logr/v2/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigureMatch(t *testing.T) {
+	str := `{    "plugins": {
+        "type": "custom",
+        "format": "plain",
+        "levels": [
+            {"id": 4, "name": "info"}
+        ],
+        "match": {"and": [
+            {"field": "component", "equals": "plugin"},
+            {"not": {"msg_regex": "^health check"}}
+        ]}
+    } }`
+
+	var cfg map[string]TargetCfg
+	err := json.Unmarshal([]byte(str), &cfg)
+	require.NoError(t, err, "should unmarshall without error")
+
+	buf := &test.Buffer{}
+	factories := &Factories{
+		TargetFactory: func(targetType string, options json.RawMessage) (logr.Target, error) {
+			return targets.NewWriterTarget(buf), nil
+		},
+	}
+
+	lgr, err := logr.New()
+	require.NoError(t, err)
+
+	err = ConfigureTargets(lgr, cfg, factories)
+	require.NoError(t, err)
+
+	plugin := lgr.NewLogger().With(logr.String("component", "plugin"))
+	plugin.Info("plugin activated")
+	plugin.Info("health check ok")
+	lgr.NewLogger().Info("server started")
+
+	err = lgr.Shutdown()
+	require.NoError(t, err)
+
+	output := buf.String()
+	assert.Contains(t, output, "plugin activated")
+	assert.NotContains(t, output, "health check ok")
+	assert.NotContains(t, output, "server started")
+}
+
+func TestNewMatcherInvalid(t *testing.T) {
+	eq := "x"
+	tests := []struct {
+		name string
+		cfg  MatchCfg
+	}{
+		{"empty", MatchCfg{}},
+		{"multiple kinds", MatchCfg{Field: "a", MsgRegex: "b"}},
+		{"equals without field", MatchCfg{Equals: &eq, MsgRegex: "b"}},
+		{"equals and regex", MatchCfg{Field: "a", Equals: &eq, Regex: "b"}},
+		{"bad regex", MatchCfg{MsgRegex: "("}},
+		{"empty and", MatchCfg{And: []MatchCfg{}}},
+		{"nested invalid", MatchCfg{Not: &MatchCfg{}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newMatcher(tt.cfg)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,307 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/mattermost/logr/v2"
+)
+
+// DefaultWatchIntervalMillis is the default interval at which a watched config file is checked for changes.
+const DefaultWatchIntervalMillis = 2000
+
+// Reconciler applies target configurations to a Logr, changing only what differs from the
+// previously applied configuration. Unlike `ConfigureTargets`, which removes and recreates
+// every target, unchanged targets keep running with their queued records, connections and
+// open files intact:
+//   - targets whose type, options, format, queue size, spill queue or redaction changed are replaced,
+//   - targets whose levels, match, sampling or flight recorder changed have their filter swapped
+//     in place, and level_overrides changes are applied in place,
+//   - targets no longer in the config are removed and new ones are added.
+//
+// A Reconciler manages only the targets it added, plus any existing targets with the same
+// name as a configured target, which are replaced on first apply.
+type Reconciler struct {
+	lgr       *logr.Logr
+	factories *Factories
+
+	mux     sync.Mutex
+	current map[string]TargetCfg
+}
+
+// NewReconciler creates a Reconciler for the Logr. An optional set of factories can be
+// provided which will be called to create any target types or formatters not built-in.
+func NewReconciler(lgr *logr.Logr, factories *Factories) *Reconciler {
+	if factories == nil {
+		factories = &Factories{nil, nil}
+	}
+	return &Reconciler{
+		lgr:       lgr,
+		factories: factories,
+		current:   make(map[string]TargetCfg),
+	}
+}
+
+// Apply reconciles the running targets with config. All new targets, formatters and filters
+// are created before any running target is changed, so a config with errors leaves the
+// running targets untouched.
+func (r *Reconciler) Apply(config map[string]TargetCfg) error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return r.apply(config)
+}
+
+// ApplyTarget reconciles a single target with tcfg, leaving the other targets of the
+// current config unchanged. The target is added if not in the current config.
+func (r *Reconciler) ApplyTarget(name string, tcfg TargetCfg) error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	config := make(map[string]TargetCfg, len(r.current)+1)
+	for n, c := range r.current {
+		config[n] = c
+	}
+	config[name] = tcfg
+	return r.apply(config)
+}
+
+// Config returns a copy of the most recently applied config.
+func (r *Reconciler) Config() map[string]TargetCfg {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	config := make(map[string]TargetCfg, len(r.current))
+	for name, tcfg := range r.current {
+		config[name] = tcfg
+	}
+	return config
+}
+
+func (r *Reconciler) apply(config map[string]TargetCfg) error {
+	running := make(map[string]struct{})
+	for _, ti := range r.lgr.TargetInfos() {
+		running[ti.Name] = struct{}{}
+	}
+
+	added := make(map[string]*builtTarget)  // new or replaced targets.
+	filters := make(map[string]logr.Filter) // filter changes only.
+	overrides := make(map[string]bool)      // level override opt-in changes only.
+	remove := make(map[string]struct{})
+
+	for name, tcfg := range config {
+		prev, managed := r.current[name]
+		_, exists := running[name]
+
+		switch {
+		case managed && exists && !targetChanged(prev, tcfg):
+			if filterChanged(prev, tcfg) {
+				filter, err := newFilter(tcfg.Levels, tcfg.Match, tcfg.Sampling, tcfg.FlightRecorder)
+				if err != nil {
+					return fmt.Errorf("error creating filter for log target %s: %w", name, err)
+				}
+				filters[name] = filter
+			}
+			if prev.LevelOverrides != tcfg.LevelOverrides {
+				overrides[name] = tcfg.LevelOverrides
+			}
+		default:
+			bt, err := buildTarget(name, tcfg, r.factories)
+			if err != nil {
+				return err
+			}
+			if exists {
+				remove[name] = struct{}{}
+			}
+			added[name] = bt // nil for targets of type "none".
+		}
+	}
+
+	for name := range r.current {
+		if _, ok := config[name]; !ok {
+			remove[name] = struct{}{}
+		}
+	}
+
+	var errs []error
+	failed := make(map[string]struct{}) // targets not added, so not stored in current.
+	kept := make(map[string]struct{})   // targets whose filter could not be changed.
+
+	if len(remove) > 0 {
+		err := r.lgr.RemoveTargets(context.Background(), func(ti logr.TargetInfo) bool {
+			_, ok := remove[ti.Name]
+			return ok
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error removing log targets: %w", err))
+		}
+	}
+
+	for name, bt := range added {
+		if bt == nil {
+			continue
+		}
+		if err := bt.add(r.lgr, name); err != nil {
+			errs = append(errs, err)
+			failed[name] = struct{}{}
+		}
+	}
+
+	for name, filter := range filters {
+		if err := r.lgr.SetTargetFilter(name, filter); err != nil {
+			errs = append(errs, fmt.Errorf("error setting filter for log target %s: %w", name, err))
+			kept[name] = struct{}{}
+		}
+	}
+
+	for name, accept := range overrides {
+		if err := r.lgr.SetTargetLevelOverrides(name, accept); err != nil {
+			errs = append(errs, fmt.Errorf("error setting level overrides for log target %s: %w", name, err))
+			kept[name] = struct{}{}
+		}
+	}
+
+	// only record what was applied, so failed targets are retried by the next apply.
+	current := make(map[string]TargetCfg, len(config))
+	for name, tcfg := range config {
+		if _, ok := failed[name]; ok {
+			continue
+		}
+		if _, ok := kept[name]; ok {
+			tcfg = r.current[name]
+		}
+		current[name] = tcfg
+	}
+	r.current = current
+
+	if len(errs) > 0 {
+		return fmt.Errorf("error reconciling log targets: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// targetChanged returns true if the target must be recreated to apply cfg.
+func targetChanged(prev, cfg TargetCfg) bool {
+	return prev.Type != cfg.Type ||
+		!jsonEqual(prev.Options, cfg.Options) ||
+		prev.Format != cfg.Format ||
+		!jsonEqual(prev.FormatOptions, cfg.FormatOptions) ||
+		prev.MaxQueueSize != cfg.MaxQueueSize ||
+		!reflect.DeepEqual(prev.Spill, cfg.Spill) ||
+		!reflect.DeepEqual(prev.Redact, cfg.Redact)
+}
+
+// filterChanged returns true if the target's filter must be replaced to apply cfg.
+func filterChanged(prev, cfg TargetCfg) bool {
+	return !reflect.DeepEqual(prev.Levels, cfg.Levels) ||
+		!reflect.DeepEqual(prev.Match, cfg.Match) ||
+		!reflect.DeepEqual(prev.Sampling, cfg.Sampling) ||
+		!reflect.DeepEqual(prev.FlightRecorder, cfg.FlightRecorder)
+}
+
+// jsonEqual compares two JSON documents, ignoring formatting and key order.
+func jsonEqual(a, b json.RawMessage) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+	var va, vb interface{}
+	if len(bytes.TrimSpace(a)) > 0 {
+		if err := json.Unmarshal(a, &va); err != nil {
+			return false
+		}
+	}
+	if len(bytes.TrimSpace(b)) > 0 {
+		if err := json.Unmarshal(b, &vb); err != nil {
+			return false
+		}
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+// FileWatcher reapplies a JSON config file via a Reconciler whenever the file changes.
+type FileWatcher struct {
+	reconciler *Reconciler
+	path       string
+	interval   time.Duration
+
+	lastData []byte
+
+	quit chan struct{}
+	done chan struct{}
+}
+
+// WatchFile applies the JSON config file at path, a map of name->TargetCfg, and then
+// checks the file for changes every intervalMillis (DefaultWatchIntervalMillis if zero),
+// reapplying it whenever its contents change. Errors after the initial apply, such as an
+// invalid config, are reported via the Logr's `OnLoggerError` handler and the previous
+// config remains in effect.
+func (r *Reconciler) WatchFile(path string, intervalMillis int64) (*FileWatcher, error) {
+	if intervalMillis <= 0 {
+		intervalMillis = DefaultWatchIntervalMillis
+	}
+
+	w := &FileWatcher{
+		reconciler: r,
+		path:       path,
+		interval:   time.Millisecond * time.Duration(intervalMillis),
+		quit:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+
+	if err := w.check(); err != nil {
+		return nil, err
+	}
+
+	go w.start()
+	return w, nil
+}
+
+// Stop stops watching the file. The applied config remains in effect.
+func (w *FileWatcher) Stop() {
+	select {
+	case <-w.quit:
+	default:
+		close(w.quit)
+	}
+	<-w.done
+}
+
+func (w *FileWatcher) start() {
+	defer close(w.done)
+
+	for {
+		select {
+		case <-w.quit:
+			return
+		case <-time.After(w.interval):
+			if err := w.check(); err != nil {
+				w.reconciler.lgr.ReportError(fmt.Errorf("error applying log config %s: %w", w.path, err))
+			}
+		}
+	}
+}
+
+// check reads the file and applies it if the contents changed since the last check.
+func (w *FileWatcher) check() error {
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		return err
+	}
+	if w.lastData != nil && bytes.Equal(data, w.lastData) {
+		return nil
+	}
+	// don't retry the same bad contents every interval.
+	w.lastData = data
+
+	var config map[string]TargetCfg
+	if err := json.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("invalid json: %w", err)
+	}
+	return w.reconciler.Apply(config)
+}
@@ -0,0 +1,287 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconcilerApply(t *testing.T) {
+	lgr, err := logr.New()
+	require.NoError(t, err)
+	defer lgr.Shutdown()
+
+	tf := &trackingFactory{}
+	r := NewReconciler(lgr, &Factories{TargetFactory: tf.create})
+
+	cfg := parseCfg(t, `{
+		"a": {"type": "tracking", "options": {"id": 1}, "format": "plain", "levels": [{"id": 4, "name": "info"}]},
+		"b": {"type": "tracking", "options": {"id": 2}, "format": "plain", "levels": [{"id": 4, "name": "info"}]}
+	}`)
+	require.NoError(t, r.Apply(cfg))
+	require.Len(t, tf.all(), 2)
+	assert.ElementsMatch(t, []string{"a", "b"}, targetNames(lgr))
+
+	logger := lgr.NewLogger()
+	assert.False(t, logger.IsLevelEnabled(logr.Debug))
+
+	t.Run("level change swaps filter in place", func(t *testing.T) {
+		cfg := parseCfg(t, `{
+			"a": {"type": "tracking", "options": {"id": 1}, "format": "plain", "levels": [{"id": 4, "name": "info"}, {"id": 5, "name": "debug"}]},
+			"b": {"type": "tracking", "options": { "id" : 2 }, "format": "plain", "levels": [{"id": 4, "name": "info"}]}
+		}`)
+		require.NoError(t, r.Apply(cfg))
+		require.Len(t, tf.all(), 2, "no targets should be recreated")
+		for _, tgt := range tf.all() {
+			assert.False(t, tgt.isShutdown())
+		}
+
+		assert.True(t, logger.IsLevelEnabled(logr.Debug))
+		logger.Debug("debug after reconcile")
+		require.NoError(t, lgr.Flush())
+		assert.Contains(t, tf.all()[0].output()+tf.all()[1].output(), "debug after reconcile")
+	})
+
+	t.Run("options change replaces target", func(t *testing.T) {
+		cfg := parseCfg(t, `{
+			"a": {"type": "tracking", "options": {"id": 3}, "format": "plain", "levels": [{"id": 4, "name": "info"}]},
+			"b": {"type": "tracking", "options": {"id": 2}, "format": "plain", "levels": [{"id": 4, "name": "info"}]}
+		}`)
+		require.NoError(t, r.Apply(cfg))
+		targets := tf.all()
+		require.Len(t, targets, 3)
+		assert.True(t, targets[0].isShutdown() || targets[1].isShutdown(), "old target should be shut down")
+		assert.False(t, targets[2].isShutdown())
+		assert.ElementsMatch(t, []string{"a", "b"}, targetNames(lgr))
+	})
+
+	t.Run("removed target is shut down", func(t *testing.T) {
+		cfg := parseCfg(t, `{
+			"a": {"type": "tracking", "options": {"id": 3}, "format": "plain", "levels": [{"id": 4, "name": "info"}]}
+		}`)
+		require.NoError(t, r.Apply(cfg))
+		assert.Equal(t, []string{"a"}, targetNames(lgr))
+		assert.Len(t, tf.all(), 3)
+	})
+
+	t.Run("invalid config leaves targets untouched", func(t *testing.T) {
+		cfg := parseCfg(t, `{
+			"a": {"type": "tracking", "options": {"id": 3}, "format": "bogus", "levels": [{"id": 4, "name": "info"}]},
+			"c": {"type": "tracking", "options": {"id": 4}, "format": "plain", "levels": [{"id": 4, "name": "info"}]}
+		}`)
+		require.Error(t, r.Apply(cfg))
+		assert.Equal(t, []string{"a"}, targetNames(lgr))
+		assert.False(t, tf.all()[2].isShutdown())
+	})
+}
+
+func TestReconcilerAddFailure(t *testing.T) {
+	lgr, err := logr.New()
+	require.NoError(t, err)
+	defer lgr.Shutdown()
+
+	tf := &trackingFactory{}
+	r := NewReconciler(lgr, &Factories{TargetFactory: tf.create})
+
+	cfg := parseCfg(t, `{
+		"a": {"type": "tracking", "options": {"id": 1}, "format": "plain", "levels": [{"id": 4, "name": "info"}]}
+	}`)
+	require.NoError(t, r.Apply(cfg))
+
+	// the replacement target fails to initialize, so the old target is removed and
+	// the new config is not recorded.
+	tf.mux.Lock()
+	tf.initErr = errors.New("init failed")
+	tf.mux.Unlock()
+	cfg2 := parseCfg(t, `{
+		"a": {"type": "tracking", "options": {"id": 2}, "format": "plain", "levels": [{"id": 4, "name": "info"}]}
+	}`)
+	require.Error(t, r.Apply(cfg2))
+	assert.Empty(t, targetNames(lgr))
+	assert.NotContains(t, r.Config(), "a")
+
+	// applying the same config again retries the target.
+	tf.mux.Lock()
+	tf.initErr = nil
+	tf.mux.Unlock()
+	require.NoError(t, r.Apply(cfg2))
+	assert.Equal(t, []string{"a"}, targetNames(lgr))
+	assert.Contains(t, r.Config(), "a")
+
+	// a managed target removed outside the reconciler is added again.
+	require.NoError(t, lgr.RemoveTargets(context.Background(), func(ti logr.TargetInfo) bool { return true }))
+	require.NoError(t, r.Apply(cfg2))
+	assert.Equal(t, []string{"a"}, targetNames(lgr))
+}
+
+func TestReconcilerLevelOverrides(t *testing.T) {
+	lgr, err := logr.New()
+	require.NoError(t, err)
+	defer lgr.Shutdown()
+
+	tf := &trackingFactory{}
+	r := NewReconciler(lgr, &Factories{TargetFactory: tf.create})
+	require.NoError(t, lgr.SetLevelOverride("store", logr.Debug))
+	logger := lgr.NewLogger().Named("store")
+
+	cfg := parseCfg(t, `{
+		"a": {"type": "tracking", "format": "plain", "levels": [{"id": 4, "name": "info"}], "level_overrides": true}
+	}`)
+	require.NoError(t, r.Apply(cfg))
+	logger.Debug("opted in")
+	require.NoError(t, lgr.Flush())
+
+	cfg = parseCfg(t, `{
+		"a": {"type": "tracking", "format": "plain", "levels": [{"id": 4, "name": "info"}]}
+	}`)
+	require.NoError(t, r.Apply(cfg))
+	logger.Debug("opted out")
+	require.NoError(t, lgr.Flush())
+
+	require.Len(t, tf.all(), 1, "target should not be recreated")
+	assert.Contains(t, tf.all()[0].output(), "opted in")
+	assert.NotContains(t, tf.all()[0].output(), "opted out")
+}
+
+func TestReconcilerApplyTarget(t *testing.T) {
+	lgr, err := logr.New()
+	require.NoError(t, err)
+	defer lgr.Shutdown()
+
+	tf := &trackingFactory{}
+	r := NewReconciler(lgr, &Factories{TargetFactory: tf.create})
+
+	cfg := parseCfg(t, `{
+		"a": {"type": "tracking", "options": {"id": 1}, "format": "plain", "levels": [{"id": 4, "name": "info"}]}
+	}`)
+	require.NoError(t, r.Apply(cfg))
+
+	tcfg := parseCfg(t, `{
+		"b": {"type": "tracking", "options": {"id": 2}, "format": "plain", "levels": [{"id": 5, "name": "debug"}]}
+	}`)["b"]
+	require.NoError(t, r.ApplyTarget("b", tcfg))
+	assert.ElementsMatch(t, []string{"a", "b"}, targetNames(lgr))
+	require.Len(t, tf.all(), 2)
+	assert.True(t, lgr.NewLogger().IsLevelEnabled(logr.Debug))
+
+	current := r.Config()
+	require.Len(t, current, 2)
+	assert.Equal(t, tcfg, current["b"])
+
+	// the returned config is a copy.
+	delete(current, "a")
+	assert.Len(t, r.Config(), 2)
+}
+
+func TestReconcilerWatchFile(t *testing.T) {
+	lgr, err := logr.New(logr.OnLoggerError(func(error) {}))
+	require.NoError(t, err)
+	defer lgr.Shutdown()
+
+	tf := &trackingFactory{}
+	r := NewReconciler(lgr, &Factories{TargetFactory: tf.create})
+
+	path := filepath.Join(t.TempDir(), "logging.json")
+	writeFile := func(data string) {
+		require.NoError(t, os.WriteFile(path, []byte(data), 0600))
+	}
+
+	writeFile(`{"a": {"type": "tracking", "format": "plain", "levels": [{"id": 4, "name": "info"}]}}`)
+
+	w, err := r.WatchFile(path, 10)
+	require.NoError(t, err)
+	defer w.Stop()
+
+	logger := lgr.NewLogger()
+	assert.Equal(t, []string{"a"}, targetNames(lgr))
+	assert.False(t, logger.IsLevelEnabled(logr.Debug))
+
+	writeFile(`{"a": {"type": "tracking", "format": "plain", "levels": [{"id": 4, "name": "info"}, {"id": 5, "name": "debug"}]}}`)
+	require.Eventually(t, func() bool { return logger.IsLevelEnabled(logr.Debug) }, time.Second*5, time.Millisecond*10)
+	assert.Len(t, tf.all(), 1)
+
+	// invalid json is ignored.
+	writeFile(`{"a": `)
+	time.Sleep(time.Millisecond * 50)
+	assert.True(t, logger.IsLevelEnabled(logr.Debug))
+	assert.Equal(t, []string{"a"}, targetNames(lgr))
+}
+
+func parseCfg(t *testing.T, s string) map[string]TargetCfg {
+	var cfg map[string]TargetCfg
+	require.NoError(t, json.Unmarshal([]byte(s), &cfg))
+	return cfg
+}
+
+func targetNames(lgr *logr.Logr) []string {
+	var names []string
+	for _, ti := range lgr.TargetInfos() {
+		names = append(names, ti.Name)
+	}
+	return names
+}
+
+// trackingFactory creates targets and remembers them in creation order.
+type trackingFactory struct {
+	mux     sync.Mutex
+	targets []*trackingTarget
+	initErr error // returned by Init of created targets.
+}
+
+func (tf *trackingFactory) create(targetType string, options json.RawMessage) (logr.Target, error) {
+	tf.mux.Lock()
+	defer tf.mux.Unlock()
+	tgt := &trackingTarget{initErr: tf.initErr}
+	tf.targets = append(tf.targets, tgt)
+	return tgt, nil
+}
+
+func (tf *trackingFactory) all() []*trackingTarget {
+	tf.mux.Lock()
+	defer tf.mux.Unlock()
+	return append([]*trackingTarget(nil), tf.targets...)
+}
+
+type trackingTarget struct {
+	mux      sync.Mutex
+	sb       strings.Builder
+	shutdown bool
+	initErr  error
+}
+
+func (tt *trackingTarget) Init() error { return tt.initErr }
+
+func (tt *trackingTarget) Write(p []byte, rec *logr.LogRec) (int, error) {
+	tt.mux.Lock()
+	defer tt.mux.Unlock()
+	return tt.sb.Write(p)
+}
+
+func (tt *trackingTarget) Shutdown() error {
+	tt.mux.Lock()
+	defer tt.mux.Unlock()
+	tt.shutdown = true
+	return nil
+}
+
+func (tt *trackingTarget) isShutdown() bool {
+	tt.mux.Lock()
+	defer tt.mux.Unlock()
+	return tt.shutdown
+}
+
+func (tt *trackingTarget) output() string {
+	tt.mux.Lock()
+	defer tt.mux.Unlock()
+	return tt.sb.String()
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/mattermost/logr/v2"
+)
+
+// RedactCfg configures redaction of sensitive data for a target, applied before formatting.
+// Keys are matched case-insensitively and may contain '*' and '?' wildcards. The HMAC secret
+// for `hash_keys` and hashed scrubbers can be provided directly via `hash_secret`, or read
+// from an environment variable named by `hash_secret_env` to keep it out of the config.
+//
+// For example, to keep user emails out of a target while still correlating them:
+//
+//	"redact": {
+//	    "mask_keys": ["*password*", "token"],
+//	    "hash_keys": ["user_email"],
+//	    "hash_secret_env": "LOG_HMAC_SECRET",
+//	    "scrub": [{"builtin": "email", "hash": true}, {"builtin": "credit_card"}]
+//	}
+type RedactCfg struct {
+	MaskKeys []string `json:"mask_keys,omitempty"`
+	DropKeys []string `json:"drop_keys,omitempty"`
+	HashKeys []string `json:"hash_keys,omitempty"`
+
+	HashSecret    string `json:"hash_secret,omitempty"`
+	HashSecretEnv string `json:"hash_secret_env,omitempty"`
+
+	Scrub []ScrubCfg `json:"scrub,omitempty"`
+
+	// RevealSecrets outputs the values of `logr.Secret` fields, which are otherwise masked.
+	RevealSecrets bool `json:"reveal_secrets,omitempty"`
+}
+
+// ScrubCfg configures replacement of text within messages and string fields. Exactly one
+// of `builtin` ("email", "credit_card" or "token") or `regex` must be specified.
+type ScrubCfg struct {
+	Builtin string `json:"builtin,omitempty"`
+	Regex   string `json:"regex,omitempty"`
+	Hash    bool   `json:"hash,omitempty"`
+}
+
+// newRedactor creates a Redactor from the config.
+func newRedactor(rc RedactCfg) (*logr.Redactor, error) {
+	opts := logr.RedactOptions{
+		MaskKeys:      rc.MaskKeys,
+		DropKeys:      rc.DropKeys,
+		HashKeys:      rc.HashKeys,
+		RevealSecrets: rc.RevealSecrets,
+	}
+
+	switch {
+	case rc.HashSecret != "" && rc.HashSecretEnv != "":
+		return nil, errors.New("specify only one of hash_secret and hash_secret_env")
+	case rc.HashSecret != "":
+		opts.HashSecret = []byte(rc.HashSecret)
+	case rc.HashSecretEnv != "":
+		secret := os.Getenv(rc.HashSecretEnv)
+		if secret == "" {
+			return nil, fmt.Errorf("environment variable %s is empty or not set", rc.HashSecretEnv)
+		}
+		opts.HashSecret = []byte(secret)
+	}
+
+	for _, sc := range rc.Scrub {
+		scrubber, err := newScrubber(sc)
+		if err != nil {
+			return nil, err
+		}
+		opts.Scrubbers = append(opts.Scrubbers, scrubber)
+	}
+	return logr.NewRedactor(opts)
+}
+
+func newScrubber(sc ScrubCfg) (logr.Scrubber, error) {
+	var scrubber logr.Scrubber
+	switch {
+	case sc.Builtin != "" && sc.Regex != "":
+		return scrubber, errors.New("scrub must specify exactly one of builtin, regex")
+	case sc.Regex != "":
+		re, err := regexp.Compile(sc.Regex)
+		if err != nil {
+			return scrubber, fmt.Errorf("invalid scrub regex: %w", err)
+		}
+		scrubber.Regexp = re
+	case sc.Builtin == "email":
+		scrubber = logr.ScrubEmails
+	case sc.Builtin == "credit_card":
+		scrubber = logr.ScrubCreditCards
+	case sc.Builtin == "token":
+		scrubber = logr.ScrubTokens
+	case sc.Builtin != "":
+		return scrubber, fmt.Errorf("unknown builtin scrubber '%s'", sc.Builtin)
+	default:
+		return scrubber, errors.New("scrub must specify exactly one of builtin, regex")
+	}
+	scrubber.Hash = sc.Hash
+	return scrubber, nil
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mattermost/logr/v2"
+	"github.com/mattermost/logr/v2/targets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigureRedact(t *testing.T) {
+	t.Setenv("LOGR_TEST_HMAC_SECRET", "s3cret")
+
+	// the audit target keeps emails while the graylog target scrubs them.
+	cfg := parseCfg(t, `{
+		"audit": {"type": "tracking", "options": {"id": 1}, "format": "plain", "levels": [{"id": 4, "name": "info"}],
+			"redact": {"reveal_secrets": true}},
+		"graylog": {"type": "tracking", "options": {"id": 2}, "format": "plain", "levels": [{"id": 4, "name": "info"}],
+			"redact": {
+				"mask_keys": ["*password*"],
+				"hash_keys": ["user_id"],
+				"hash_secret_env": "LOGR_TEST_HMAC_SECRET",
+				"scrub": [{"builtin": "email"}, {"regex": "ticket-[0-9]+", "hash": true}]
+			}}
+	}`)
+
+	lgr, err := logr.New()
+	require.NoError(t, err)
+
+	tf := &trackingFactory{}
+	err = ConfigureTargets(lgr, cfg, &Factories{TargetFactory: tf.create})
+	require.NoError(t, err)
+
+	lgr.NewLogger().Info("password reset for sarah@example.com, ticket-42",
+		logr.Secret("email", "sarah@example.com"),
+		logr.String("password_hint", "cat"),
+		logr.String("user_id", "u123"),
+	)
+	require.NoError(t, lgr.Shutdown())
+
+	require.Len(t, tf.all(), 2)
+	audit, graylog := tf.all()[0].output(), tf.all()[1].output()
+	if strings.Contains(audit, logr.RedactedValue) {
+		audit, graylog = graylog, audit
+	}
+
+	assert.Contains(t, audit, "email=sarah@example.com")
+	assert.Contains(t, audit, "password_hint=cat")
+
+	assert.NotContains(t, graylog, "sarah@example.com")
+	assert.NotContains(t, graylog, "cat")
+	assert.NotContains(t, graylog, "u123")
+	assert.NotContains(t, graylog, "ticket-42")
+	assert.Contains(t, graylog, `email="[REDACTED]"`)
+	assert.Contains(t, graylog, `user_id="hmac:`)
+}
+
+func TestConfigureRedactRing(t *testing.T) {
+	cfg := parseCfg(t, `{
+		"viewer": {"type": "ring", "format": "json", "levels": [{"id": 4, "name": "info"}],
+			"redact": {"mask_keys": ["*password*"], "scrub": [{"builtin": "email"}]}}
+	}`)
+
+	lgr, err := logr.New()
+	require.NoError(t, err)
+	require.NoError(t, ConfigureTargets(lgr, cfg, nil))
+
+	lgr.NewLogger().Info("login for sarah@example.com", logr.String("password", "hunter2"))
+	require.NoError(t, lgr.Shutdown())
+
+	ring, ok := lgr.Target("viewer").(*targets.Ring)
+	require.True(t, ok)
+	buf := &strings.Builder{}
+	require.NoError(t, ring.Query(targets.RingQuery{}).Export(buf, targets.RingExportPlain))
+	assert.NotContains(t, buf.String(), "sarah@example.com")
+	assert.NotContains(t, buf.String(), "hunter2")
+	assert.Contains(t, buf.String(), logr.RedactedValue)
+}
+
+func TestRedactCfgInvalid(t *testing.T) {
+	tests := map[string]RedactCfg{
+		"hash without secret":     {HashKeys: []string{"id"}},
+		"both secrets":            {HashSecret: "a", HashSecretEnv: "B"},
+		"missing env secret":      {HashKeys: []string{"id"}, HashSecretEnv: "LOGR_TEST_UNSET_SECRET"},
+		"unknown builtin":         {Scrub: []ScrubCfg{{Builtin: "ssn"}}},
+		"builtin and regex":       {Scrub: []ScrubCfg{{Builtin: "email", Regex: "x"}}},
+		"empty scrub":             {Scrub: []ScrubCfg{{}}},
+		"invalid regex":           {Scrub: []ScrubCfg{{Regex: "("}}},
+		"hashed scrub w/o secret": {Scrub: []ScrubCfg{{Builtin: "email", Hash: true}}},
+	}
+	for name, rc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := newRedactor(rc)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,51 @@
+//go:build !windows && !nacl && !plan9
+// +build !windows,!nacl,!plan9
+
+package config
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigureRedactSyslogStructuredData(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	cfg := parseCfg(t, fmt.Sprintf(`{
+		"syslog": {"type": "syslog", "format": "plain", "levels": [{"id": 4, "name": "info"}],
+			"options": {"network": "udp", "host": "127.0.0.1", "port": %d, "tag": "logrtest",
+				"format": "rfc5424", "sd_id": "logr@32473"},
+			"redact": {"drop_keys": ["email"], "mask_keys": ["*password*"], "scrub": [{"builtin": "email"}]}}
+	}`, conn.LocalAddr().(*net.UDPAddr).Port))
+
+	lgr, err := logr.New()
+	require.NoError(t, err)
+	require.NoError(t, ConfigureTargets(lgr, cfg, nil))
+
+	lgr.NewLogger().Info("user bob@example.com logged in",
+		logr.String("email", "bob@example.com"),
+		logr.String("password", "hunter2"),
+		logr.String("user", "bob"),
+	)
+	require.NoError(t, lgr.Shutdown())
+
+	buf := make([]byte, 4096)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	msg := string(buf[:n])
+
+	assert.NotContains(t, msg, "bob@example.com")
+	assert.NotContains(t, msg, "hunter2")
+	assert.NotContains(t, msg, `email="`)
+	assert.Contains(t, msg, `password="`+logr.RedactedValue+`"`)
+	assert.Contains(t, msg, `user="bob"`)
+}
@@ -34,4 +34,8 @@ const (
 
 	// DefaultMaxFieldLength is the maximum size of a String or fmt.Stringer field can be.
 	DefaultMaxFieldLength = -1
+
+	// DefaultRecorderMaxRecords is the default maximum number of log records buffered by a
+	// flight recorder. See `FlightRecorder`.
+	DefaultRecorderMaxRecords = 100
 )
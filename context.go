@@ -0,0 +1,56 @@
+package logr
+
+import (
+	"context"
+)
+
+type loggerCtxKey struct{}
+
+// ContextExtractorFunc returns fields to add to log records from a context, such as
+// a request ID, trace ID or span ID. It is called for every enabled log record logged
+// via `Logger.LogCtx`, so it should be fast and must be safe for concurrent use.
+type ContextExtractorFunc func(ctx context.Context) []Field
+
+// WithContext returns a copy of ctx carrying this `Logger`, which can be retrieved
+// via `FromContext`.
+func (logger Logger) WithContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, loggerCtxKey{}, logger)
+}
+
+// FromContext returns the `Logger` stored in ctx via `Logger.WithContext`. The bool
+// is false if ctx does not contain a Logger.
+func FromContext(ctx context.Context) (Logger, bool) {
+	if ctx == nil {
+		return Logger{}, false
+	}
+	logger, ok := ctx.Value(loggerCtxKey{}).(Logger)
+	return logger, ok
+}
+
+// LogCtx is like `Log` but adds any fields returned by the `ContextExtractor` option
+// for ctx, ahead of the supplied fields. If the Logr queue is full, ctx also bounds how
+// long the call can block: a context with a deadline replaces `EnqueueTimeout`, and a
+// cancelled context abandons the record.
+func (logger Logger) LogCtx(ctx context.Context, lvl Level, msg string, fields ...Field) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	r := recorderFromContext(ctx)
+	if r == nil {
+		r = logger.recorder
+	}
+	status, overridden := logger.levelStatus(lvl)
+	if status.Enabled || status.isRecording(r) {
+		if extract := logger.lgr.options.contextExtractor; extract != nil {
+			if ctxFields := extract(ctx); len(ctxFields) > 0 {
+				all := make([]Field, 0, len(ctxFields)+len(fields))
+				all = append(all, ctxFields...)
+				fields = append(all, fields...)
+			}
+		}
+		rec := NewLogRec(lvl, logger, msg, fields, status.Stacktrace)
+		rec.levelOverride = overridden
+		logger.lgr.enqueueRecorded(ctx, r, status, rec)
+	}
+	logger.lgr.exitOrPanic(lvl, msg)
+}
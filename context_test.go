@@ -0,0 +1,102 @@
+package logr_test
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr/v2"
+	"github.com/mattermost/logr/v2/formatters"
+	"github.com/mattermost/logr/v2/targets"
+	"github.com/mattermost/logr/v2/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type requestIDKey struct{}
+
+func TestLoggerWithContext(t *testing.T) {
+	lgr, err := logr.New()
+	require.NoError(t, err)
+	defer lgr.Shutdown()
+
+	_, ok := logr.FromContext(context.Background())
+	assert.False(t, ok)
+
+	logger := lgr.NewLogger().With(logr.String("user", "Sarah"))
+	ctx := logger.WithContext(context.Background())
+
+	got, ok := logr.FromContext(ctx)
+	require.True(t, ok)
+	assert.Equal(t, logger, got)
+}
+
+func TestLogCtxExtractor(t *testing.T) {
+	extractor := func(ctx context.Context) []logr.Field {
+		if id, ok := ctx.Value(requestIDKey{}).(string); ok {
+			return []logr.Field{logr.String("request_id", id)}
+		}
+		return nil
+	}
+
+	lgr, err := logr.New(logr.ContextExtractor(extractor))
+	require.NoError(t, err)
+
+	buf := &test.Buffer{}
+	formatter := &formatters.Plain{DisableTimestamp: true, DisableLevel: true}
+	err = lgr.AddTarget(targets.NewWriterTarget(buf), "ctx", &logr.StdFilter{Lvl: logr.Info}, formatter, 100)
+	require.NoError(t, err)
+
+	logger := lgr.NewLogger()
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "abc123")
+
+	logger.LogCtx(ctx, logr.Info, "handled request", logr.Int("status", 200))
+	logger.LogCtx(context.Background(), logr.Info, "no request")
+	logger.LogCtx(ctx, logr.Debug, "not enabled")
+
+	require.NoError(t, lgr.Shutdown())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], "request_id=abc123")
+	assert.Contains(t, lines[0], "status=200")
+	assert.Less(t, strings.Index(lines[0], "request_id"), strings.Index(lines[0], "status"))
+	assert.NotContains(t, lines[1], "request_id")
+}
+
+func TestLogCtxEnqueueBoundedByContext(t *testing.T) {
+	var abandoned int32
+	opts := []logr.Option{
+		logr.MaxQueueSize(1),
+		logr.EnqueueTimeout(time.Minute),
+		logr.OnLoggerError(func(err error) {
+			if strings.Contains(err.Error(), "enqueue abandoned") {
+				atomic.AddInt32(&abandoned, 1)
+			}
+		}),
+	}
+	lgr, err := logr.New(opts...)
+	require.NoError(t, err)
+
+	// a slow target with a tiny queue backs up the Logr queue.
+	target := test.NewSlowTarget(&test.Buffer{}, 200)
+	err = lgr.AddTarget(target, "slow", &logr.StdFilter{Lvl: logr.Info}, &formatters.Plain{}, 1)
+	require.NoError(t, err)
+
+	logger := lgr.NewLogger()
+
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*20)
+		logger.LogCtx(ctx, logr.Info, "blocked request")
+		cancel()
+	}
+	elapsed := time.Since(start)
+
+	assert.True(t, elapsed < time.Second*2, "enqueue should be bounded by the context deadline, not EnqueueTimeout")
+	assert.Greater(t, atomic.LoadInt32(&abandoned), int32(0))
+
+	_ = lgr.Shutdown()
+}
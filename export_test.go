@@ -0,0 +1,8 @@
+package logr
+
+// PrepLogRec prepares a log record the same way the engine does before passing it
+// to filters and targets. Exported for tests only.
+func PrepLogRec(rec *LogRec) *LogRec {
+	rec.prep()
+	return rec
+}
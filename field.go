@@ -54,6 +54,7 @@ const (
 	BinaryType
 	ArrayType
 	MapType
+	SecretType
 )
 
 type Field struct {
@@ -236,6 +237,9 @@ func (f Field) ValueString(w io.Writer, shouldQuote func(s string) bool) error {
 
 		}
 
+	case SecretType:
+		err = quoteString(w, RedactedValue, shouldQuote)
+
 	case UnknownType:
 		_, err = fmt.Fprintf(w, "%v", f.Interface)
 
@@ -245,6 +249,17 @@ func (f Field) ValueString(w io.Writer, shouldQuote func(s string) bool) error {
 	return err
 }
 
+// Reveal returns the underlying field of a field created via `Secret`, or the field
+// itself for all other types.
+func (f Field) Reveal() Field {
+	if f.Type == SecretType {
+		if inner, ok := f.Interface.(Field); ok {
+			return inner
+		}
+	}
+	return f
+}
+
 func nilField(key string) Field {
 	return String(key, "")
 }
@@ -125,3 +125,10 @@ func Array[S ~[]E, E any](key string, val S) Field {
 func Map[M ~map[K]V, K comparable, V any](key string, val M) Field {
 	return Field{Key: key, Type: MapType, Interface: val}
 }
+
+// Secret constructs a field containing a key and a sensitive value, such as a password,
+// token or email address. All built-in formatters output the value as `RedactedValue`
+// unless the target's `Redactor` is configured to reveal secrets.
+func Secret(key string, val any) Field {
+	return Field{Key: key, Type: SecretType, Interface: Any(key, val)}
+}
@@ -2,6 +2,7 @@ package logr
 
 import (
 	"errors"
+	"strings"
 	"testing"
 	"time"
 )
@@ -127,3 +128,15 @@ func TestFieldMap(t *testing.T) {
 	type myGenericMap[K comparable, V any] map[K]V
 	_ = Map("array", myGenericMap[int, any]{})
 }
+
+func TestFieldSecret(t *testing.T) {
+	f := Secret("password", "hunter2")
+	if f.Reveal() != String("password", "hunter2") {
+		t.Error("Reveal should return the underlying field")
+	}
+
+	var sb strings.Builder
+	if err := f.ValueString(&sb, nil); err != nil || sb.String() != RedactedValue {
+		t.Errorf("secret should be masked, got %q, %v", sb.String(), err)
+	}
+}
@@ -1,6 +1,7 @@
 package logr
 
 import (
+	"sort"
 	"sync"
 )
 
@@ -45,3 +46,16 @@ func (cf *CustomFilter) Add(levels ...Level) {
 		cf.levels[s.ID] = s
 	}
 }
+
+// Levels returns the levels in the list, ordered by ID.
+func (cf *CustomFilter) Levels() []Level {
+	cf.mux.RLock()
+	defer cf.mux.RUnlock()
+
+	levels := make([]Level, 0, len(cf.levels))
+	for _, level := range cf.levels {
+		levels = append(levels, level)
+	}
+	sort.Slice(levels, func(i, j int) bool { return levels[i].ID < levels[j].ID })
+	return levels
+}
@@ -0,0 +1,214 @@
+package logr
+
+import (
+	"regexp"
+	"strings"
+)
+
+// RecordFilter is an optional interface that can be implemented by a `Filter` to
+// include or exclude individual log records based on more than level, such as fields
+// or message text. `IsRecordEnabled` is called once for each log record with an enabled
+// level, before the record is queued for the target, and must be safe for concurrent use.
+//
+// Level filtering via `GetEnabledLevel` and the level cache still applies first, so
+// records at disabled levels remain cheap.
+type RecordFilter interface {
+	// IsRecordEnabled returns true if the log record should be output.
+	IsRecordEnabled(rec *LogRec) bool
+}
+
+// RecordMatcher matches log records. Matchers can be composed via `And`, `Or`
+// and `Not`.
+type RecordMatcher interface {
+	Match(rec *LogRec) bool
+}
+
+// MatchFilter wraps a Filter with a RecordMatcher. A log record is output only if its
+// level is enabled by the Filter and the matcher matches.
+type MatchFilter struct {
+	Filter
+	Matcher RecordMatcher
+}
+
+// NewMatchFilter creates a Filter that applies matcher to records enabled by filter.
+func NewMatchFilter(filter Filter, matcher RecordMatcher) *MatchFilter {
+	return &MatchFilter{
+		Filter:  filter,
+		Matcher: matcher,
+	}
+}
+
+// IsRecordEnabled returns true if the matcher matches the log record.
+func (mf *MatchFilter) IsRecordEnabled(rec *LogRec) bool {
+	if mf.Matcher == nil {
+		return true
+	}
+	return mf.Matcher.Match(rec)
+}
+
+// IsStacktraceNeeded returns true if the matcher requires the caller of log
+// records, which is resolved from a stack trace.
+func (mf *MatchFilter) IsStacktraceNeeded() bool {
+	return needsCaller(mf.Matcher)
+}
+
+// MatcherFunc adapts a function to a RecordMatcher.
+type MatcherFunc func(rec *LogRec) bool
+
+// Match calls f(rec).
+func (f MatcherFunc) Match(rec *LogRec) bool {
+	return f(rec)
+}
+
+type andMatcher []RecordMatcher
+
+// And returns a matcher that matches when all matchers match.
+func And(matchers ...RecordMatcher) RecordMatcher {
+	return andMatcher(matchers)
+}
+
+func (m andMatcher) Match(rec *LogRec) bool {
+	for _, matcher := range m {
+		if !matcher.Match(rec) {
+			return false
+		}
+	}
+	return true
+}
+
+type orMatcher []RecordMatcher
+
+// Or returns a matcher that matches when any of the matchers match.
+func Or(matchers ...RecordMatcher) RecordMatcher {
+	return orMatcher(matchers)
+}
+
+func (m orMatcher) Match(rec *LogRec) bool {
+	for _, matcher := range m {
+		if matcher.Match(rec) {
+			return true
+		}
+	}
+	return false
+}
+
+type notMatcher struct {
+	matcher RecordMatcher
+}
+
+// Not returns a matcher that matches when matcher does not.
+func Not(matcher RecordMatcher) RecordMatcher {
+	return notMatcher{matcher: matcher}
+}
+
+func (m notMatcher) Match(rec *LogRec) bool {
+	return !m.matcher.Match(rec)
+}
+
+type fieldMatcher struct {
+	key   string
+	match func(val string) bool // nil matches presence only.
+}
+
+// FieldExists returns a matcher that matches records containing a field with the
+// specified key, including fields added to the Logger via `With`.
+func FieldExists(key string) RecordMatcher {
+	return fieldMatcher{key: key}
+}
+
+// FieldEquals returns a matcher that matches records containing a field with the
+// specified key whose value, formatted as a string, equals val.
+func FieldEquals(key string, val string) RecordMatcher {
+	return fieldMatcher{key: key, match: func(s string) bool { return s == val }}
+}
+
+// FieldRegex returns a matcher that matches records containing a field with the
+// specified key whose value, formatted as a string, matches re.
+func FieldRegex(key string, re *regexp.Regexp) RecordMatcher {
+	return fieldMatcher{key: key, match: re.MatchString}
+}
+
+func (m fieldMatcher) Match(rec *LogRec) bool {
+	fields := rec.Fields()
+	// search backwards so record fields take precedence over logger fields.
+	for i := len(fields) - 1; i >= 0; i-- {
+		f := fields[i]
+		if f.Key != m.key {
+			continue
+		}
+		if m.match == nil {
+			return true
+		}
+		return m.match(fieldValueString(f))
+	}
+	return false
+}
+
+func fieldValueString(f Field) string {
+	if f.Type == StringType {
+		return f.String
+	}
+	var sb strings.Builder
+	if err := f.ValueString(&sb, nil); err != nil {
+		return ""
+	}
+	return sb.String()
+}
+
+type msgMatcher struct {
+	re *regexp.Regexp
+}
+
+// MsgRegex returns a matcher that matches records whose message matches re.
+func MsgRegex(re *regexp.Regexp) RecordMatcher {
+	return msgMatcher{re: re}
+}
+
+func (m msgMatcher) Match(rec *LogRec) bool {
+	return m.re.MatchString(rec.Msg())
+}
+
+type callerMatcher struct {
+	prefix string
+}
+
+// CallerPackagePrefix returns a matcher that matches records emitted from a package
+// whose import path starts with prefix, e.g. "github.com/mattermost/mattermost/server/channels/store".
+// The caller is resolved from a stack trace, so a target using this matcher causes stack
+// traces to be captured for every log record at an enabled level.
+func CallerPackagePrefix(prefix string) RecordMatcher {
+	return callerMatcher{prefix: prefix}
+}
+
+func (m callerMatcher) Match(rec *LogRec) bool {
+	for _, frame := range rec.StackFrames() {
+		if frame.Function == "" {
+			continue
+		}
+		return strings.HasPrefix(ResolvePackageName(frame.Function), m.prefix)
+	}
+	return false
+}
+
+// needsCaller returns true if matcher, or any composed matcher, matches by caller.
+func needsCaller(matcher RecordMatcher) bool {
+	switch m := matcher.(type) {
+	case callerMatcher:
+		return true
+	case andMatcher:
+		for _, mm := range m {
+			if needsCaller(mm) {
+				return true
+			}
+		}
+	case orMatcher:
+		for _, mm := range m {
+			if needsCaller(mm) {
+				return true
+			}
+		}
+	case notMatcher:
+		return needsCaller(m.matcher)
+	}
+	return false
+}
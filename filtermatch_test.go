@@ -0,0 +1,127 @@
+package logr_test
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/logr/v2"
+	"github.com/mattermost/logr/v2/formatters"
+	"github.com/mattermost/logr/v2/targets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordMatchers(t *testing.T) {
+	lgr, err := logr.New()
+	require.NoError(t, err)
+	defer lgr.Shutdown()
+
+	logger := lgr.NewLogger().With(logr.String("component", "plugin"), logr.Int("shard", 3))
+
+	newRec := func(msg string, fields ...logr.Field) *logr.LogRec {
+		rec := logr.NewLogRec(logr.Info, logger, msg, fields, false)
+		// records are prepared by the engine before reaching filters.
+		return logr.PrepLogRec(rec)
+	}
+
+	rec := newRec("health check ok", logr.Err(errors.New("boom")))
+
+	tests := []struct {
+		name    string
+		matcher logr.RecordMatcher
+		want    bool
+	}{
+		{"field exists", logr.FieldExists("component"), true},
+		{"field missing", logr.FieldExists("user"), false},
+		{"field equals", logr.FieldEquals("component", "plugin"), true},
+		{"field not equals", logr.FieldEquals("component", "store"), false},
+		{"field equals int", logr.FieldEquals("shard", "3"), true},
+		{"field equals error", logr.FieldEquals("error", "boom"), true},
+		{"field regex", logr.FieldRegex("component", regexp.MustCompile("^plug")), true},
+		{"msg regex", logr.MsgRegex(regexp.MustCompile("^health")), true},
+		{"msg regex no match", logr.MsgRegex(regexp.MustCompile("^login")), false},
+		{"and", logr.And(logr.FieldExists("component"), logr.FieldEquals("shard", "3")), true},
+		{"and fails", logr.And(logr.FieldExists("component"), logr.FieldExists("user")), false},
+		{"or", logr.Or(logr.FieldExists("user"), logr.FieldExists("shard")), true},
+		{"not", logr.Not(logr.FieldExists("user")), true},
+		{"func", logr.MatcherFunc(func(rec *logr.LogRec) bool { return rec.Level() == logr.Info }), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.matcher.Match(rec))
+		})
+	}
+
+	t.Run("record field overrides logger field", func(t *testing.T) {
+		rec := newRec("msg", logr.String("component", "store"))
+		assert.True(t, logr.FieldEquals("component", "store").Match(rec))
+	})
+}
+
+func TestMatchFilterRouting(t *testing.T) {
+	lgr, err := logr.New()
+	require.NoError(t, err)
+
+	formatter := &formatters.Plain{DisableTimestamp: true, DisableLevel: true}
+	isPlugin := logr.FieldEquals("component", "plugin")
+
+	bufPlugin := &bytes.Buffer{}
+	filter := logr.NewMatchFilter(&logr.StdFilter{Lvl: logr.Info}, isPlugin)
+	err = lgr.AddTarget(targets.NewWriterTarget(bufPlugin), "plugin", filter, formatter, 100)
+	require.NoError(t, err)
+
+	bufOther := &bytes.Buffer{}
+	filter = logr.NewMatchFilter(&logr.StdFilter{Lvl: logr.Info}, logr.Not(isPlugin))
+	err = lgr.AddTarget(targets.NewWriterTarget(bufOther), "other", filter, formatter, 100)
+	require.NoError(t, err)
+
+	pluginLogger := lgr.NewLogger().With(logr.String("component", "plugin"))
+	pluginLogger.Info("from plugin")
+	pluginLogger.Debug("plugin debug") // level not enabled
+	lgr.NewLogger().Info("from server")
+
+	require.NoError(t, lgr.Shutdown())
+
+	assert.Contains(t, bufPlugin.String(), "from plugin")
+	assert.NotContains(t, bufPlugin.String(), "from server")
+	assert.NotContains(t, bufPlugin.String(), "plugin debug")
+
+	assert.Contains(t, bufOther.String(), "from server")
+	assert.NotContains(t, bufOther.String(), "from plugin")
+}
+
+func TestMatchFilterCallerPrefix(t *testing.T) {
+	lgr, err := logr.New()
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	filter := logr.NewMatchFilter(&logr.StdFilter{Lvl: logr.Info}, logr.CallerPackagePrefix("github.com/mattermost/logr/v2_test"))
+	require.True(t, filter.IsStacktraceNeeded())
+
+	formatter := &formatters.Plain{DisableTimestamp: true, DisableLevel: true}
+	err = lgr.AddTarget(targets.NewWriterTarget(buf), "caller", filter, formatter, 100)
+	require.NoError(t, err)
+
+	require.True(t, lgr.IsLevelEnabled(logr.Info).Stacktrace, "caller matching should force stack capture")
+
+	lgr.NewLogger().Info("from this package")
+	require.NoError(t, lgr.Shutdown())
+
+	assert.Equal(t, 1, strings.Count(buf.String(), "from this package"))
+}
+
+func TestSampledMatchFilter(t *testing.T) {
+	match := logr.NewMatchFilter(&logr.StdFilter{Lvl: logr.Info}, logr.FieldExists("component"))
+	filter := logr.NewSampledFilter(match, logr.NewRateLimiter(1, 1))
+
+	var rf logr.RecordFilter = filter
+	lgr, err := logr.New()
+	require.NoError(t, err)
+	defer lgr.Shutdown()
+
+	rec := logr.PrepLogRec(logr.NewLogRec(logr.Info, lgr.NewLogger(), "msg", nil, false))
+	assert.False(t, rf.IsRecordEnabled(rec), "sampled filter should apply wrapped record filter")
+}
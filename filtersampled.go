@@ -0,0 +1,190 @@
+package logr
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// samplerTableSize is the number of counters used by a Sampler. Level+message pairs
+	// are hashed into this table so memory use is constant.
+	samplerTableSize = 4096
+
+	fnvOffset32 = 2166136261
+	fnvPrime32  = 16777619
+)
+
+// RecordSampler is an optional interface that can be implemented by a `Filter` to
+// suppress individual log records after level filtering, e.g. to sample or rate limit
+// high volume log sites. `Sample` is called once for each log record with an enabled
+// level, before the record is queued for the target, and must be safe for concurrent use.
+type RecordSampler interface {
+	// Sample returns true if the log record should be output.
+	Sample(rec *LogRec) bool
+}
+
+// SampledFilter wraps a Filter with one or more RecordSamplers. A log record is
+// output only if its level is enabled by the Filter and every sampler allows it.
+type SampledFilter struct {
+	Filter
+	Samplers []RecordSampler
+}
+
+// NewSampledFilter creates a Filter that applies samplers to records enabled by filter.
+func NewSampledFilter(filter Filter, samplers ...RecordSampler) *SampledFilter {
+	return &SampledFilter{
+		Filter:   filter,
+		Samplers: samplers,
+	}
+}
+
+// Sample returns true if all samplers allow the log record to be output.
+func (sf *SampledFilter) Sample(rec *LogRec) bool {
+	for _, s := range sf.Samplers {
+		if !s.Sample(rec) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsRecordEnabled applies the wrapped Filter's `RecordFilter`, if any, so sampling
+// only counts records that would otherwise be output.
+func (sf *SampledFilter) IsRecordEnabled(rec *LogRec) bool {
+	if rf, ok := sf.Filter.(RecordFilter); ok {
+		return rf.IsRecordEnabled(rec)
+	}
+	return true
+}
+
+// IsStacktraceNeeded returns true if the wrapped Filter requires stack traces.
+func (sf *SampledFilter) IsStacktraceNeeded() bool {
+	if sn, ok := sf.Filter.(interface{ IsStacktraceNeeded() bool }); ok {
+		return sn.IsStacktraceNeeded()
+	}
+	return false
+}
+
+// Sampler outputs the first N log records with the same level and message within
+// each interval, then every Mth record thereafter. Records are keyed by hashing the
+// level and message, so different messages may occasionally share a counter.
+type Sampler struct {
+	interval   time.Duration
+	first      uint64
+	thereafter uint64
+	counters   []samplerCounter
+}
+
+type samplerCounter struct {
+	resetAt atomic.Int64
+	count   atomic.Uint64
+}
+
+// NewSampler creates a Sampler that outputs the first `first` records for each
+// level+message per `interval`, then every `thereafter`th record. A `thereafter` of
+// zero suppresses all records beyond the first N within the interval.
+func NewSampler(interval time.Duration, first int, thereafter int) *Sampler {
+	if first < 0 {
+		first = 0
+	}
+	if thereafter < 0 {
+		thereafter = 0
+	}
+	return &Sampler{
+		interval:   interval,
+		first:      uint64(first),
+		thereafter: uint64(thereafter),
+		counters:   make([]samplerCounter, samplerTableSize),
+	}
+}
+
+// Sample returns true if the log record should be output.
+func (s *Sampler) Sample(rec *LogRec) bool {
+	// FNV-1a hash of level id and message, computed inline to avoid allocations.
+	h := uint32(fnvOffset32)
+	for id := uint32(rec.level.ID); id != 0; id >>= 8 {
+		h ^= id & 0xff
+		h *= fnvPrime32
+	}
+	msg := rec.Msg()
+	for i := 0; i < len(msg); i++ {
+		h ^= uint32(msg[i])
+		h *= fnvPrime32
+	}
+
+	counter := &s.counters[h%samplerTableSize]
+	n := counter.inc(rec.Time(), s.interval)
+
+	if n <= s.first {
+		return true
+	}
+	if s.thereafter == 0 {
+		return false
+	}
+	return (n-s.first)%s.thereafter == 0
+}
+
+// inc increments the counter, resetting it first if the interval has elapsed.
+func (c *samplerCounter) inc(t time.Time, interval time.Duration) uint64 {
+	tn := t.UnixNano()
+	resetAt := c.resetAt.Load()
+	if tn > resetAt {
+		newResetAt := tn + interval.Nanoseconds()
+		if c.resetAt.CompareAndSwap(resetAt, newResetAt) {
+			c.count.Store(1)
+			return 1
+		}
+	}
+	return c.count.Add(1)
+}
+
+// RateLimiter is a token bucket limiting the number of log records output per second,
+// regardless of level or message. Up to `burst` records can be output at once.
+type RateLimiter struct {
+	mux      sync.Mutex
+	rate     float64
+	burst    float64
+	tokens   float64
+	lastTime time.Time
+}
+
+// NewRateLimiter creates a RateLimiter allowing `perSecond` records per second on
+// average, with bursts of up to `burst` records. A burst less than one is treated as one.
+func NewRateLimiter(perSecond float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:   perSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+	}
+}
+
+// Sample returns true if a token is available for the log record.
+func (rl *RateLimiter) Sample(rec *LogRec) bool {
+	return rl.allow(time.Now())
+}
+
+func (rl *RateLimiter) allow(now time.Time) bool {
+	rl.mux.Lock()
+	defer rl.mux.Unlock()
+
+	if !rl.lastTime.IsZero() {
+		elapsed := now.Sub(rl.lastTime).Seconds()
+		if elapsed > 0 {
+			rl.tokens += elapsed * rl.rate
+			if rl.tokens > rl.burst {
+				rl.tokens = rl.burst
+			}
+		}
+	}
+	rl.lastTime = now
+
+	if rl.tokens < 1 {
+		return false
+	}
+	rl.tokens--
+	return true
+}
@@ -0,0 +1,131 @@
+package logr_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr/v2"
+	"github.com/mattermost/logr/v2/formatters"
+	"github.com/mattermost/logr/v2/targets"
+	"github.com/mattermost/logr/v2/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSampler(t *testing.T) {
+	lgr, err := logr.New()
+	require.NoError(t, err)
+	defer lgr.Shutdown()
+	logger := lgr.NewLogger()
+
+	sampler := logr.NewSampler(time.Second, 3, 10)
+	now := time.Now()
+
+	sample := func(lvl logr.Level, msg string, tm time.Time) bool {
+		rec := logr.NewLogRec(lvl, logger, msg, nil, false).WithTime(tm)
+		return sampler.Sample(rec)
+	}
+
+	var count int
+	for i := 0; i < 100; i++ {
+		if sample(logr.Debug, "retrying", now) {
+			count++
+		}
+	}
+	// first 3, then every 10th of the remaining 97.
+	assert.Equal(t, 3+9, count)
+
+	// different message or level has its own counter.
+	assert.True(t, sample(logr.Debug, "something else", now))
+	assert.True(t, sample(logr.Info, "retrying", now))
+
+	// counter resets after the interval.
+	assert.True(t, sample(logr.Debug, "retrying", now.Add(time.Second*2)))
+}
+
+func TestSamplerNoThereafter(t *testing.T) {
+	lgr, err := logr.New()
+	require.NoError(t, err)
+	defer lgr.Shutdown()
+	logger := lgr.NewLogger()
+
+	sampler := logr.NewSampler(time.Minute, 2, 0)
+	now := time.Now()
+
+	var count int
+	for i := 0; i < 50; i++ {
+		if sampler.Sample(logr.NewLogRec(logr.Info, logger, "flood", nil, false).WithTime(now)) {
+			count++
+		}
+	}
+	assert.Equal(t, 2, count)
+}
+
+func TestRateLimiter(t *testing.T) {
+	lgr, err := logr.New()
+	require.NoError(t, err)
+	defer lgr.Shutdown()
+	rec := logr.NewLogRec(logr.Info, lgr.NewLogger(), "msg", nil, false)
+
+	limiter := logr.NewRateLimiter(1, 5)
+
+	var count int
+	for i := 0; i < 100; i++ {
+		if limiter.Sample(rec) {
+			count++
+		}
+	}
+	assert.Equal(t, 5, count, "only the burst should be allowed")
+}
+
+func TestSampledFilterTarget(t *testing.T) {
+	collector := test.NewTestMetricsCollector()
+	lgr, err := logr.New(logr.SetMetricsCollector(collector, 1000))
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	target := targets.NewWriterTarget(buf)
+	filter := logr.NewSampledFilter(&logr.StdFilter{Lvl: logr.Debug}, logr.NewSampler(time.Minute, 5, 100))
+	formatter := &formatters.Plain{DisableTimestamp: true, DisableLevel: true}
+
+	err = lgr.AddTarget(target, TestTargetName, filter, formatter, 1000)
+	require.NoError(t, err)
+
+	logger := lgr.NewLogger()
+	for i := 0; i < 1000; i++ {
+		logger.Debug("connection refused, retrying")
+	}
+	logger.Info("unique message")
+
+	require.NoError(t, lgr.Flush())
+
+	output := buf.String()
+	assert.Equal(t, 5+9, strings.Count(output, "connection refused"), output)
+	assert.Contains(t, output, "unique message")
+
+	metrics := collector.Get(TestTargetName)
+	assert.EqualValues(t, 1000-5-9, metrics.Suppressed)
+	assert.EqualValues(t, 5+9+1, metrics.Logged)
+
+	require.NoError(t, lgr.Shutdown())
+}
+
+func BenchmarkSampler(b *testing.B) {
+	lgr, _ := logr.New()
+	defer lgr.Shutdown()
+	sampler := logr.NewSampler(time.Second, 100, 100)
+
+	recs := make([]*logr.LogRec, 16)
+	for i := range recs {
+		recs[i] = logr.NewLogRec(logr.Debug, lgr.NewLogger(), fmt.Sprintf("message %d", i), nil, false)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sampler.Sample(recs[i%len(recs)])
+	}
+}
@@ -0,0 +1,231 @@
+package formatters
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/francoispqt/gojay"
+	"github.com/mattermost/logr/v2"
+)
+
+const (
+	// DefaultECSVersion is the Elastic Common Schema version output as `ecs.version`.
+	DefaultECSVersion = "8.11.0"
+
+	// ECSTimestampFormat is the format of the `@timestamp` field.
+	ECSTimestampFormat = "2006-01-02T15:04:05.000Z07:00"
+)
+
+// ECS formats log records as Elastic Common Schema JSON documents
+// (https://www.elastic.co/guide/en/ecs/current/index.html), ready for indexing into
+// Elasticsearch without ingest pipelines.
+//
+// Each record contains `@timestamp`, `log.level`, `message` and `ecs.version`, plus
+// `log.logger` for named loggers and `log.origin.*` from the record's stack frames. The
+// first error field is output as `error.message` and `error.type`, with `error.stack_trace`
+// for levels with stack traces enabled. Other fields are output under `Namespace`, or
+// mapped to ECS names via `FieldMap`; dotted ECS names are output as nested objects.
+type ECS struct {
+	// Namespace is the key under which fields not in FieldMap are grouped, e.g. "labels" or
+	// the name of your application. If empty, fields are output at the top level, with an
+	// underscore prefix added to any field colliding with an ECS field.
+	Namespace string `json:"namespace"`
+
+	// FieldMap maps field keys to ECS field names, e.g. {"user_id": "user.id"}.
+	FieldMap map[string]string `json:"field_map,omitempty"`
+
+	// DisableOrigin disables output of `log.origin.*`, which requires a stack trace for
+	// every record.
+	DisableOrigin bool `json:"disable_origin"`
+
+	// DisableStacktrace disables output of `error.stack_trace`.
+	DisableStacktrace bool `json:"disable_stacktrace"`
+
+	// ECSVersion overrides the `ecs.version` field. Defaults to DefaultECSVersion.
+	ECSVersion string `json:"ecs_version"`
+
+	// FieldSorter allows custom sorting of the fields. If nil then
+	// no sorting is done.
+	FieldSorter func(fields []logr.Field) []logr.Field `json:"-"`
+}
+
+func (e *ECS) CheckValid() error {
+	for key, name := range e.FieldMap {
+		if key == "" || name == "" {
+			return fmt.Errorf("invalid field_map entry '%s': '%s'", key, name)
+		}
+		for _, part := range strings.Split(name, ".") {
+			if part == "" {
+				return fmt.Errorf("invalid ECS field name '%s'", name)
+			}
+		}
+	}
+	return nil
+}
+
+// IsStacktraceNeeded returns true if a stacktrace is needed so we can output the `log.origin` fields.
+func (e *ECS) IsStacktraceNeeded() bool {
+	return !e.DisableOrigin
+}
+
+// Format converts a log record to bytes in ECS JSON format.
+func (e *ECS) Format(rec *logr.LogRec, level logr.Level, buf *bytes.Buffer) (*bytes.Buffer, error) {
+	if buf == nil {
+		buf = &bytes.Buffer{}
+	}
+	enc := gojay.BorrowEncoder(buf)
+	defer func() {
+		enc.Release()
+	}()
+
+	doc := e.newDocument(rec, level)
+	if err := enc.EncodeObject(doc); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf, nil
+}
+
+// newDocument builds the nested ECS document for a log record.
+func (e *ECS) newDocument(rec *logr.LogRec, level logr.Level) *ecsNode {
+	doc := &ecsNode{}
+
+	ts := rec.Time().Format(ECSTimestampFormat)
+	doc.insert([]string{"@timestamp"}, logr.String("", ts))
+	doc.insert([]string{"log", "level"}, logr.String("", level.Name))
+	if name := rec.Logger().Name(); name != "" {
+		doc.insert([]string{"log", "logger"}, logr.String("", name))
+	}
+	doc.insert([]string{"message"}, logr.String("", rec.Msg()))
+
+	frames := rec.StackFrames()
+	if !e.DisableOrigin {
+		for _, frame := range frames {
+			if frame.File == "" {
+				continue
+			}
+			doc.insert([]string{"log", "origin", "file", "name"}, logr.String("", frame.File))
+			doc.insert([]string{"log", "origin", "file", "line"}, logr.Int("", frame.Line))
+			doc.insert([]string{"log", "origin", "function"}, logr.String("", frame.Function))
+			break
+		}
+	}
+
+	fields := rec.Fields()
+	if e.FieldSorter != nil {
+		fields = e.FieldSorter(fields)
+	}
+
+	hasError := false
+	for _, field := range fields {
+		if field.Type == logr.ErrorType && !hasError {
+			hasError = true
+			doc.insert([]string{"error", "message"}, logr.String("", fmt.Sprint(field.Interface)))
+			doc.insert([]string{"error", "type"}, logr.String("", fmt.Sprintf("%T", field.Interface)))
+			continue
+		}
+		e.insertField(doc, field)
+	}
+
+	if level.Stacktrace && !e.DisableStacktrace && len(frames) > 0 {
+		var sb strings.Builder
+		for _, frame := range frames {
+			fmt.Fprintf(&sb, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		}
+		doc.insert([]string{"error", "stack_trace"}, logr.String("", sb.String()))
+	}
+
+	version := e.ECSVersion
+	if version == "" {
+		version = DefaultECSVersion
+	}
+	doc.insert([]string{"ecs", "version"}, logr.String("", version))
+	return doc
+}
+
+// insertField adds a user field to the document, either at its mapped ECS name or under
+// the namespace.
+func (e *ECS) insertField(doc *ecsNode, field logr.Field) {
+	if name, ok := e.FieldMap[field.Key]; ok {
+		if doc.insert(strings.Split(name, "."), field) {
+			return
+		}
+		// a mapped name colliding with an existing field is output unmapped.
+	}
+
+	// the namespace may already be taken by a mapped field.
+	if ns := doc.child(e.Namespace); e.Namespace != "" && (ns == nil || !ns.leaf) {
+		key := field.Key
+		for !doc.insert([]string{e.Namespace, key}, field) {
+			key = "_" + key
+		}
+		return
+	}
+
+	key := field.Key
+	for !doc.insert([]string{key}, field) {
+		key = "_" + key
+	}
+}
+
+// ecsNode is a field or nested object within an ECS document. Children are kept in
+// insertion order.
+type ecsNode struct {
+	key      string
+	field    logr.Field
+	leaf     bool
+	children []*ecsNode
+}
+
+// insert adds a field at the path, creating objects as needed. Returns false if the
+// path collides with an existing field or object.
+func (n *ecsNode) insert(path []string, field logr.Field) bool {
+	for i, part := range path {
+		child := n.child(part)
+		if i == len(path)-1 {
+			if child != nil {
+				return false
+			}
+			n.children = append(n.children, &ecsNode{key: part, field: field, leaf: true})
+			return true
+		}
+		if child == nil {
+			child = &ecsNode{key: part}
+			n.children = append(n.children, child)
+		} else if child.leaf {
+			return false
+		}
+		n = child
+	}
+	return false
+}
+
+func (n *ecsNode) child(key string) *ecsNode {
+	for _, c := range n.children {
+		if c.key == key {
+			return c
+		}
+	}
+	return nil
+}
+
+// MarshalJSONObject encodes the node's children as JSON.
+func (n *ecsNode) MarshalJSONObject(enc *gojay.Encoder) {
+	for _, c := range n.children {
+		if !c.leaf {
+			enc.AddObjectKey(c.key, c)
+			continue
+		}
+		field := c.field
+		field.Key = c.key
+		if err := encodeField(enc, field); err != nil {
+			enc.AddStringKey(field.Key, "<error encoding field: "+err.Error()+">")
+		}
+	}
+}
+
+// IsNil returns true if the node pointer is nil.
+func (n *ecsNode) IsNil() bool {
+	return n == nil
+}
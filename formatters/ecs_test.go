@@ -0,0 +1,101 @@
+package formatters_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/logr/v2"
+	"github.com/mattermost/logr/v2/formatters"
+	"github.com/mattermost/logr/v2/targets"
+	"github.com/mattermost/logr/v2/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestECSFormatter(t *testing.T) {
+	formatter := &formatters.ECS{
+		Namespace: "app",
+		FieldMap: map[string]string{
+			"user_id":    "user.id",
+			"user_name":  "user.name",
+			"req_method": "http.request.method",
+			"level":      "log.level", // collides with the built-in field.
+		},
+	}
+	docs := formatECS(t, formatter, func(logger logr.Logger) {
+		logger.Named("api").Error("request failed",
+			logr.String("user_id", "u1"),
+			logr.String("user_name", "sarah"),
+			logr.String("req_method", "GET"),
+			logr.Int("status", 500),
+			logr.String("level", "custom"),
+			logr.Err(errors.New("connection reset")),
+		)
+	})
+	require.Len(t, docs, 1)
+	doc := docs[0]
+
+	assert.Contains(t, doc, "@timestamp")
+	assert.Equal(t, "request failed", doc["message"])
+	assert.Equal(t, map[string]any{"version": formatters.DefaultECSVersion}, doc["ecs"])
+
+	log := doc["log"].(map[string]any)
+	assert.Equal(t, "error", log["level"])
+	assert.Equal(t, "api", log["logger"])
+	origin := log["origin"].(map[string]any)
+	assert.Contains(t, origin["file"].(map[string]any)["name"], "ecs_test.go")
+	assert.Contains(t, origin["function"], "TestECSFormatter")
+
+	assert.Equal(t, map[string]any{"id": "u1", "name": "sarah"}, doc["user"])
+	assert.Equal(t, map[string]any{"request": map[string]any{"method": "GET"}}, doc["http"])
+	assert.Equal(t, map[string]any{"status": float64(500), "level": "custom"}, doc["app"])
+
+	errObj := doc["error"].(map[string]any)
+	assert.Equal(t, "connection reset", errObj["message"])
+	assert.Equal(t, "*errors.errorString", errObj["type"])
+	assert.Contains(t, errObj["stack_trace"], "TestECSFormatter")
+}
+
+func TestECSFormatterTopLevelFields(t *testing.T) {
+	formatter := &formatters.ECS{DisableOrigin: true}
+	docs := formatECS(t, formatter, func(logger logr.Logger) {
+		logger.Info("hello", logr.String("message", "dup"), logr.Bool("ok", true))
+	})
+	require.Len(t, docs, 1)
+	doc := docs[0]
+
+	assert.Equal(t, "hello", doc["message"])
+	assert.Equal(t, "dup", doc["_message"])
+	assert.Equal(t, true, doc["ok"])
+	assert.NotContains(t, doc["log"], "origin")
+	assert.NotContains(t, doc, "error")
+}
+
+func TestECSFormatter_CheckValid(t *testing.T) {
+	assert.NoError(t, (&formatters.ECS{FieldMap: map[string]string{"a": "user.id"}}).CheckValid())
+	assert.Error(t, (&formatters.ECS{FieldMap: map[string]string{"a": ""}}).CheckValid())
+	assert.Error(t, (&formatters.ECS{FieldMap: map[string]string{"a": "user..id"}}).CheckValid())
+}
+
+func formatECS(t *testing.T, formatter *formatters.ECS, log func(logger logr.Logger)) []map[string]any {
+	lgr, err := logr.New()
+	require.NoError(t, err)
+
+	buf := &test.Buffer{}
+	filter := &logr.StdFilter{Lvl: logr.Info, Stacktrace: logr.Error}
+	err = lgr.AddTarget(targets.NewWriterTarget(buf), "ecs", filter, formatter, 100)
+	require.NoError(t, err)
+
+	log(lgr.NewLogger())
+	require.NoError(t, lgr.Shutdown())
+
+	var docs []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var doc map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &doc), line)
+		docs = append(docs, doc)
+	}
+	return docs
+}
@@ -0,0 +1,653 @@
+package formatters
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/francoispqt/gojay"
+	"github.com/mattermost/logr/v2"
+)
+
+const (
+	OTLPEncodingJSON     = "json"
+	OTLPEncodingProtobuf = "protobuf"
+
+	DefaultOTLPTraceIDKey = "trace_id"
+	DefaultOTLPSpanIDKey  = "span_id"
+)
+
+// OTLP formats log records as OpenTelemetry `LogRecord` messages
+// (https://opentelemetry.io/docs/specs/otel/logs/data-model/), encoded as either OTLP/JSON
+// or protobuf. Each formatted record is a single `LogRecord`; the `targets.OTLP` target
+// wraps batches of records with the resource and scope before sending them to a collector.
+//
+// Fields are output as attributes with typed values, meaning ints, floats, bools, bytes,
+// arrays and maps keep their types rather than being converted to strings. Fields named by
+// `TraceIDKey` and `SpanIDKey` containing hex encoded IDs are output as the record's trace
+// and span IDs instead of attributes.
+type OTLP struct {
+	// Encoding is one of "json" (default) or "protobuf".
+	Encoding string `json:"encoding"`
+
+	// EnableCaller enables output of the function, file and line number that emitted a
+	// log record as `code.*` attributes.
+	EnableCaller bool `json:"enable_caller"`
+
+	// DisableStacktrace disables output of the `code.stacktrace` attribute for levels
+	// with stack traces enabled.
+	DisableStacktrace bool `json:"disable_stacktrace"`
+
+	// TraceIDKey is the name of the field containing a hex encoded trace ID.
+	// Defaults to DefaultOTLPTraceIDKey.
+	TraceIDKey string `json:"trace_id_key"`
+
+	// SpanIDKey is the name of the field containing a hex encoded span ID.
+	// Defaults to DefaultOTLPSpanIDKey.
+	SpanIDKey string `json:"span_id_key"`
+
+	// Severities maps custom level IDs to OpenTelemetry severity numbers (1-24). The
+	// standard levels are mapped automatically; other levels are output with an
+	// unspecified severity number unless mapped here.
+	Severities map[logr.LevelID]int32 `json:"severities,omitempty"`
+}
+
+func (o *OTLP) CheckValid() error {
+	switch o.Encoding {
+	case "", OTLPEncodingJSON, OTLPEncodingProtobuf:
+	default:
+		return fmt.Errorf("invalid encoding '%s'", o.Encoding)
+	}
+	for id, sev := range o.Severities {
+		if sev < 1 || sev > 24 {
+			return fmt.Errorf("invalid severity number %d for level %d", sev, id)
+		}
+	}
+	return nil
+}
+
+// IsStacktraceNeeded returns true if a stacktrace is needed so we can output the `code.*` attributes.
+func (o *OTLP) IsStacktraceNeeded() bool {
+	return o.EnableCaller
+}
+
+// Format converts a log record to an OpenTelemetry `LogRecord` in JSON or protobuf format.
+func (o *OTLP) Format(rec *logr.LogRec, level logr.Level, buf *bytes.Buffer) (*bytes.Buffer, error) {
+	if buf == nil {
+		buf = &bytes.Buffer{}
+	}
+
+	lr := o.newLogRecord(rec, level)
+
+	if o.Encoding == OTLPEncodingProtobuf {
+		buf.Write(lr.appendProto(nil))
+		return buf, nil
+	}
+
+	enc := gojay.BorrowEncoder(buf)
+	defer func() {
+		enc.Release()
+	}()
+	if err := enc.EncodeObject(lr); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// severity returns the OpenTelemetry severity number for a level.
+func (o *OTLP) severity(level logr.Level) int32 {
+	if sev, ok := o.Severities[level.ID]; ok {
+		return sev
+	}
+	switch level.ID {
+	case logr.Trace.ID:
+		return 1 // TRACE
+	case logr.Debug.ID:
+		return 5 // DEBUG
+	case logr.Info.ID:
+		return 9 // INFO
+	case logr.Warn.ID:
+		return 13 // WARN
+	case logr.Error.ID:
+		return 17 // ERROR
+	case logr.Fatal.ID, logr.Panic.ID:
+		return 21 // FATAL
+	}
+	return 0 // UNSPECIFIED
+}
+
+func (o *OTLP) newLogRecord(rec *logr.LogRec, level logr.Level) *otlpLogRecord {
+	traceKey := o.TraceIDKey
+	if traceKey == "" {
+		traceKey = DefaultOTLPTraceIDKey
+	}
+	spanKey := o.SpanIDKey
+	if spanKey == "" {
+		spanKey = DefaultOTLPSpanIDKey
+	}
+
+	lr := &otlpLogRecord{
+		time:         rec.Time(),
+		observedTime: time.Now(),
+		severity:     o.severity(level),
+		severityText: level.Name,
+		body:         rec.Msg(),
+	}
+
+	fields := rec.Fields()
+	lr.attrs = make([]otlpKeyValue, 0, len(fields)+3)
+
+	for _, field := range fields {
+		switch {
+		case field.Key == traceKey && lr.traceID == nil:
+			if id := hexID(field, 16); id != nil {
+				lr.traceID = id
+				continue
+			}
+		case field.Key == spanKey && lr.spanID == nil:
+			if id := hexID(field, 8); id != nil {
+				lr.spanID = id
+				continue
+			}
+		}
+		lr.attrs = append(lr.attrs, otlpKeyValue{key: field.Key, value: fieldValue(field)})
+	}
+
+	if o.EnableCaller {
+		for _, frame := range rec.StackFrames() {
+			if frame.File == "" {
+				continue
+			}
+			lr.attrs = append(lr.attrs,
+				otlpKeyValue{key: "code.function", value: otlpValue{kind: otlpString, str: frame.Function}},
+				otlpKeyValue{key: "code.filepath", value: otlpValue{kind: otlpString, str: frame.File}},
+				otlpKeyValue{key: "code.lineno", value: otlpValue{kind: otlpInt, num: int64(frame.Line)}},
+			)
+			break
+		}
+	}
+
+	if level.Stacktrace && !o.DisableStacktrace {
+		if frames := rec.StackFrames(); len(frames) > 0 {
+			var sb strings.Builder
+			for _, frame := range frames {
+				fmt.Fprintf(&sb, "%s\n  %s:%d\n", frame.Function, frame.File, frame.Line)
+			}
+			lr.attrs = append(lr.attrs, otlpKeyValue{key: "code.stacktrace", value: otlpValue{kind: otlpString, str: sb.String()}})
+		}
+	}
+	return lr
+}
+
+// hexID decodes a trace or span ID field of the specified size, returning nil if the
+// field is not a valid, non-zero, hex encoded ID.
+func hexID(field logr.Field, size int) []byte {
+	var s string
+	switch field.Type {
+	case logr.StringType:
+		s = field.String
+	case logr.StringerType:
+		if sv, ok := field.Interface.(fmt.Stringer); ok {
+			s = sv.String()
+		}
+	}
+	if len(s) != size*2 {
+		return nil
+	}
+	id, err := hex.DecodeString(s)
+	if err != nil {
+		return nil
+	}
+	for _, b := range id {
+		if b != 0 {
+			return id
+		}
+	}
+	return nil
+}
+
+type otlpKind uint8
+
+const (
+	otlpString otlpKind = iota
+	otlpBool
+	otlpInt
+	otlpDouble
+	otlpBytes
+	otlpArray
+	otlpKVList
+)
+
+// otlpValue is an OpenTelemetry `AnyValue`.
+type otlpValue struct {
+	kind   otlpKind
+	str    string
+	num    int64
+	float  float64
+	bytes  []byte
+	values otlpValues
+	kvs    otlpKeyValues
+}
+
+// otlpKeyValue is an OpenTelemetry `KeyValue`.
+type otlpKeyValue struct {
+	key   string
+	value otlpValue
+}
+
+type otlpValues []otlpValue
+type otlpKeyValues []otlpKeyValue
+
+// fieldValue converts a field to an `AnyValue`, preserving the field's type where
+// OpenTelemetry has an equivalent.
+func fieldValue(field logr.Field) otlpValue {
+	switch field.Type {
+	case logr.StringType:
+		return otlpValue{kind: otlpString, str: field.String}
+
+	case logr.BoolType:
+		return boolValue(field.Integer != 0)
+
+	case logr.Int64Type, logr.Int32Type, logr.IntType:
+		return otlpValue{kind: otlpInt, num: field.Integer}
+
+	case logr.Uint64Type, logr.Uint32Type, logr.UintType:
+		return uintValue(uint64(field.Integer))
+
+	case logr.Float64Type, logr.Float32Type:
+		return otlpValue{kind: otlpDouble, float: field.Float}
+
+	case logr.BinaryType:
+		if b, ok := field.Interface.([]byte); ok {
+			return otlpValue{kind: otlpBytes, bytes: b}
+		}
+
+	case logr.ArrayType:
+		a := reflect.ValueOf(field.Interface)
+		if a.Kind() == reflect.Slice || a.Kind() == reflect.Array {
+			values := make(otlpValues, 0, a.Len())
+			for i := 0; i < a.Len(); i++ {
+				values = append(values, anyValue(a.Index(i).Interface()))
+			}
+			return otlpValue{kind: otlpArray, values: values}
+		}
+
+	case logr.MapType:
+		m := reflect.ValueOf(field.Interface)
+		if m.Kind() == reflect.Map {
+			kvs := make(otlpKeyValues, 0, m.Len())
+			iter := m.MapRange()
+			for iter.Next() {
+				kvs = append(kvs, otlpKeyValue{
+					key:   fmt.Sprint(iter.Key().Interface()),
+					value: anyValue(iter.Value().Interface()),
+				})
+			}
+			return otlpValue{kind: otlpKVList, kvs: kvs}
+		}
+	}
+
+	var sb strings.Builder
+	if err := field.ValueString(&sb, nil); err != nil {
+		return otlpValue{kind: otlpString, str: fmt.Sprintf("<error encoding field: %v>", err)}
+	}
+	return otlpValue{kind: otlpString, str: sb.String()}
+}
+
+// anyValue converts an array element or map value to an `AnyValue`.
+func anyValue(v interface{}) otlpValue {
+	switch t := v.(type) {
+	case nil:
+		return otlpValue{kind: otlpString}
+	case string:
+		return otlpValue{kind: otlpString, str: t}
+	case bool:
+		return boolValue(t)
+	case int:
+		return otlpValue{kind: otlpInt, num: int64(t)}
+	case int8:
+		return otlpValue{kind: otlpInt, num: int64(t)}
+	case int16:
+		return otlpValue{kind: otlpInt, num: int64(t)}
+	case int32:
+		return otlpValue{kind: otlpInt, num: int64(t)}
+	case int64:
+		return otlpValue{kind: otlpInt, num: t}
+	case uint:
+		return uintValue(uint64(t))
+	case uint8:
+		return uintValue(uint64(t))
+	case uint16:
+		return uintValue(uint64(t))
+	case uint32:
+		return uintValue(uint64(t))
+	case uint64:
+		return uintValue(t)
+	case float32:
+		return otlpValue{kind: otlpDouble, float: float64(t)}
+	case float64:
+		return otlpValue{kind: otlpDouble, float: t}
+	case []byte:
+		return otlpValue{kind: otlpBytes, bytes: t}
+	case logr.LogWriter:
+		var sb strings.Builder
+		if err := t.LogWrite(&sb); err != nil {
+			return otlpValue{kind: otlpString, str: fmt.Sprintf("<error encoding value: %v>", err)}
+		}
+		return otlpValue{kind: otlpString, str: sb.String()}
+	case fmt.Stringer:
+		return otlpValue{kind: otlpString, str: t.String()}
+	case error:
+		return otlpValue{kind: otlpString, str: t.Error()}
+	}
+	return otlpValue{kind: otlpString, str: fmt.Sprintf("%v", v)}
+}
+
+func boolValue(b bool) otlpValue {
+	v := otlpValue{kind: otlpBool}
+	if b {
+		v.num = 1
+	}
+	return v
+}
+
+// uintValue converts an unsigned int to an int `AnyValue`, or a string if it overflows int64.
+func uintValue(u uint64) otlpValue {
+	if u > math.MaxInt64 {
+		return otlpValue{kind: otlpString, str: strconv.FormatUint(u, 10)}
+	}
+	return otlpValue{kind: otlpInt, num: int64(u)}
+}
+
+// otlpLogRecord is an OpenTelemetry `LogRecord`.
+type otlpLogRecord struct {
+	time         time.Time
+	observedTime time.Time
+	severity     int32
+	severityText string
+	body         string
+	attrs        otlpKeyValues
+	traceID      []byte
+	spanID       []byte
+}
+
+// MarshalJSONObject encodes the log record as OTLP/JSON.
+func (lr *otlpLogRecord) MarshalJSONObject(enc *gojay.Encoder) {
+	// 64 bit ints are encoded as strings in OTLP/JSON.
+	enc.AddStringKey("timeUnixNano", strconv.FormatInt(lr.time.UnixNano(), 10))
+	enc.AddStringKey("observedTimeUnixNano", strconv.FormatInt(lr.observedTime.UnixNano(), 10))
+	if lr.severity != 0 {
+		enc.AddInt32Key("severityNumber", lr.severity)
+	}
+	enc.AddStringKey("severityText", lr.severityText)
+	enc.AddObjectKey("body", otlpValue{kind: otlpString, str: lr.body})
+	if len(lr.attrs) > 0 {
+		enc.AddArrayKey("attributes", lr.attrs)
+	}
+	// trace and span IDs are hex encoded in OTLP/JSON, unlike other bytes fields.
+	if lr.traceID != nil {
+		enc.AddStringKey("traceId", hex.EncodeToString(lr.traceID))
+	}
+	if lr.spanID != nil {
+		enc.AddStringKey("spanId", hex.EncodeToString(lr.spanID))
+	}
+}
+
+// IsNil returns true if the log record pointer is nil.
+func (lr *otlpLogRecord) IsNil() bool {
+	return lr == nil
+}
+
+// MarshalJSONObject encodes the value as an OTLP/JSON `AnyValue`.
+func (v otlpValue) MarshalJSONObject(enc *gojay.Encoder) {
+	switch v.kind {
+	case otlpString:
+		enc.AddStringKey("stringValue", v.str)
+	case otlpBool:
+		enc.AddBoolKey("boolValue", v.num != 0)
+	case otlpInt:
+		enc.AddStringKey("intValue", strconv.FormatInt(v.num, 10))
+	case otlpDouble:
+		switch {
+		case math.IsNaN(v.float):
+			enc.AddStringKey("doubleValue", "NaN")
+		case math.IsInf(v.float, 1):
+			enc.AddStringKey("doubleValue", "Infinity")
+		case math.IsInf(v.float, -1):
+			enc.AddStringKey("doubleValue", "-Infinity")
+		default:
+			enc.AddFloat64Key("doubleValue", v.float)
+		}
+	case otlpBytes:
+		enc.AddStringKey("bytesValue", base64.StdEncoding.EncodeToString(v.bytes))
+	case otlpArray:
+		enc.AddObjectKey("arrayValue", v.values)
+	case otlpKVList:
+		enc.AddObjectKey("kvlistValue", v.kvs)
+	}
+}
+
+// IsNil always returns false; an empty `AnyValue` is still output.
+func (v otlpValue) IsNil() bool {
+	return false
+}
+
+// MarshalJSONObject encodes the key/value pair as an OTLP/JSON `KeyValue`.
+func (kv otlpKeyValue) MarshalJSONObject(enc *gojay.Encoder) {
+	enc.AddStringKey("key", kv.key)
+	enc.AddObjectKey("value", kv.value)
+}
+
+// IsNil always returns false.
+func (kv otlpKeyValue) IsNil() bool {
+	return false
+}
+
+// MarshalJSONArray encodes the values as a JSON array.
+func (vs otlpValues) MarshalJSONArray(enc *gojay.Encoder) {
+	for _, v := range vs {
+		enc.AddObject(v)
+	}
+}
+
+// MarshalJSONObject encodes the values as an OTLP/JSON `ArrayValue`.
+func (vs otlpValues) MarshalJSONObject(enc *gojay.Encoder) {
+	enc.AddArrayKeyOmitEmpty("values", vs)
+}
+
+// IsNil returns true if there are no values.
+func (vs otlpValues) IsNil() bool {
+	return len(vs) == 0
+}
+
+// MarshalJSONArray encodes the key/value pairs as a JSON array.
+func (kvs otlpKeyValues) MarshalJSONArray(enc *gojay.Encoder) {
+	for _, kv := range kvs {
+		enc.AddObject(kv)
+	}
+}
+
+// MarshalJSONObject encodes the key/value pairs as an OTLP/JSON `KeyValueList`.
+func (kvs otlpKeyValues) MarshalJSONObject(enc *gojay.Encoder) {
+	enc.AddArrayKeyOmitEmpty("values", kvs)
+}
+
+// IsNil returns true if there are no key/value pairs.
+func (kvs otlpKeyValues) IsNil() bool {
+	return len(kvs) == 0
+}
+
+// Protobuf field numbers from opentelemetry/proto/logs/v1/logs.proto,
+// opentelemetry/proto/common/v1/common.proto, opentelemetry/proto/resource/v1/resource.proto
+// and opentelemetry/proto/collector/logs/v1/logs_service.proto.
+const (
+	pbRequestResourceLogs   = 1 // ExportLogsServiceRequest.resource_logs
+	pbResourceLogsResource  = 1
+	pbResourceLogsScopeLogs = 2
+	pbScopeLogsScope        = 1
+	pbScopeLogsLogRecords   = 2
+	pbResourceAttributes    = 1
+	pbScopeName             = 1
+
+	pbLogRecordTime         = 1
+	pbLogRecordSeverity     = 2
+	pbLogRecordSeverityText = 3
+	pbLogRecordBody         = 5
+	pbLogRecordAttributes   = 6
+	pbLogRecordTraceID      = 9
+	pbLogRecordSpanID       = 10
+	pbLogRecordObservedTime = 11
+
+	pbAnyValueString = 1
+	pbAnyValueBool   = 2
+	pbAnyValueInt    = 3
+	pbAnyValueDouble = 4
+	pbAnyValueArray  = 5
+	pbAnyValueKVList = 6
+	pbAnyValueBytes  = 7
+
+	pbKeyValueKey   = 1
+	pbKeyValueValue = 2
+
+	pbListValues = 1 // ArrayValue.values and KeyValueList.values
+)
+
+// Protobuf wire types.
+const (
+	pbVarint  = 0
+	pbFixed64 = 1
+	pbBytes   = 2
+)
+
+// appendProto appends the log record encoded as a protobuf `LogRecord`. The time is
+// always encoded first so the encoding never starts with '{'.
+func (lr *otlpLogRecord) appendProto(b []byte) []byte {
+	b = pbAppendFixed64(b, pbLogRecordTime, uint64(lr.time.UnixNano()))
+	if lr.severity != 0 {
+		b = pbAppendVarint(b, pbLogRecordSeverity, uint64(lr.severity))
+	}
+	b = pbAppendString(b, pbLogRecordSeverityText, lr.severityText)
+	b = pbAppendMessage(b, pbLogRecordBody, otlpValue{kind: otlpString, str: lr.body}.appendProto)
+	for _, kv := range lr.attrs {
+		b = pbAppendMessage(b, pbLogRecordAttributes, kv.appendProto)
+	}
+	if lr.traceID != nil {
+		b = pbAppendBytes(b, pbLogRecordTraceID, lr.traceID)
+	}
+	if lr.spanID != nil {
+		b = pbAppendBytes(b, pbLogRecordSpanID, lr.spanID)
+	}
+	b = pbAppendFixed64(b, pbLogRecordObservedTime, uint64(lr.observedTime.UnixNano()))
+	return b
+}
+
+// appendProto appends the value encoded as a protobuf `AnyValue`.
+func (v otlpValue) appendProto(b []byte) []byte {
+	switch v.kind {
+	case otlpString:
+		b = pbAppendString(b, pbAnyValueString, v.str)
+	case otlpBool:
+		b = pbAppendVarint(b, pbAnyValueBool, uint64(v.num))
+	case otlpInt:
+		b = pbAppendVarint(b, pbAnyValueInt, uint64(v.num))
+	case otlpDouble:
+		b = pbAppendFixed64(b, pbAnyValueDouble, math.Float64bits(v.float))
+	case otlpBytes:
+		b = pbAppendBytes(b, pbAnyValueBytes, v.bytes)
+	case otlpArray:
+		b = pbAppendMessage(b, pbAnyValueArray, func(b []byte) []byte {
+			for _, item := range v.values {
+				b = pbAppendMessage(b, pbListValues, item.appendProto)
+			}
+			return b
+		})
+	case otlpKVList:
+		b = pbAppendMessage(b, pbAnyValueKVList, func(b []byte) []byte {
+			for _, kv := range v.kvs {
+				b = pbAppendMessage(b, pbListValues, kv.appendProto)
+			}
+			return b
+		})
+	}
+	return b
+}
+
+// appendProto appends the key/value pair encoded as a protobuf `KeyValue`.
+func (kv otlpKeyValue) appendProto(b []byte) []byte {
+	b = pbAppendString(b, pbKeyValueKey, kv.key)
+	return pbAppendMessage(b, pbKeyValueValue, kv.value.appendProto)
+}
+
+// OTLPProtoResource returns an OTLP `Resource` with string attributes, sorted by key,
+// encoded as protobuf for use with `AppendOTLPProtoRequest`.
+func OTLPProtoResource(attrs map[string]string) []byte {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b []byte
+	for _, k := range keys {
+		kv := otlpKeyValue{key: k, value: otlpValue{kind: otlpString, str: attrs[k]}}
+		b = pbAppendMessage(b, pbResourceAttributes, kv.appendProto)
+	}
+	return b
+}
+
+// AppendOTLPProtoRequest appends a protobuf OTLP `ExportLogsServiceRequest` containing
+// the log records, formatted by `OTLP` with protobuf encoding, for the resource returned
+// by `OTLPProtoResource` and the instrumentation scope named scopeName.
+func AppendOTLPProtoRequest(b []byte, resource []byte, scopeName string, records [][]byte) []byte {
+	return pbAppendMessage(b, pbRequestResourceLogs, func(b []byte) []byte {
+		b = pbAppendBytes(b, pbResourceLogsResource, resource)
+		return pbAppendMessage(b, pbResourceLogsScopeLogs, func(b []byte) []byte {
+			b = pbAppendMessage(b, pbScopeLogsScope, func(b []byte) []byte {
+				return pbAppendString(b, pbScopeName, scopeName)
+			})
+			for _, rec := range records {
+				b = pbAppendBytes(b, pbScopeLogsLogRecords, rec)
+			}
+			return b
+		})
+	})
+}
+
+func pbAppendTag(b []byte, field int, wireType int) []byte {
+	return binary.AppendUvarint(b, uint64(field)<<3|uint64(wireType))
+}
+
+func pbAppendVarint(b []byte, field int, v uint64) []byte {
+	b = pbAppendTag(b, field, pbVarint)
+	return binary.AppendUvarint(b, v)
+}
+
+func pbAppendFixed64(b []byte, field int, v uint64) []byte {
+	b = pbAppendTag(b, field, pbFixed64)
+	return binary.LittleEndian.AppendUint64(b, v)
+}
+
+func pbAppendBytes(b []byte, field int, v []byte) []byte {
+	b = pbAppendTag(b, field, pbBytes)
+	b = binary.AppendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+func pbAppendString(b []byte, field int, s string) []byte {
+	b = pbAppendTag(b, field, pbBytes)
+	b = binary.AppendUvarint(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+// pbAppendMessage appends an embedded message encoded by the append func.
+func pbAppendMessage(b []byte, field int, appendMsg func([]byte) []byte) []byte {
+	return pbAppendBytes(b, field, appendMsg(nil))
+}
@@ -0,0 +1,117 @@
+package formatters_test
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/logr/v2"
+	"github.com/mattermost/logr/v2/formatters"
+	"github.com/mattermost/logr/v2/targets"
+	"github.com/mattermost/logr/v2/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOTLPFormatter(t *testing.T) {
+	lgr, err := logr.New()
+	require.NoError(t, err)
+
+	custom := logr.Level{ID: 100, Name: "audit"}
+	unmapped := logr.Level{ID: 101, Name: "other"}
+
+	buf := &test.Buffer{}
+	formatter := &formatters.OTLP{
+		Severities: map[logr.LevelID]int32{custom.ID: 10},
+	}
+	filter := logr.NewCustomFilter(logr.Info, logr.Warn, custom, unmapped)
+	err = lgr.AddTarget(targets.NewWriterTarget(buf), "otlp", filter, formatter, 1000)
+	require.NoError(t, err)
+
+	logger := lgr.NewLogger()
+	logger.Info("typed fields",
+		logr.String("s", "text"),
+		logr.Int("i", -42),
+		logr.Uint64("big", math.MaxUint64),
+		logr.Bool("b", true),
+		logr.Float("f", 3.5),
+		logr.Any("bin", []byte{1, 2, 3}),
+		logr.Array("arr", []int{1, 2}),
+		logr.Map("m", map[string]bool{"ok": true}),
+		logr.String("trace_id", "4bf92f3577b34da6a3ce929d0e0e4736"),
+		logr.String("span_id", "00f067aa0ba902b7"),
+	)
+	logger.Warn("bad ids", logr.String("trace_id", "nothex"), logr.String("span_id", "0000000000000000"))
+	logger.Log(custom, "custom level")
+	logger.Log(unmapped, "unmapped level")
+	require.NoError(t, lgr.Shutdown())
+
+	// records are output back to back.
+	dec := json.NewDecoder(strings.NewReader(buf.String()))
+	var recs []map[string]any
+	for dec.More() {
+		var rec map[string]any
+		require.NoError(t, dec.Decode(&rec))
+		recs = append(recs, rec)
+	}
+	require.Len(t, recs, 4)
+
+	rec := recs[0]
+	assert.Equal(t, float64(9), rec["severityNumber"])
+	assert.Equal(t, "info", rec["severityText"])
+	assert.Equal(t, map[string]any{"stringValue": "typed fields"}, rec["body"])
+	assert.IsType(t, "", rec["timeUnixNano"])
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", rec["traceId"])
+	assert.Equal(t, "00f067aa0ba902b7", rec["spanId"])
+
+	attrs := otlpAttrs(t, rec)
+	assert.Equal(t, map[string]any{"stringValue": "text"}, attrs["s"])
+	assert.Equal(t, map[string]any{"intValue": "-42"}, attrs["i"])
+	assert.Equal(t, map[string]any{"stringValue": "18446744073709551615"}, attrs["big"])
+	assert.Equal(t, map[string]any{"boolValue": true}, attrs["b"])
+	assert.Equal(t, map[string]any{"doubleValue": 3.5}, attrs["f"])
+	assert.Equal(t, map[string]any{"bytesValue": "AQID"}, attrs["bin"])
+	assert.Equal(t, map[string]any{"arrayValue": map[string]any{"values": []any{
+		map[string]any{"intValue": "1"},
+		map[string]any{"intValue": "2"},
+	}}}, attrs["arr"])
+	assert.Equal(t, map[string]any{"kvlistValue": map[string]any{"values": []any{
+		map[string]any{"key": "ok", "value": map[string]any{"boolValue": true}},
+	}}}, attrs["m"])
+	assert.NotContains(t, attrs, "trace_id")
+	assert.NotContains(t, attrs, "span_id")
+
+	// invalid or zero IDs remain attributes.
+	rec = recs[1]
+	assert.Equal(t, float64(13), rec["severityNumber"])
+	assert.NotContains(t, rec, "traceId")
+	assert.NotContains(t, rec, "spanId")
+	attrs = otlpAttrs(t, rec)
+	assert.Contains(t, attrs, "trace_id")
+	assert.Contains(t, attrs, "span_id")
+
+	assert.Equal(t, float64(10), recs[2]["severityNumber"])
+	assert.Equal(t, "audit", recs[2]["severityText"])
+	assert.NotContains(t, recs[3], "severityNumber")
+	assert.Equal(t, "other", recs[3]["severityText"])
+}
+
+func TestOTLPFormatter_CheckValid(t *testing.T) {
+	assert.NoError(t, (&formatters.OTLP{}).CheckValid())
+	assert.NoError(t, (&formatters.OTLP{Encoding: formatters.OTLPEncodingProtobuf}).CheckValid())
+	assert.Error(t, (&formatters.OTLP{Encoding: "xml"}).CheckValid())
+	assert.Error(t, (&formatters.OTLP{Severities: map[logr.LevelID]int32{100: 25}}).CheckValid())
+}
+
+func otlpAttrs(t *testing.T, rec map[string]any) map[string]any {
+	list, ok := rec["attributes"].([]any)
+	require.True(t, ok, "attributes missing")
+
+	attrs := make(map[string]any)
+	for _, item := range list {
+		kv := item.(map[string]any)
+		attrs[kv["key"].(string)] = kv["value"]
+	}
+	return attrs
+}
@@ -0,0 +1,468 @@
+package formatters
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattermost/logr/v2"
+)
+
+const (
+	PrettyTimestampRelative = "relative"
+	PrettyTimestampClock    = "clock"
+	PrettyTimestampNone     = "none"
+
+	PrettyColorAuto   = "auto"
+	PrettyColorAlways = "always"
+	PrettyColorNever  = "never"
+
+	// DefaultPrettyClockFormat is the default format for "clock" timestamps.
+	DefaultPrettyClockFormat = "15:04:05.000"
+
+	defaultPrettyLevelWidth = 5
+	defaultPrettyMsgWidth   = 40
+)
+
+var (
+	ansiReset = []byte("\u001b[0m")
+	ansiDim   = []byte("\u001b[2m")
+	ansiBold  = []byte("\u001b[1m")
+)
+
+// Pretty formats log records for humans reading a terminal during development:
+// colored, fixed-width level badges, short dimmed timestamps, aligned messages,
+// colored `key=value` fields, and compact stack traces. Multiline values and
+// Map or Array fields are output indented on continuation lines.
+//
+// Color is disabled automatically when the output is not a terminal or the
+// NO_COLOR environment variable is set.
+type Pretty struct {
+	// Timestamp is one of "relative" (default), showing time elapsed since the first
+	// record, "clock", showing wall-clock time, or "none".
+	Timestamp string `json:"timestamp"`
+
+	// TimestampFormat is the format of "clock" timestamps. Defaults to DefaultPrettyClockFormat.
+	TimestampFormat string `json:"timestamp_format"`
+
+	// Color is one of "auto" (default), "always" or "never".
+	Color string `json:"color"`
+
+	// Out is the file the formatted output is written to, used to detect whether it is
+	// a terminal when Color is "auto". Defaults to os.Stdout.
+	Out *os.File `json:"-"`
+
+	// LevelWidth is the width of level badges. Longer level names are truncated.
+	// Defaults to 5.
+	LevelWidth int `json:"level_width"`
+
+	// MsgWidth is the width messages are padded to so fields line up. Defaults to 40.
+	MsgWidth int `json:"msg_width"`
+
+	// EnableCaller enables output of the file and line number that emitted a log record.
+	EnableCaller bool `json:"enable_caller"`
+
+	// DisableStacktrace disables output of stack traces.
+	DisableStacktrace bool `json:"disable_stacktrace"`
+
+	// ModuleRelativePaths outputs stack frame file paths relative to the root of the Go
+	// module (or GOROOT) containing them, instead of absolute paths.
+	ModuleRelativePaths bool `json:"module_relative_paths"`
+
+	// FieldSorter allows custom sorting of the fields. If nil then
+	// no sorting is done.
+	FieldSorter func(fields []logr.Field) []logr.Field `json:"-"`
+
+	once   sync.Once
+	color  bool
+	start  time.Time
+	roots  sync.Map // directory -> module root
+	indent string
+}
+
+func (p *Pretty) CheckValid() error {
+	switch p.Timestamp {
+	case "", PrettyTimestampRelative, PrettyTimestampClock, PrettyTimestampNone:
+	default:
+		return fmt.Errorf("invalid timestamp '%s'", p.Timestamp)
+	}
+	switch p.Color {
+	case "", PrettyColorAuto, PrettyColorAlways, PrettyColorNever:
+	default:
+		return fmt.Errorf("invalid color '%s'", p.Color)
+	}
+	if p.LevelWidth < 0 || p.LevelWidth > 64 {
+		return fmt.Errorf("level_width is invalid(%d)", p.LevelWidth)
+	}
+	if p.MsgWidth < 0 || p.MsgWidth > 1024 {
+		return fmt.Errorf("msg_width is invalid(%d)", p.MsgWidth)
+	}
+	return nil
+}
+
+// IsStacktraceNeeded returns true if a stacktrace is needed so we can output the caller.
+func (p *Pretty) IsStacktraceNeeded() bool {
+	return p.EnableCaller
+}
+
+// init applies defaults and detects color support once, on the first record.
+func (p *Pretty) init(first time.Time) {
+	p.once.Do(func() {
+		p.start = first
+		if p.LevelWidth == 0 {
+			p.LevelWidth = defaultPrettyLevelWidth
+		}
+		if p.MsgWidth == 0 {
+			p.MsgWidth = defaultPrettyMsgWidth
+		}
+
+		switch p.Color {
+		case PrettyColorAlways:
+			p.color = true
+		case PrettyColorNever:
+			p.color = false
+		default:
+			out := p.Out
+			if out == nil {
+				out = os.Stdout
+			}
+			_, noColor := os.LookupEnv("NO_COLOR")
+			p.color = !noColor && isTerminal(out)
+		}
+
+		width := p.LevelWidth + 3 // badge plus padding and separator.
+		switch p.Timestamp {
+		case PrettyTimestampNone:
+		case PrettyTimestampClock:
+			width += len(p.clockFormat()) + 1
+		default:
+			width += 9
+		}
+		p.indent = strings.Repeat(" ", width)
+	})
+}
+
+// Format converts a log record to bytes in a human friendly format.
+func (p *Pretty) Format(rec *logr.LogRec, level logr.Level, buf *bytes.Buffer) (*bytes.Buffer, error) {
+	if buf == nil {
+		buf = &bytes.Buffer{}
+	}
+	p.init(rec.Time())
+
+	if ts := p.timestamp(rec.Time()); ts != "" {
+		p.dim(buf, ts)
+		buf.WriteByte(' ')
+	}
+
+	p.badge(buf, level)
+	buf.WriteByte(' ')
+
+	msgLen := 0
+	if name := rec.Logger().Name(); name != "" {
+		p.dim(buf, name+":")
+		buf.WriteByte(' ')
+		msgLen += len(name) + 2
+	}
+	msg, msgCont, _ := strings.Cut(rec.Msg(), "\n")
+	n, _ := buf.WriteString(msg)
+	msgLen += n
+
+	fields := rec.Fields()
+	if p.FieldSorter != nil {
+		fields = p.FieldSorter(fields)
+	}
+
+	// single line fields follow the message; the rest are output on continuation lines.
+	var inline, multiline []logr.Field
+	for _, field := range fields {
+		if p.isMultiline(field) {
+			multiline = append(multiline, field)
+		} else {
+			inline = append(inline, field)
+		}
+	}
+
+	if len(inline) > 0 {
+		if msgLen < p.MsgWidth {
+			buf.WriteString(strings.Repeat(" ", p.MsgWidth-msgLen))
+		}
+		for _, field := range inline {
+			buf.WriteString("  ")
+			if err := p.writeInline(buf, field); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if p.EnableCaller {
+		if caller := rec.Caller(); caller != "" {
+			buf.WriteString("  ")
+			p.dim(buf, caller)
+		}
+	}
+
+	if msgCont != "" {
+		for _, line := range strings.Split(msgCont, "\n") {
+			buf.WriteByte('\n')
+			buf.WriteString(p.indent)
+			buf.WriteString(line)
+		}
+	}
+
+	for _, field := range multiline {
+		if err := p.writeMultiline(buf, field); err != nil {
+			return nil, err
+		}
+	}
+
+	if level.Stacktrace && !p.DisableStacktrace {
+		p.writeStacktrace(buf, rec)
+	}
+
+	buf.WriteByte('\n')
+	return buf, nil
+}
+
+// timestamp returns the short form of t, or an empty string if disabled.
+func (p *Pretty) timestamp(t time.Time) string {
+	switch p.Timestamp {
+	case PrettyTimestampNone:
+		return ""
+	case PrettyTimestampClock:
+		return t.Format(p.clockFormat())
+	}
+
+	d := t.Sub(p.start)
+	sign := "+"
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	var s string
+	switch {
+	case d < time.Minute:
+		s = strconv.FormatFloat(d.Seconds(), 'f', 3, 64) + "s"
+	case d < time.Hour:
+		s = fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+	default:
+		s = fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return fmt.Sprintf("%8s", sign+s)
+}
+
+func (p *Pretty) clockFormat() string {
+	if p.TimestampFormat == "" {
+		return DefaultPrettyClockFormat
+	}
+	return p.TimestampFormat
+}
+
+// badge writes the fixed-width level name, in reverse video using the level's color.
+func (p *Pretty) badge(buf *bytes.Buffer, level logr.Level) {
+	name := strings.ToUpper(level.Name)
+	if len(name) > p.LevelWidth {
+		name = name[:p.LevelWidth]
+	}
+	name += strings.Repeat(" ", p.LevelWidth-len(name))
+
+	if !p.color {
+		buf.WriteString(name)
+		return
+	}
+	if level.Color == logr.NoColor {
+		buf.Write(ansiBold)
+	} else {
+		fmt.Fprintf(buf, "\u001b[1;7;%dm", level.Color)
+	}
+	buf.WriteByte(' ')
+	buf.WriteString(name)
+	buf.WriteByte(' ')
+	buf.Write(ansiReset)
+}
+
+func (p *Pretty) dim(buf *bytes.Buffer, s string) {
+	if !p.color {
+		buf.WriteString(s)
+		return
+	}
+	buf.Write(ansiDim)
+	buf.WriteString(s)
+	buf.Write(ansiReset)
+}
+
+// key writes a field key in red for errors, otherwise in cyan.
+func (p *Pretty) key(buf *bytes.Buffer, field logr.Field) {
+	color := logr.Cyan
+	if field.Type == logr.ErrorType {
+		color = logr.Red
+	}
+	if !p.color {
+		color = logr.NoColor
+	}
+	_ = logr.WriteWithColor(buf, field.Key, color)
+}
+
+func (p *Pretty) isMultiline(field logr.Field) bool {
+	switch field.Type {
+	case logr.MapType, logr.ArrayType:
+		return true
+	case logr.StringType:
+		return strings.Contains(field.String, "\n")
+	case logr.ErrorType, logr.StringerType, logr.UnknownType:
+		return strings.Contains(valueString(field), "\n")
+	}
+	return false
+}
+
+func (p *Pretty) writeInline(buf *bytes.Buffer, field logr.Field) error {
+	p.key(buf, field)
+	p.dim(buf, "=")
+	return field.ValueString(buf, prettyShouldQuote)
+}
+
+// writeMultiline writes a field on continuation lines below the message.
+func (p *Pretty) writeMultiline(buf *bytes.Buffer, field logr.Field) error {
+	buf.WriteByte('\n')
+	buf.WriteString(p.indent)
+	p.key(buf, field)
+	p.dim(buf, ":")
+
+	nested := p.indent + "    "
+	switch field.Type {
+	case logr.MapType:
+		v := reflect.ValueOf(field.Interface)
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		for _, k := range keys {
+			buf.WriteByte('\n')
+			buf.WriteString(nested)
+			p.writeValue(buf, fmt.Sprint(k.Interface()), v.MapIndex(k).Interface(), nested)
+		}
+	case logr.ArrayType:
+		v := reflect.ValueOf(field.Interface)
+		for i := 0; i < v.Len(); i++ {
+			buf.WriteByte('\n')
+			buf.WriteString(nested)
+			p.writeValue(buf, "-", v.Index(i).Interface(), nested)
+		}
+	default:
+		for _, line := range strings.Split(valueString(field), "\n") {
+			buf.WriteByte('\n')
+			buf.WriteString(nested)
+			buf.WriteString(line)
+		}
+	}
+	return nil
+}
+
+// writeValue writes a map entry or array item, indenting any continuation lines.
+func (p *Pretty) writeValue(buf *bytes.Buffer, key string, val any, indent string) {
+	if key == "-" {
+		p.dim(buf, "- ")
+	} else {
+		color := logr.Cyan
+		if !p.color {
+			color = logr.NoColor
+		}
+		_ = logr.WriteWithColor(buf, key, color)
+		p.dim(buf, "=")
+	}
+	var s string
+	switch v := val.(type) {
+	case fmt.Stringer:
+		s = v.String()
+	case error:
+		s = v.Error()
+	default:
+		s = fmt.Sprint(v)
+	}
+	buf.WriteString(strings.ReplaceAll(s, "\n", "\n"+indent+"  "))
+}
+
+// writeStacktrace writes one line per stack frame.
+func (p *Pretty) writeStacktrace(buf *bytes.Buffer, rec *logr.LogRec) {
+	for _, frame := range rec.StackFrames() {
+		buf.WriteByte('\n')
+		buf.WriteString(p.indent)
+		p.dim(buf, "at ")
+		buf.WriteString(frame.Function)
+		if frame.File != "" {
+			buf.WriteByte(' ')
+			p.dim(buf, "("+p.framePath(frame.File)+":"+strconv.Itoa(frame.Line)+")")
+		}
+	}
+}
+
+// framePath returns the frame's file path, optionally relative to its module root.
+func (p *Pretty) framePath(file string) string {
+	if !p.ModuleRelativePaths {
+		return file
+	}
+	dir := filepath.Dir(file)
+	root, ok := p.roots.Load(dir)
+	if !ok {
+		root = findModuleRoot(dir)
+		p.roots.Store(dir, root)
+	}
+	if root == "" {
+		return file
+	}
+	if rel, err := filepath.Rel(root.(string), file); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return file
+}
+
+// findModuleRoot returns the closest directory at or above dir containing a go.mod
+// file, or an empty string if none.
+func findModuleRoot(dir string) string {
+	for {
+		if fi, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil && !fi.IsDir() {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+func valueString(field logr.Field) string {
+	var sb strings.Builder
+	if err := field.ValueString(&sb, nil); err != nil {
+		return fmt.Sprintf("<error encoding field: %v>", err)
+	}
+	return sb.String()
+}
+
+// prettyShouldQuote returns true if val is empty or contains spaces, quotes or control
+// characters, so values stay unambiguous without quoting every punctuation character.
+func prettyShouldQuote(val string) bool {
+	if val == "" {
+		return true
+	}
+	for _, c := range val {
+		if c <= ' ' || c == '"' || c == '=' || c == 0x7f {
+			return true
+		}
+	}
+	return false
+}
+
+// isTerminal returns true if f is a character device such as a terminal.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
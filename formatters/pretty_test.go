@@ -0,0 +1,143 @@
+package formatters_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/mattermost/logr/v2"
+	"github.com/mattermost/logr/v2/formatters"
+	"github.com/mattermost/logr/v2/targets"
+	"github.com/mattermost/logr/v2/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPretty(t *testing.T) {
+	formatter := &formatters.Pretty{Timestamp: formatters.PrettyTimestampNone, Color: formatters.PrettyColorNever, MsgWidth: 20}
+
+	got := logPretty(t, formatter, logr.Error, func(logger logr.Logger) {
+		logger.Named("api").Info("request done", logr.String("user", "wiggin"), logr.Int("status", 200),
+			logr.String("path", "/a b"))
+		logger.Warn("short")
+	})
+
+	want := "INFO  api: request done     user=wiggin  status=200  path=\"/a b\"\n" +
+		"WARN  short\n"
+	assert.Equal(t, want, got)
+}
+
+func TestPrettyMultiline(t *testing.T) {
+	formatter := &formatters.Pretty{Timestamp: formatters.PrettyTimestampNone, Color: formatters.PrettyColorNever, MsgWidth: 1}
+
+	got := logPretty(t, formatter, logr.Error, func(logger logr.Logger) {
+		logger.Info("line one\nline two",
+			logr.Int("n", 1),
+			logr.Map("m", map[string]int{"b": 2, "a": 1}),
+			logr.Array("arr", []string{"x", "y\nz"}),
+			logr.String("s", "first\nsecond"),
+		)
+	})
+
+	want := "INFO  line one  n=1\n" +
+		"        line two\n" +
+		"        m:\n" +
+		"            a=1\n" +
+		"            b=2\n" +
+		"        arr:\n" +
+		"            - x\n" +
+		"            - y\n" +
+		"              z\n" +
+		"        s:\n" +
+		"            first\n" +
+		"            second\n"
+	assert.Equal(t, want, got)
+}
+
+func TestPrettyColor(t *testing.T) {
+	formatter := &formatters.Pretty{Timestamp: formatters.PrettyTimestampNone, Color: formatters.PrettyColorAlways}
+
+	got := logPretty(t, formatter, logr.Error, func(logger logr.Logger) {
+		logger.Info("hello", logr.String("k", "v"))
+		logger.Warn("oops", logr.Err(errors.New("bad")))
+	})
+
+	assert.Contains(t, got, "\u001b[1;7;36m INFO  \u001b[0m hello")
+	assert.Contains(t, got, "\u001b[36mk\u001b[0m\u001b[2m=\u001b[0mv")
+	assert.Contains(t, got, "\u001b[1;7;33m WARN  \u001b[0m oops")
+	assert.Contains(t, got, "\u001b[31merror\u001b[0m")
+}
+
+func TestPrettyColorAuto(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "out.log"))
+	require.NoError(t, err)
+	defer f.Close()
+
+	// a regular file is not a terminal.
+	formatter := &formatters.Pretty{Out: f}
+
+	got := logPretty(t, formatter, logr.Error, func(logger logr.Logger) {
+		logger.Info("hello")
+	})
+	assert.NotContains(t, got, "\u001b[")
+	assert.Regexp(t, regexp.MustCompile(`^ +\+0\.\d{3}s INFO  hello\n$`), got)
+}
+
+func TestPrettyClock(t *testing.T) {
+	formatter := &formatters.Pretty{Timestamp: formatters.PrettyTimestampClock, TimestampFormat: "15:04", Color: formatters.PrettyColorNever}
+
+	got := logPretty(t, formatter, logr.Error, func(logger logr.Logger) {
+		logger.Info("hello")
+	})
+	assert.Regexp(t, regexp.MustCompile(`^\d\d:\d\d INFO  hello\n$`), got)
+}
+
+func TestPrettyStacktrace(t *testing.T) {
+	formatter := &formatters.Pretty{
+		Timestamp:           formatters.PrettyTimestampNone,
+		Color:               formatters.PrettyColorNever,
+		EnableCaller:        true,
+		ModuleRelativePaths: true,
+	}
+
+	got := logPretty(t, formatter, logr.Error, func(logger logr.Logger) {
+		logger.Error("failed")
+	})
+
+	assert.Regexp(t, regexp.MustCompile(`^ERROR failed  formatters/pretty_test\.go:\d+\n`), got)
+	assert.Regexp(t, regexp.MustCompile(`\n        at \S+TestPrettyStacktrace\S* \(formatters/pretty_test\.go:\d+\)\n`), got)
+}
+
+func TestPrettyCheckValid(t *testing.T) {
+	tests := map[string]*formatters.Pretty{
+		"timestamp":   {Timestamp: "sometimes"},
+		"color":       {Color: "rainbow"},
+		"level width": {LevelWidth: -1},
+		"msg width":   {MsgWidth: 5000},
+	}
+	for name, p := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Error(t, p.CheckValid())
+		})
+	}
+
+	p := formatters.Pretty{Timestamp: formatters.PrettyTimestampClock, Color: formatters.PrettyColorAuto}
+	assert.NoError(t, p.CheckValid())
+}
+
+func logPretty(t *testing.T, formatter *formatters.Pretty, stacktrace logr.Level, fn func(logger logr.Logger)) string {
+	t.Helper()
+
+	lgr, err := logr.New()
+	require.NoError(t, err)
+	buf := &test.Buffer{}
+	filter := &logr.StdFilter{Lvl: logr.Info, Stacktrace: stacktrace}
+	err = lgr.AddTarget(targets.NewWriterTarget(buf), "prettyTest", filter, formatter, 1000)
+	require.NoError(t, err)
+
+	fn(lgr.NewLogger())
+	require.NoError(t, lgr.Shutdown())
+	return buf.String()
+}
@@ -11,6 +11,10 @@ type LevelStatus struct {
 	Enabled    bool
 	Stacktrace bool
 	empty      bool
+
+	capture  bool // buffered by a flight recorder when not enabled.
+	trigger  bool // outputs records buffered by a flight recorder.
+	override bool // a target outputs records enabled by a level override.
 }
 
 type levelCache interface {
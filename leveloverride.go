@@ -0,0 +1,202 @@
+package logr
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// levelOverrides is an immutable set of level overrides. A new instance is created
+// each time the overrides change, which also discards the resolved name cache.
+type levelOverrides struct {
+	patterns []levelOverride // most specific first.
+
+	mux   sync.RWMutex
+	cache map[string]*Level // logger name -> override, or nil if none.
+}
+
+type levelOverride struct {
+	pattern string
+	prefix  string // pattern without the trailing "*".
+	level   Level
+}
+
+// SetLevelOverride sets the effective level for loggers created via `Logger.Named` whose
+// name matches pattern, replacing any existing override for the same pattern. The pattern
+// is either an exact logger name such as "store.sql", a prefix such as "store.*" which
+// matches "store" and any logger named beneath it, or "*" which matches all named loggers.
+// When several patterns match, the longest wins.
+//
+// For matching loggers, records at the override level or more severe are output and all
+// others are discarded, regardless of target levels. Records at levels a target's filter
+// excludes are only output to targets that opt in via `SetTargetLevelOverrides`; other
+// targets are unaffected. Overrides only apply to the standard levels Panic through Trace.
+//
+// Overrides can be changed at any time and take effect immediately.
+func (lgr *Logr) SetLevelOverride(pattern string, level Level) error {
+	if err := checkOverridePattern(pattern); err != nil {
+		return err
+	}
+	if level.ID > Trace.ID {
+		return fmt.Errorf("level override must be a standard level, got %s", level.Name)
+	}
+
+	lgr.overrideMux.Lock()
+	defer lgr.overrideMux.Unlock()
+
+	current := lgr.LevelOverrides()
+	current[pattern] = level
+	lgr.storeLevelOverrides(current)
+	return nil
+}
+
+// RemoveLevelOverride removes the override for pattern, if any.
+func (lgr *Logr) RemoveLevelOverride(pattern string) {
+	lgr.overrideMux.Lock()
+	defer lgr.overrideMux.Unlock()
+
+	current := lgr.LevelOverrides()
+	delete(current, pattern)
+	lgr.storeLevelOverrides(current)
+}
+
+// ClearLevelOverrides removes all level overrides.
+func (lgr *Logr) ClearLevelOverrides() {
+	lgr.overrideMux.Lock()
+	defer lgr.overrideMux.Unlock()
+
+	lgr.storeLevelOverrides(nil)
+}
+
+// SetTargetLevelOverrides sets whether the named target(s) output records enabled by a
+// level override at levels their own filter excludes. Targets do not by default, so an
+// override can only make a target more verbose once it opts in. Returns an error if no
+// target with the name exists.
+func (lgr *Logr) SetTargetLevelOverrides(name string, accept bool) error {
+	lgr.tmux.RLock()
+	defer lgr.tmux.RUnlock()
+
+	var found bool
+	for _, host := range lgr.targetHosts {
+		if host.String() == name {
+			host.overrides.Store(accept)
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("target %s not found", name)
+	}
+
+	lgr.ResetLevelCache()
+	return nil
+}
+
+// LevelOverrides returns a copy of the current level overrides, keyed by pattern.
+func (lgr *Logr) LevelOverrides() map[string]Level {
+	m := make(map[string]Level)
+	if lo := lgr.overrides.Load(); lo != nil {
+		for _, o := range lo.patterns {
+			m[o.pattern] = o.level
+		}
+	}
+	return m
+}
+
+// storeLevelOverrides atomically replaces the active overrides. Caller must hold overrideMux.
+func (lgr *Logr) storeLevelOverrides(m map[string]Level) {
+	if len(m) == 0 {
+		lgr.overrides.Store(nil)
+		return
+	}
+
+	lo := &levelOverrides{cache: make(map[string]*Level)}
+	for pattern, level := range m {
+		lo.patterns = append(lo.patterns, levelOverride{
+			pattern: pattern,
+			prefix:  strings.TrimSuffix(pattern, "*"),
+			level:   level,
+		})
+	}
+	sort.Slice(lo.patterns, func(i, j int) bool {
+		return len(lo.patterns[i].pattern) > len(lo.patterns[j].pattern)
+	})
+	lgr.overrides.Store(lo)
+}
+
+// levelOverride returns the override level for the named logger, or nil if none.
+// Does not allocate once the name has been resolved.
+func (lgr *Logr) levelOverride(name string) *Level {
+	lo := lgr.overrides.Load()
+	if lo == nil || name == "" {
+		return nil
+	}
+
+	lo.mux.RLock()
+	level, ok := lo.cache[name]
+	lo.mux.RUnlock()
+	if ok {
+		return level
+	}
+
+	level = lo.resolve(name)
+
+	lo.mux.Lock()
+	lo.cache[name] = level
+	lo.mux.Unlock()
+	return level
+}
+
+// resolve finds the most specific override matching name.
+func (lo *levelOverrides) resolve(name string) *Level {
+	for i := range lo.patterns {
+		o := &lo.patterns[i]
+		if matchOverride(o, name) {
+			return &o.level
+		}
+	}
+	return nil
+}
+
+func matchOverride(o *levelOverride, name string) bool {
+	if o.pattern == "*" {
+		return true
+	}
+	if o.prefix == o.pattern {
+		return name == o.pattern
+	}
+	// "store.*" matches "store" and "store.sql".
+	base := strings.TrimSuffix(o.prefix, ".")
+	return name == base || strings.HasPrefix(name, o.prefix)
+}
+
+func checkOverridePattern(pattern string) error {
+	if pattern == "" {
+		return errors.New("level override pattern cannot be empty")
+	}
+	if idx := strings.Index(pattern, "*"); idx != -1 && idx != len(pattern)-1 {
+		return fmt.Errorf("invalid level override pattern '%s'; '*' is only allowed at the end", pattern)
+	}
+	if pattern != "*" && strings.HasSuffix(pattern, "*") && !strings.HasSuffix(pattern, ".*") {
+		return fmt.Errorf("invalid level override pattern '%s'; use '.*' to match a prefix", pattern)
+	}
+	return nil
+}
+
+// overrideLevelStatus applies a level override to the status of a level.
+func overrideLevelStatus(status LevelStatus, lvl Level, override *Level) (LevelStatus, bool) {
+	if override == nil || lvl.ID > Trace.ID {
+		return status, false
+	}
+	if lvl.ID > override.ID {
+		// flight recorders still capture levels disabled by an override.
+		return LevelStatus{Stacktrace: status.Stacktrace && status.capture, capture: status.capture, trigger: status.trigger}, false
+	}
+	if !status.Enabled && !status.override {
+		// no target outputs the record, so avoid creating it.
+		return status, false
+	}
+	status.Enabled = true
+	return status, true
+}
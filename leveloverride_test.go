@@ -0,0 +1,184 @@
+package logr_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mattermost/logr/v2"
+	"github.com/mattermost/logr/v2/formatters"
+	"github.com/mattermost/logr/v2/targets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoggerNamed(t *testing.T) {
+	lgr, err := logr.New()
+	require.NoError(t, err)
+	defer lgr.Shutdown()
+
+	logger := lgr.NewLogger()
+	assert.Equal(t, "", logger.Name())
+
+	store := logger.Named("store")
+	assert.Equal(t, "store", store.Name())
+	assert.Equal(t, "store.sql", store.Named("sql").Name())
+	assert.Equal(t, "store", store.With(logr.String("a", "b")).Name())
+	assert.Equal(t, "store", store.Named("").Name())
+}
+
+func TestSetLevelOverride(t *testing.T) {
+	lgr, err := logr.New()
+	require.NoError(t, err)
+
+	formatter := &formatters.Plain{DisableTimestamp: true, DisableLevel: true}
+
+	bufMain := &bytes.Buffer{}
+	err = lgr.AddTarget(targets.NewWriterTarget(bufMain), "main", &logr.StdFilter{Lvl: logr.Info}, formatter, 100)
+	require.NoError(t, err)
+
+	bufErrors := &bytes.Buffer{}
+	err = lgr.AddTarget(targets.NewWriterTarget(bufErrors), "errors", &logr.StdFilter{Lvl: logr.Error}, formatter, 100)
+	require.NoError(t, err)
+
+	bufOther := &bytes.Buffer{}
+	err = lgr.AddTarget(targets.NewWriterTarget(bufOther), "other", &logr.StdFilter{Lvl: logr.Info}, formatter, 100)
+	require.NoError(t, err)
+
+	require.NoError(t, lgr.SetTargetLevelOverrides("main", true))
+	assert.Error(t, lgr.SetTargetLevelOverrides("missing", true))
+
+	root := lgr.NewLogger()
+	sql := root.Named("store").Named("sql")
+	api := root.Named("api")
+
+	assert.False(t, sql.IsLevelEnabled(logr.Debug))
+
+	require.NoError(t, lgr.SetLevelOverride("store.*", logr.Debug))
+	require.NoError(t, lgr.SetLevelOverride("api", logr.Warn))
+
+	assert.True(t, sql.IsLevelEnabled(logr.Debug))
+	assert.False(t, sql.IsLevelEnabled(logr.Trace))
+	assert.False(t, root.IsLevelEnabled(logr.Debug), "unnamed loggers are not affected")
+	assert.False(t, api.IsLevelEnabled(logr.Info), "override can reduce verbosity")
+	assert.True(t, api.IsLevelEnabled(logr.Warn))
+
+	sql.Debug("sql debug")
+	root.Debug("root debug")
+	api.Info("api info")
+	api.Error("api error")
+
+	require.NoError(t, lgr.Flush())
+
+	assert.Contains(t, bufMain.String(), "sql debug")
+	assert.NotContains(t, bufMain.String(), "root debug")
+	assert.NotContains(t, bufMain.String(), "api info")
+	assert.Contains(t, bufMain.String(), "api error")
+
+	assert.NotContains(t, bufErrors.String(), "sql debug", "targets that did not opt in are unaffected")
+	assert.Contains(t, bufErrors.String(), "api error")
+
+	assert.NotContains(t, bufOther.String(), "sql debug", "targets that did not opt in are unaffected")
+	assert.NotContains(t, bufOther.String(), "api info")
+	assert.Contains(t, bufOther.String(), "api error")
+
+	// opting out takes effect immediately.
+	require.NoError(t, lgr.SetTargetLevelOverrides("main", false))
+	assert.False(t, sql.IsLevelEnabled(logr.Debug), "no target outputs the record")
+	sql.Debug("sql debug after opt out")
+	require.NoError(t, lgr.Flush())
+	assert.NotContains(t, bufMain.String(), "sql debug after opt out")
+
+	// removing the override takes effect immediately.
+	lgr.RemoveLevelOverride("store.*")
+	assert.False(t, sql.IsLevelEnabled(logr.Debug))
+	assert.Equal(t, map[string]logr.Level{"api": logr.Warn}, lgr.LevelOverrides())
+
+	lgr.ClearLevelOverrides()
+	assert.True(t, api.IsLevelEnabled(logr.Info))
+	assert.Empty(t, lgr.LevelOverrides())
+
+	require.NoError(t, lgr.Shutdown())
+}
+
+func TestLevelOverridePatterns(t *testing.T) {
+	lgr, err := logr.New()
+	require.NoError(t, err)
+	defer lgr.Shutdown()
+
+	err = lgr.AddTarget(targets.NewWriterTarget(&bytes.Buffer{}), "main", &logr.StdFilter{Lvl: logr.Info}, &formatters.Plain{}, 100)
+	require.NoError(t, err)
+	require.NoError(t, lgr.SetTargetLevelOverrides("main", true))
+
+	require.NoError(t, lgr.SetLevelOverride("*", logr.Error))
+	require.NoError(t, lgr.SetLevelOverride("store.*", logr.Debug))
+	require.NoError(t, lgr.SetLevelOverride("store.sql", logr.Trace))
+
+	root := lgr.NewLogger()
+	tests := []struct {
+		name  string
+		level logr.Level
+	}{
+		{"other", logr.Error},
+		{"store", logr.Debug},
+		{"store.cache", logr.Debug},
+		{"store.sql", logr.Trace},
+		{"store.sql.replica", logr.Debug},
+		{"storage", logr.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := root.Named(tt.name)
+			assert.True(t, logger.IsLevelEnabled(tt.level))
+			if tt.level.ID < logr.Trace.ID {
+				next := logr.Level{ID: tt.level.ID + 1}
+				assert.False(t, logger.IsLevelEnabled(next))
+			}
+		})
+	}
+
+	assert.Error(t, lgr.SetLevelOverride("", logr.Debug))
+	assert.Error(t, lgr.SetLevelOverride("store*", logr.Debug))
+	assert.Error(t, lgr.SetLevelOverride("*.sql", logr.Debug))
+	assert.Error(t, lgr.SetLevelOverride("store.*", logr.Level{ID: 100, Name: "custom"}))
+}
+
+func TestLevelOverrideDisabledNoAllocs(t *testing.T) {
+	lgr, err := logr.New()
+	require.NoError(t, err)
+	defer lgr.Shutdown()
+
+	err = lgr.AddTarget(targets.NewWriterTarget(&bytes.Buffer{}), "main", &logr.StdFilter{Lvl: logr.Info}, &formatters.Plain{}, 100)
+	require.NoError(t, err)
+	require.NoError(t, lgr.SetLevelOverride("store.*", logr.Info))
+
+	logger := lgr.NewLogger().Named("store.sql")
+	logger.Debug("warm up name cache")
+
+	allocs := testing.AllocsPerRun(100, func() {
+		logger.Debug("disabled")
+	})
+	assert.Zero(t, allocs)
+}
+
+func TestLevelOverrideWithoutTargetNoAllocs(t *testing.T) {
+	lgr, err := logr.New()
+	require.NoError(t, err)
+	defer lgr.Shutdown()
+
+	err = lgr.AddTarget(targets.NewWriterTarget(&bytes.Buffer{}), "main", &logr.StdFilter{Lvl: logr.Info}, &formatters.Plain{}, 100)
+	require.NoError(t, err)
+	require.NoError(t, lgr.SetLevelOverride("store.*", logr.Debug))
+
+	// the override enables debug, but no target opted in to output it.
+	logger := lgr.NewLogger().Named("store.sql")
+	logger.Debug("warm up name cache")
+	assert.False(t, logger.IsLevelEnabled(logr.Debug))
+
+	allocs := testing.AllocsPerRun(100, func() {
+		logger.Debug("not output")
+	})
+	assert.Zero(t, allocs)
+
+	require.NoError(t, lgr.SetTargetLevelOverrides("main", true))
+	assert.True(t, logger.IsLevelEnabled(logr.Debug))
+}
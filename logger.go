@@ -1,11 +1,18 @@
 package logr
 
-import "log"
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"time"
+)
 
 // Logger provides context for logging via fields.
 type Logger struct {
-	lgr    *Logr
-	fields []Field
+	lgr      *Logr
+	fields   []Field
+	name     string
+	recorder *recorder // optional flight recorder buffer; see `WithRecorder`.
 }
 
 // Logr returns the `Logr` instance that created this `Logger`.
@@ -15,7 +22,7 @@ func (logger Logger) Logr() *Logr {
 
 // With creates a new `Logger` with any existing fields plus the new ones.
 func (logger Logger) With(fields ...Field) Logger {
-	l := Logger{lgr: logger.lgr}
+	l := Logger{lgr: logger.lgr, name: logger.name, recorder: logger.recorder}
 	size := len(logger.fields) + len(fields)
 	if size > 0 {
 		l.fields = make([]Field, 0, size)
@@ -25,6 +32,26 @@ func (logger Logger) With(fields ...Field) Logger {
 	return l
 }
 
+// Named creates a new `Logger` with the specified name appended to any existing name,
+// separated by a period, e.g. `lgr.NewLogger().Named("store").Named("sql")` is named
+// "store.sql". Named loggers are subject to level overrides set via `Logr.SetLevelOverride`.
+// Any fields are preserved.
+func (logger Logger) Named(name string) Logger {
+	l := logger
+	if logger.name != "" && name != "" {
+		name = logger.name + "." + name
+	} else if name == "" {
+		name = logger.name
+	}
+	l.name = name
+	return l
+}
+
+// Name returns the name of this `Logger`, or empty string if not named.
+func (logger Logger) Name() string {
+	return logger.name
+}
+
 // StdLogger creates a standard logger backed by this `Logr.Logger` instance.
 // All log records are emitted with the specified log level.
 func (logger Logger) StdLogger(level Level) *log.Logger {
@@ -34,10 +61,32 @@ func (logger Logger) StdLogger(level Level) *log.Logger {
 // IsLevelEnabled determines if the specified level is enabled for at least
 // one log target.
 func (logger Logger) IsLevelEnabled(level Level) bool {
-	status := logger.Logr().IsLevelEnabled(level)
+	status, _ := logger.levelStatus(level)
 	return status.Enabled
 }
 
+// LevelStatus returns the status of the level for this logger, applying any level
+// override for the logger name. `Enabled` is also true for levels captured by the
+// logger's flight recorder. This is intended for adapters, such as a `log/slog`
+// handler, that need to know whether a record will be discarded before creating it.
+func (logger Logger) LevelStatus(level Level) LevelStatus {
+	status, _ := logger.levelStatus(level)
+	if status.isRecording(logger.recorder) {
+		status.Enabled = true
+	}
+	return status
+}
+
+// levelStatus returns the level status for this logger, applying any level override
+// for the logger name. The returned bool is true if the level is enabled by an override.
+func (logger Logger) levelStatus(lvl Level) (LevelStatus, bool) {
+	status := logger.lgr.IsLevelEnabled(lvl)
+	if logger.name == "" || atomic.LoadInt32(&logger.lgr.shutdown) != 0 {
+		return status, false
+	}
+	return overrideLevelStatus(status, lvl, logger.lgr.levelOverride(logger.name))
+}
+
 // Sugar creates a new `Logger` with a less structured API. Any fields are preserved.
 func (logger Logger) Sugar(fields ...Field) Sugar {
 	return Sugar{
@@ -48,12 +97,36 @@ func (logger Logger) Sugar(fields ...Field) Sugar {
 // Log checks that the level matches one or more targets, and
 // if so, generates a log record that is added to the Logr queue.
 // Arguments are handled in the manner of fmt.Print.
+//
+// Logging at the `Fatal` or `Panic` level flushes all targets and then
+// calls `OnExit` or `OnPanic` respectively, even if the level is not
+// enabled for any target.
 func (logger Logger) Log(lvl Level, msg string, fields ...Field) {
-	status := logger.lgr.IsLevelEnabled(lvl)
-	if status.Enabled {
+	status, overridden := logger.levelStatus(lvl)
+	if status.Enabled || status.isRecording(logger.recorder) {
 		rec := NewLogRec(lvl, logger, msg, fields, status.Stacktrace)
-		logger.lgr.enqueue(rec)
+		rec.levelOverride = overridden
+		logger.lgr.enqueueRecorded(context.Background(), logger.recorder, status, rec)
+	}
+	logger.lgr.exitOrPanic(lvl, msg)
+}
+
+// LogWithCallers is like `Log` but uses the supplied time and stack program counters
+// instead of the current time and the caller's stack. This is intended for adapters,
+// such as a `log/slog` handler, that receive records created elsewhere. The program
+// counters are only used when a stack trace is needed by at least one target; pcs
+// may be nil.
+func (logger Logger) LogWithCallers(t time.Time, lvl Level, msg string, pcs []uintptr, fields ...Field) {
+	status, overridden := logger.levelStatus(lvl)
+	if status.Enabled || status.isRecording(logger.recorder) {
+		if !status.Stacktrace {
+			pcs = nil
+		}
+		rec := newLogRecWithCallers(t, lvl, logger, msg, fields, pcs)
+		rec.levelOverride = overridden
+		logger.lgr.enqueueRecorded(context.Background(), logger.recorder, status, rec)
 	}
+	logger.lgr.exitOrPanic(lvl, msg)
 }
 
 // LogM calls `Log` multiple times, one for each level provided.
@@ -88,12 +161,14 @@ func (logger Logger) Error(msg string, fields ...Field) {
 	logger.Log(Error, msg, fields...)
 }
 
-// Fatal is a convenience method equivalent to `Log(FatalLevel, msg, fields...)`
+// Fatal is a convenience method equivalent to `Log(FatalLevel, msg, fields...)`.
+// All targets are flushed and `OnExit` is called, which defaults to `os.Exit(1)`.
 func (logger Logger) Fatal(msg string, fields ...Field) {
 	logger.Log(Fatal, msg, fields...)
 }
 
-// Panic is a convenience method equivalent to `Log(PanicLevel, msg, fields...)`
+// Panic is a convenience method equivalent to `Log(PanicLevel, msg, fields...)`.
+// All targets are flushed and `OnPanic` is called, which defaults to `panic(msg)`.
 func (logger Logger) Panic(msg string, fields ...Field) {
 	logger.Log(Panic, msg, fields...)
 }
@@ -29,6 +29,12 @@ type Logr struct {
 	metricsMux sync.RWMutex
 	metrics    *metrics
 
+	overrideMux sync.Mutex // serializes level override updates
+	overrides   atomic.Pointer[levelOverrides]
+
+	smux        sync.RWMutex // subscribers mutex
+	subscribers []*subscriber
+
 	shutdown int32
 }
 
@@ -147,15 +153,33 @@ func (lgr *Logr) IsLevelEnabled(lvl Level) LevelStatus {
 	lgr.tmux.RLock()
 	defer lgr.tmux.RUnlock()
 	for _, host := range lgr.targetHosts {
+		if host.paused.Load() {
+			continue
+		}
+		if host.overrides.Load() {
+			status.override = true
+		}
+		filter := host.filter.Load()
 		enabled, level := host.IsLevelEnabled(lvl)
 		if enabled {
 			status.Enabled = true
-			if level.Stacktrace || host.formatter.IsStacktraceNeeded() {
+			if level.Stacktrace || host.formatter.IsStacktraceNeeded() || filter.stacktraceNeeded || host.callerNeeded {
 				status.Stacktrace = true
-				break // if both level and stacktrace enabled then no sense checking more targets
+			}
+		}
+		if filter.recorder != nil {
+			if !enabled && filter.recorder.IsLevelCaptured(lvl) {
+				status.capture = true
+				if host.formatter.IsStacktraceNeeded() || filter.stacktraceNeeded || host.callerNeeded {
+					status.Stacktrace = true
+				}
+			}
+			if filter.recorder.IsLevelTrigger(lvl) {
+				status.trigger = true
 			}
 		}
 	}
+	lgr.subscribersLevelStatus(lvl, &status)
 
 	// Cache and return the result.
 	if err := lgr.lvlCache.put(lvl.ID, status); err != nil {
@@ -196,6 +220,21 @@ func (lgr *Logr) TargetInfos() []TargetInfo {
 	return infos
 }
 
+// Target returns the first target added with the specified name, or nil if none.
+// This provides access to targets created via configuration, such as a `targets.Ring`
+// queried by an in-product log viewer.
+func (lgr *Logr) Target(name string) Target {
+	lgr.tmux.RLock()
+	defer lgr.tmux.RUnlock()
+
+	for _, host := range lgr.targetHosts {
+		if host.String() == name {
+			return host.target
+		}
+	}
+	return nil
+}
+
 // RemoveTargets safely removes one or more targets based on the filtering method.
 // f should return true to delete the target, false to keep it.
 // When removing a target, best effort is made to write any queued log records before
@@ -204,6 +243,7 @@ func (lgr *Logr) TargetInfos() []TargetInfo {
 func (lgr *Logr) RemoveTargets(cxt context.Context, f func(ti TargetInfo) bool) error {
 	errs := merror.New()
 	hosts := make([]*TargetHost, 0)
+	removed := make(map[string]struct{})
 
 	lgr.tmux.Lock()
 	defer lgr.tmux.Unlock()
@@ -217,17 +257,90 @@ func (lgr *Logr) RemoveTargets(cxt context.Context, f func(ti TargetInfo) bool)
 			if err := host.Shutdown(cxt); err != nil {
 				errs.Append(err)
 			}
+			removed[inf.Name] = struct{}{}
 		} else {
 			hosts = append(hosts, host)
 		}
 	}
 
+	// keep metrics shared with a remaining target of the same name.
+	for _, host := range hosts {
+		delete(removed, host.String())
+	}
+	lgr.removeTargetMetrics(removed)
+
 	lgr.targetHosts = hosts
 	lgr.ResetLevelCache()
 
 	return errs.ErrorOrNil()
 }
 
+// SetTargetFilter replaces the filter of the named target(s) without interrupting
+// the target, so queued log records are not lost. Returns an error if no target
+// with the name exists.
+func (lgr *Logr) SetTargetFilter(name string, filter Filter) error {
+	if filter == nil {
+		return errors.New("filter cannot be nil")
+	}
+
+	lgr.tmux.Lock()
+	defer lgr.tmux.Unlock()
+
+	var found bool
+	for _, host := range lgr.targetHosts {
+		if host.String() == name {
+			host.setFilter(filter)
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("target %s not found", name)
+	}
+
+	lgr.ResetLevelCache()
+	return nil
+}
+
+// SetTargetLevels replaces the levels enabled by the filter of the named target(s),
+// keeping any `MatchFilter`, `SampledFilter` or `FlightRecorderFilter` wrapping the
+// levels. Returns an error if no target with the name exists.
+func (lgr *Logr) SetTargetLevels(name string, levels ...Level) error {
+	lgr.tmux.Lock()
+	defer lgr.tmux.Unlock()
+
+	var found bool
+	for _, host := range lgr.targetHosts {
+		if host.String() == name {
+			host.setFilter(withFilterLevels(host.filter.Load().Filter, levels))
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("target %s not found", name)
+	}
+
+	lgr.ResetLevelCache()
+	return nil
+}
+
+// Reopen reopens the files of all targets implementing `Reopener`, typically after the
+// files were rotated by an external tool such as logrotate.
+func (lgr *Logr) Reopen() error {
+	errs := merror.New()
+
+	lgr.tmux.RLock()
+	defer lgr.tmux.RUnlock()
+
+	for _, host := range lgr.targetHosts {
+		if r, ok := host.target.(Reopener); ok {
+			if err := r.Reopen(); err != nil {
+				errs.Append(fmt.Errorf("cannot reopen target %s: %w", host.String(), err))
+			}
+		}
+	}
+	return errs.ErrorOrNil()
+}
+
 // ResetLevelCache resets the cached results of `IsLevelEnabled`. This is
 // called any time a Target is added or a target's level is changed.
 func (lgr *Logr) ResetLevelCache() {
@@ -247,6 +360,13 @@ func (lgr *Logr) SetMetricsCollector(collector MetricsCollector, updateFreqMilli
 // this function either blocks or the log record is dropped, depending on
 // the result of calling `OnQueueFull`.
 func (lgr *Logr) enqueue(rec *LogRec) {
+	lgr.enqueueCtx(context.Background(), rec)
+}
+
+// enqueueCtx adds a log record to the Logr queue. If the queue is full, ctx bounds how
+// long to block: its deadline replaces `EnqueueTimeout`, and cancellation abandons the record.
+// A ctx without a deadline also uses `EnqueueTimeout`.
+func (lgr *Logr) enqueueCtx(ctx context.Context, rec *LogRec) {
 	// check if a limit has been configured
 	if limit := lgr.options.maxFieldLen; limit > 0 {
 		// we limit the message
@@ -276,10 +396,19 @@ func (lgr *Logr) enqueue(rec *LogRec) {
 		if lgr.options.onQueueFull != nil && lgr.options.onQueueFull(rec, cap(lgr.in)) {
 			return // drop the record
 		}
+		var timeout <-chan time.Time
+		if _, hasDeadline := ctx.Deadline(); !hasDeadline {
+			timer := time.NewTimer(lgr.options.enqueueTimeout)
+			defer timer.Stop()
+			timeout = timer.C
+		}
+
 		select {
-		case <-time.After(lgr.options.enqueueTimeout):
+		case <-timeout:
 			lgr.ReportError(fmt.Errorf("enqueue timed out for log rec [%v]", rec))
-		case lgr.in <- rec: // block until success or timeout
+		case <-ctx.Done():
+			lgr.ReportError(fmt.Errorf("enqueue abandoned for log rec [%v]: %w", rec, ctx.Err()))
+		case lgr.in <- rec: // block until success, timeout or context done
 		}
 	}
 }
@@ -302,7 +431,7 @@ func (lgr *Logr) Flush() error {
 // Use `IsTimeoutError` to determine if the returned error is
 // due to a timeout.
 func (lgr *Logr) FlushWithTimeout(ctx context.Context) error {
-	if !lgr.HasTargets() {
+	if !lgr.HasTargets() && !lgr.hasSubscribers() {
 		return nil
 	}
 
@@ -367,6 +496,7 @@ func (lgr *Logr) ShutdownWithTimeout(ctx context.Context) error {
 		errs.Append(newTimeoutError("logr queue shutdown timeout"))
 	case <-lgr.done:
 	}
+	lgr.closeSubscribers()
 
 	// logr.in channel should now be drained to targets and no more log records
 	// can be added.
@@ -394,6 +524,72 @@ func (lgr *Logr) ReportError(err interface{}) {
 	lgr.options.onLoggerError(fmt.Errorf("%v", err))
 }
 
+// isTerminalLevel returns true for levels that terminate the program or goroutine
+// after logging, regardless of whether the level is enabled for any target.
+func isTerminalLevel(lvl Level) bool {
+	return lvl.ID == Fatal.ID || lvl.ID == Panic.ID
+}
+
+// exitOrPanic calls `exit` for `Fatal` level and `panic` for `Panic` level. All other
+// levels are ignored.
+func (lgr *Logr) exitOrPanic(lvl Level, msg string) {
+	if !isTerminalLevel(lvl) {
+		return
+	}
+	if lvl.ID == Fatal.ID {
+		lgr.exit(1)
+		return
+	}
+	lgr.panic(msg)
+}
+
+// exit is called after a `Fatal` level log record is enqueued. All targets are
+// flushed before `OnExit` is called. If no `OnExit` handler was provided then this
+// Logr is shut down and `os.Exit(code)` is called.
+func (lgr *Logr) exit(code int) {
+	ctx, cancel := context.WithTimeout(context.Background(), lgr.options.flushTimeout)
+	defer cancel()
+
+	if lgr.options.onExit != nil {
+		lgr.flushBeforeTerminate(ctx)
+		lgr.options.onExit(code)
+		return
+	}
+
+	if !lgr.IsShutdown() {
+		if err := lgr.ShutdownWithTimeout(ctx); err != nil {
+			lgr.ReportError(err)
+		}
+	}
+	os.Exit(code)
+}
+
+// panic is called after a `Panic` level log record is enqueued. All targets are
+// flushed before `OnPanic` is called. If no `OnPanic` handler was provided then
+// `panic(msg)` is called. The Logr is not shut down since the panic may be recovered.
+func (lgr *Logr) panic(msg string) {
+	ctx, cancel := context.WithTimeout(context.Background(), lgr.options.flushTimeout)
+	defer cancel()
+
+	lgr.flushBeforeTerminate(ctx)
+
+	if lgr.options.onPanic != nil {
+		lgr.options.onPanic(msg)
+		return
+	}
+	panic(msg)
+}
+
+// flushBeforeTerminate flushes all targets, reporting any errors.
+func (lgr *Logr) flushBeforeTerminate(ctx context.Context) {
+	if lgr.IsShutdown() {
+		return
+	}
+	if err := lgr.FlushWithTimeout(ctx); err != nil {
+		lgr.ReportError(err)
+	}
+}
+
 // BorrowBuffer borrows a buffer from the pool. Release the buffer to reduce garbage collection.
 func (lgr *Logr) BorrowBuffer() *bytes.Buffer {
 	if lgr.options.disableBufferPool {
@@ -453,7 +649,14 @@ func (lgr *Logr) fanout(rec *LogRec) {
 	lgr.tmux.RLock()
 	defer lgr.tmux.RUnlock()
 	for _, host = range lgr.targetHosts {
-		if enabled, _ := host.IsLevelEnabled(rec.Level()); enabled {
+		if rec.backfill != nil {
+			if host.acceptsBackfill(rec) {
+				host.Log(rec)
+				logged = true
+			}
+			continue
+		}
+		if enabled, _ := host.IsLevelEnabled(rec.Level()); enabled || (rec.levelOverride && host.acceptsOverride()) {
 			host.Log(rec)
 			logged = true
 		}
@@ -462,6 +665,8 @@ func (lgr *Logr) fanout(rec *LogRec) {
 	if logged {
 		lgr.incLoggedCounter()
 	}
+
+	lgr.publish(rec)
 }
 
 // flush drains the queue and notifies when done.
@@ -11,6 +11,7 @@ import (
 
 	"github.com/mattermost/logr/v2"
 	"github.com/mattermost/logr/v2/formatters"
+	"github.com/mattermost/logr/v2/targets"
 	"github.com/mattermost/logr/v2/test"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -197,3 +198,146 @@ func TestLimitLogFields(t *testing.T) {
 
 	require.Equal(t, 1, numLines)
 }
+
+func TestFatalCallsOnExit(t *testing.T) {
+	buf := &bytes.Buffer{}
+	formatter := &formatters.Plain{DisableTimestamp: true, Delim: " | "}
+	filter := &logr.StdFilter{Lvl: logr.Info, Stacktrace: logr.Panic}
+
+	var exitCode int
+	var output string
+	lgr, _ := logr.New(logr.OnExit(func(code int) {
+		exitCode = code
+		// targets must be flushed before OnExit is called.
+		output = buf.String()
+	}))
+	target := test.NewSlowTarget(buf, 2)
+	err := lgr.AddTarget(target, "fatalTest", filter, formatter, 3000)
+	require.NoError(t, err)
+
+	logger := lgr.NewLogger()
+	for i := 0; i < 10; i++ {
+		logger.Info("before fatal", logr.Int("i", i))
+	}
+	logger.Fatal("this is fatal")
+
+	require.Equal(t, 1, exitCode)
+	require.Contains(t, output, "i=9")
+	require.Contains(t, output, "this is fatal")
+
+	err = lgr.Shutdown()
+	require.NoError(t, err)
+}
+
+func TestFatalCallsOnExitWhenDisabled(t *testing.T) {
+	var called bool
+	lgr, _ := logr.New(logr.OnExit(func(code int) {
+		called = true
+	}))
+	err := lgr.AddTarget(targets.NewWriterTarget(nil), "none", logr.NewCustomFilter(logr.Info), nil, 100)
+	require.NoError(t, err)
+
+	lgr.NewLogger().Fatal("not enabled for any target")
+	require.True(t, called)
+
+	err = lgr.Shutdown()
+	require.NoError(t, err)
+}
+
+func TestPanicCallsOnPanic(t *testing.T) {
+	buf := &bytes.Buffer{}
+	formatter := &formatters.Plain{DisableTimestamp: true, Delim: " | "}
+	filter := &logr.StdFilter{Lvl: logr.Info, Stacktrace: logr.Panic}
+
+	var panicVal interface{}
+	var output string
+	lgr, _ := logr.New(logr.OnPanic(func(err interface{}) {
+		panicVal = err
+		output = buf.String()
+	}))
+	target := test.NewSlowTarget(buf, 2)
+	err := lgr.AddTarget(target, "panicTest", filter, formatter, 3000)
+	require.NoError(t, err)
+
+	lgr.NewLogger().Panic("this is a panic")
+
+	require.Equal(t, "this is a panic", panicVal)
+	require.Contains(t, output, "this is a panic")
+
+	err = lgr.Shutdown()
+	require.NoError(t, err)
+}
+
+func TestPanicDefault(t *testing.T) {
+	buf := &bytes.Buffer{}
+	formatter := &formatters.Plain{DisableTimestamp: true, Delim: " | "}
+	filter := &logr.StdFilter{Lvl: logr.Info}
+
+	lgr, _ := logr.New()
+	err := lgr.AddTarget(targets.NewWriterTarget(buf), "panicTest", filter, formatter, 3000)
+	require.NoError(t, err)
+
+	require.PanicsWithValue(t, "recover me", func() {
+		lgr.NewLogger().Panic("recover me")
+	})
+	require.Contains(t, buf.String(), "recover me")
+
+	// Logr is still usable after a recovered panic.
+	lgr.NewLogger().Info("still logging")
+	err = lgr.Shutdown()
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), "still logging")
+}
+
+func TestSetTargetFilter(t *testing.T) {
+	lgr, err := logr.New()
+	require.NoError(t, err)
+
+	buf := &test.Buffer{}
+	formatter := &formatters.Plain{DisableTimestamp: true, DisableLevel: true}
+	err = lgr.AddTarget(targets.NewWriterTarget(buf), "swap", &logr.StdFilter{Lvl: logr.Info}, formatter, 100)
+	require.NoError(t, err)
+
+	logger := lgr.NewLogger()
+	logger.Debug("before swap")
+	assert.False(t, logger.IsLevelEnabled(logr.Debug))
+
+	err = lgr.SetTargetFilter("swap", &logr.StdFilter{Lvl: logr.Debug})
+	require.NoError(t, err)
+	assert.True(t, logger.IsLevelEnabled(logr.Debug), "level cache should be reset")
+
+	logger.Debug("after swap")
+	require.NoError(t, lgr.Shutdown())
+
+	assert.NotContains(t, buf.String(), "before swap")
+	assert.Contains(t, buf.String(), "after swap")
+
+	assert.Error(t, lgr.SetTargetFilter("missing", &logr.StdFilter{Lvl: logr.Debug}))
+}
+
+func TestSetTargetLevels(t *testing.T) {
+	lgr, err := logr.New()
+	require.NoError(t, err)
+
+	buf := &test.Buffer{}
+	formatter := &formatters.Plain{DisableTimestamp: true, DisableLevel: true}
+	filter := logr.NewSampledFilter(logr.NewMatchFilter(&logr.StdFilter{Lvl: logr.Info}, logr.FieldEquals("tenant", "a")))
+	err = lgr.AddTarget(targets.NewWriterTarget(buf), "levels", filter, formatter, 100)
+	require.NoError(t, err)
+
+	logger := lgr.NewLogger()
+	require.NoError(t, lgr.SetTargetLevels("levels", logr.Error, logr.Debug))
+	assert.True(t, logger.IsLevelEnabled(logr.Debug), "level cache should be reset")
+	assert.False(t, logger.IsLevelEnabled(logr.Info))
+
+	logger.Debug("debug a", logr.String("tenant", "a"))
+	logger.Debug("debug b", logr.String("tenant", "b"))
+	logger.Info("info a", logr.String("tenant", "a"))
+	require.NoError(t, lgr.Shutdown())
+
+	assert.Contains(t, buf.String(), "debug a")
+	assert.NotContains(t, buf.String(), "debug b", "match filter should be kept")
+	assert.NotContains(t, buf.String(), "info a")
+
+	assert.Error(t, lgr.SetTargetLevels("missing", logr.Debug))
+}
@@ -25,6 +25,12 @@ type LogRec struct {
 	stackPC    []uintptr
 	stackCount int
 
+	// level enabled by a level override for the logger name.
+	levelOverride bool
+
+	// level of the record that triggered output by a flight recorder, if backfill.
+	backfill *Level
+
 	// flushes Logr and target queues when not nil.
 	flush chan struct{}
 
@@ -44,6 +50,15 @@ func NewLogRec(lvl Level, logger Logger, msg string, fields []Field, incStacktra
 	return rec
 }
 
+// newLogRecWithCallers creates a new LogRec with the specified time and stack
+// program counters. A zero time is replaced with the current time.
+func newLogRecWithCallers(t time.Time, lvl Level, logger Logger, msg string, fields []Field, pcs []uintptr) *LogRec {
+	if t.IsZero() {
+		t = time.Now()
+	}
+	return &LogRec{time: t, logger: logger, level: lvl, msg: msg, fields: fields, stackPC: pcs, stackCount: len(pcs)}
+}
+
 // newFlushLogRec creates a LogRec that flushes the Logr queue and
 // any target queues that support flushing.
 func newFlushLogRec(logger Logger) *LogRec {
@@ -95,15 +110,40 @@ func (rec *LogRec) WithTime(time time.Time) *LogRec {
 	defer rec.mux.RUnlock()
 
 	return &LogRec{
-		time:       time,
-		level:      rec.level,
-		logger:     rec.logger,
-		msg:        rec.msg,
-		newline:    rec.newline,
-		fields:     rec.fields,
-		stackPC:    rec.stackPC,
-		stackCount: rec.stackCount,
-		frames:     rec.frames,
+		time:          time,
+		level:         rec.level,
+		logger:        rec.logger,
+		msg:           rec.msg,
+		newline:       rec.newline,
+		fields:        rec.fields,
+		stackPC:       rec.stackPC,
+		stackCount:    rec.stackCount,
+		frames:        rec.frames,
+		levelOverride: rec.levelOverride,
+		backfill:      rec.backfill,
+	}
+}
+
+// withFields returns a shallow copy of a prepared log record while replacing the
+// message and all fields, including those added to the logger via `With`.
+func (rec *LogRec) withFields(msg string, fields []Field) *LogRec {
+	rec.mux.RLock()
+	defer rec.mux.RUnlock()
+
+	return &LogRec{
+		time:          rec.time,
+		level:         rec.level,
+		logger:        rec.logger,
+		msg:           msg,
+		newline:       rec.newline,
+		fields:        fields,
+		stackPC:       rec.stackPC,
+		stackCount:    rec.stackCount,
+		frames:        rec.frames,
+		fieldsAll:     fields,
+		caller:        rec.caller,
+		levelOverride: rec.levelOverride,
+		backfill:      rec.backfill,
 	}
 }
 
@@ -26,6 +26,13 @@ type Gauge interface {
 	Sub(float64)
 }
 
+// Histogram is a metrics sink that counts observed values in buckets.
+// Implementations are external to Logr and provided via `ExtendedMetricsCollector`.
+type Histogram interface {
+	// Observe adds a single observation to the histogram.
+	Observe(float64)
+}
+
 // MetricsCollector provides a way for users of this Logr package to have metrics pushed
 // in an efficient way to any backend, e.g. Prometheus.
 // For each target added to Logr, the supplied MetricsCollector will provide a Gauge
@@ -43,6 +50,47 @@ type MetricsCollector interface {
 	BlockedCounter(target string) (Counter, error)
 }
 
+// SamplingMetricsCollector is an optional interface a `MetricsCollector` can implement
+// to count log records suppressed by a target's `RecordSampler`.
+type SamplingMetricsCollector interface {
+	// SuppressedCounter returns a Counter that will be incremented by the named target.
+	SuppressedCounter(target string) (Counter, error)
+}
+
+// ExtendedMetricsCollector is an optional interface a `MetricsCollector` can implement
+// to receive richer per-target metrics. Durations are observed in seconds.
+type ExtendedMetricsCollector interface {
+	// LevelLoggedCounter returns a Counter incremented for each record at the level
+	// output by the named target.
+	LevelLoggedCounter(target string, level Level) (Counter, error)
+	// WrittenBytesCounter returns a Counter incremented by the size of each formatted
+	// record output by the named target.
+	WrittenBytesCounter(target string) (Counter, error)
+	// FormatErrorCounter returns a Counter incremented for each record the named target's
+	// formatter failed to format. These are included in `ErrorCounter`.
+	FormatErrorCounter(target string) (Counter, error)
+	// WriteErrorCounter returns a Counter incremented for each record the named target
+	// failed to write. These are included in `ErrorCounter`.
+	WriteErrorCounter(target string) (Counter, error)
+	// WriteLatencyHistogram returns a Histogram observing the duration of each
+	// `Target.Write` or `BatchTarget.WriteBatch` call of the named target.
+	WriteLatencyHistogram(target string) (Histogram, error)
+	// EnqueueWaitHistogram returns a Histogram observing the time logging blocked
+	// because the named target's queue was full.
+	EnqueueWaitHistogram(target string) (Histogram, error)
+	// LastWriteAgeGauge returns a Gauge set periodically to the time since the named
+	// target last wrote successfully, or since it was added if it has not written yet.
+	LastWriteAgeGauge(target string) (Gauge, error)
+}
+
+// RemovableMetricsCollector is an optional interface a `MetricsCollector` can implement
+// to release the metrics of targets removed via `Logr.RemoveTargets`.
+type RemovableMetricsCollector interface {
+	// RemoveTarget removes all metrics for the named target. It is only called once no
+	// remaining target has the name.
+	RemoveTarget(target string)
+}
+
 // TargetWithMetrics is a target that provides metrics.
 type TargetWithMetrics interface {
 	EnableMetrics(collector MetricsCollector, updateFreqMillis int64) error
@@ -138,3 +186,19 @@ func (lgr *Logr) stopMetricsUpdater() {
 		lgr.metrics.done = nil
 	}
 }
+
+// removeTargetMetrics releases the metrics of removed targets if the collector
+// implements `RemovableMetricsCollector`.
+func (lgr *Logr) removeTargetMetrics(names map[string]struct{}) {
+	lgr.metricsMux.RLock()
+	defer lgr.metricsMux.RUnlock()
+
+	if lgr.metrics == nil {
+		return
+	}
+	if rmc, ok := lgr.metrics.collector.(RemovableMetricsCollector); ok {
+		for name := range names {
+			rmc.RemoveTarget(name)
+		}
+	}
+}
@@ -0,0 +1,456 @@
+// Package metrics provides a `logr.MetricsCollector` that keeps counters and gauges
+// in-process and exposes them in the Prometheus text or OpenMetrics exposition format.
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/mattermost/logr/v2"
+)
+
+const (
+	// DefaultNamespace is the default prefix of metric names.
+	DefaultNamespace = "logr"
+
+	// LabelTarget is the label holding the target name. Metrics for the Logr itself use
+	// the pseudo-target "_logr".
+	LabelTarget = "target"
+
+	// LabelLevel is the label holding the level name of per-level metrics.
+	LabelLevel = "level"
+)
+
+// DefaultDurationBuckets are the default upper bounds, in seconds, of the buckets of
+// duration histograms.
+var DefaultDurationBuckets = []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10}
+
+var metricNameRegex = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// Options provides optional parameters for a Collector.
+type Options struct {
+	// Namespace is the prefix of metric names, e.g. "logr" produces "logr_logged_total".
+	// Defaults to DefaultNamespace.
+	Namespace string
+
+	// DurationBuckets are the upper bounds, in seconds and in increasing order, of the
+	// buckets of the write latency and enqueue wait histograms. Defaults to
+	// DefaultDurationBuckets.
+	DurationBuckets []float64
+}
+
+// CheckValid returns a non-nil error if the options are invalid.
+func (o Options) CheckValid() error {
+	if o.Namespace != "" && !metricNameRegex.MatchString(o.Namespace) {
+		return fmt.Errorf("invalid metrics namespace '%s'", o.Namespace)
+	}
+	for i, b := range o.DurationBuckets {
+		if math.IsNaN(b) || (i > 0 && b <= o.DurationBuckets[i-1]) {
+			return errors.New("duration buckets must be in increasing order")
+		}
+	}
+	return nil
+}
+
+type metricType string
+
+const (
+	typeCounter   metricType = "counter"
+	typeGauge     metricType = "gauge"
+	typeHistogram metricType = "histogram"
+)
+
+// family is a set of metrics with the same name and differing label values.
+type family struct {
+	name   string // excluding namespace and any _total suffix.
+	help   string
+	typ    metricType
+	series map[string]series // keyed by label values.
+}
+
+// series is a counter, gauge or histogram with a set of label values.
+type series interface {
+	labelPairs() []labelPair
+	// count returns the value of a counter or gauge, or the number of observations of a histogram.
+	count() float64
+}
+
+// value holds a counter or gauge value as float64 bits.
+type value struct {
+	labels []labelPair
+	bits   atomic.Uint64
+}
+
+func (v *value) labelPairs() []labelPair {
+	return v.labels
+}
+
+func (v *value) count() float64 {
+	return v.get()
+}
+
+// histogram counts observations in buckets.
+type histogram struct {
+	labels []labelPair
+	upper  []float64
+
+	mux     sync.Mutex
+	buckets []uint64 // non-cumulative counts per bucket; the last is +Inf.
+	sum     float64
+	total   uint64
+}
+
+func (h *histogram) labelPairs() []labelPair {
+	return h.labels
+}
+
+func (h *histogram) count() float64 {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	return float64(h.total)
+}
+
+// Observe adds a single observation to the histogram.
+func (h *histogram) Observe(val float64) {
+	i := sort.SearchFloat64s(h.upper, val) // first bucket with upper bound >= val.
+
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	h.buckets[i]++
+	h.sum += val
+	h.total++
+}
+
+// snapshot returns the cumulative bucket counts, sum and count.
+func (h *histogram) snapshot() ([]uint64, float64, uint64) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	cumulative := make([]uint64, len(h.buckets))
+	var n uint64
+	for i, c := range h.buckets {
+		n += c
+		cumulative[i] = n
+	}
+	return cumulative, h.sum, h.total
+}
+
+type labelPair struct {
+	name  string
+	value string
+}
+
+func (v *value) Inc() {
+	v.Add(1)
+}
+
+func (v *value) Add(val float64) {
+	for {
+		old := v.bits.Load()
+		if v.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+val)) {
+			return
+		}
+	}
+}
+
+func (v *value) Sub(val float64) {
+	v.Add(-val)
+}
+
+func (v *value) Set(val float64) {
+	v.bits.Store(math.Float64bits(val))
+}
+
+func (v *value) get() float64 {
+	return math.Float64frombits(v.bits.Load())
+}
+
+// Collector is a `logr.MetricsCollector` keeping metrics in-process. Pass it to
+// `logr.SetMetricsCollector` and serve it via `ServeHTTP` for scraping. It also
+// implements `logr.SamplingMetricsCollector`, `logr.ExtendedMetricsCollector`,
+// `targets.SpillMetricsCollector` and `logr.RemovableMetricsCollector`, so metrics of
+// targets removed via `Logr.RemoveTargets` are no longer exposed.
+//
+// Metrics have a `target` label, and per-level metrics a `level` label. A Collector
+// should be used with a single Logr.
+type Collector struct {
+	namespace string
+	buckets   []float64
+
+	mux      sync.RWMutex
+	families map[string]*family
+}
+
+// NewCollector creates a Collector.
+func NewCollector(opts Options) (*Collector, error) {
+	if err := opts.CheckValid(); err != nil {
+		return nil, err
+	}
+	c := &Collector{
+		namespace: opts.Namespace,
+		families:  make(map[string]*family),
+	}
+	if c.namespace == "" {
+		c.namespace = DefaultNamespace
+	}
+	c.buckets = opts.DurationBuckets
+	if len(c.buckets) == 0 {
+		c.buckets = DefaultDurationBuckets
+	}
+
+	c.addFamily("queue_size", typeGauge, "Number of log records queued for a target.")
+	c.addFamily("logged", typeCounter, "Number of log records output by a target.")
+	c.addFamily("errors", typeCounter, "Number of log records a target failed to output.")
+	c.addFamily("dropped", typeCounter, "Number of log records dropped because a target queue was full.")
+	c.addFamily("blocked", typeCounter, "Number of times logging blocked because a target queue was full.")
+	c.addFamily("suppressed", typeCounter, "Number of log records suppressed by a target's sampler.")
+	c.addFamily("spilled", typeCounter, "Number of log records written to a target's spill queue.")
+	c.addFamily("replayed", typeCounter, "Number of log records replayed from a target's spill queue.")
+	c.addFamily("discarded", typeCounter, "Number of log records discarded because a target's spill queue was full.")
+	c.addFamily("level_logged", typeCounter, "Number of log records output by a target, per level.")
+	c.addFamily("written_bytes", typeCounter, "Number of bytes of formatted log records output by a target.")
+	c.addFamily("format_errors", typeCounter, "Number of log records a target's formatter failed to format.")
+	c.addFamily("write_errors", typeCounter, "Number of log records a target failed to write.")
+	c.addFamily("write_duration_seconds", typeHistogram, "Duration of target writes, including batch writes.")
+	c.addFamily("enqueue_wait_seconds", typeHistogram, "Time logging blocked because a target queue was full.")
+	c.addFamily("last_write_age_seconds", typeGauge, "Time since a target last wrote successfully, or since it was added.")
+	return c, nil
+}
+
+func (c *Collector) addFamily(name string, typ metricType, help string) {
+	c.families[name] = &family{
+		name:   name,
+		help:   help,
+		typ:    typ,
+		series: make(map[string]series),
+	}
+}
+
+// get returns the series of the named family with the labels, creating it if needed.
+func (c *Collector) get(name string, labels ...labelPair) (series, error) {
+	for _, l := range labels {
+		if l.value == "" {
+			return nil, fmt.Errorf("metric %s requires a %s label value", name, l.name)
+		}
+	}
+	key := labelKey(labels)
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	f, ok := c.families[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown metric %s", name)
+	}
+	ser, ok := f.series[key]
+	if !ok {
+		if f.typ == typeHistogram {
+			ser = &histogram{labels: labels, upper: c.buckets, buckets: make([]uint64, len(c.buckets)+1)}
+		} else {
+			ser = &value{labels: labels}
+		}
+		f.series[key] = ser
+	}
+	return ser, nil
+}
+
+func (c *Collector) targetValue(name string, target string) (*value, error) {
+	ser, err := c.get(name, labelPair{LabelTarget, target})
+	if err != nil {
+		return nil, err
+	}
+	return ser.(*value), nil
+}
+
+func (c *Collector) targetHistogram(name string, target string) (*histogram, error) {
+	ser, err := c.get(name, labelPair{LabelTarget, target})
+	if err != nil {
+		return nil, err
+	}
+	return ser.(*histogram), nil
+}
+
+// QueueSizeGauge returns a Gauge that will be updated by the named target.
+func (c *Collector) QueueSizeGauge(target string) (logr.Gauge, error) {
+	return c.targetValue("queue_size", target)
+}
+
+// LoggedCounter returns a Counter that will be incremented by the named target.
+func (c *Collector) LoggedCounter(target string) (logr.Counter, error) {
+	return c.targetValue("logged", target)
+}
+
+// ErrorCounter returns a Counter that will be incremented by the named target.
+func (c *Collector) ErrorCounter(target string) (logr.Counter, error) {
+	return c.targetValue("errors", target)
+}
+
+// DroppedCounter returns a Counter that will be incremented by the named target.
+func (c *Collector) DroppedCounter(target string) (logr.Counter, error) {
+	return c.targetValue("dropped", target)
+}
+
+// BlockedCounter returns a Counter that will be incremented by the named target.
+func (c *Collector) BlockedCounter(target string) (logr.Counter, error) {
+	return c.targetValue("blocked", target)
+}
+
+// SuppressedCounter returns a Counter that will be incremented by the named target.
+func (c *Collector) SuppressedCounter(target string) (logr.Counter, error) {
+	return c.targetValue("suppressed", target)
+}
+
+// SpilledCounter returns a Counter incremented for each record written to disk.
+func (c *Collector) SpilledCounter(target string) (logr.Counter, error) {
+	return c.targetValue("spilled", target)
+}
+
+// ReplayedCounter returns a Counter incremented for each record replayed from disk.
+func (c *Collector) ReplayedCounter(target string) (logr.Counter, error) {
+	return c.targetValue("replayed", target)
+}
+
+// DiscardedCounter returns a Counter incremented for each record discarded because
+// the spill queue was full.
+func (c *Collector) DiscardedCounter(target string) (logr.Counter, error) {
+	return c.targetValue("discarded", target)
+}
+
+// LevelLoggedCounter returns a Counter incremented for each record at the level output
+// by the named target. Levels without a name are labeled with their ID.
+func (c *Collector) LevelLoggedCounter(target string, level logr.Level) (logr.Counter, error) {
+	name := level.Name
+	if name == "" {
+		name = strconv.FormatUint(uint64(level.ID), 10)
+	}
+	ser, err := c.get("level_logged", labelPair{LabelTarget, target}, labelPair{LabelLevel, name})
+	if err != nil {
+		return nil, err
+	}
+	return ser.(*value), nil
+}
+
+// WrittenBytesCounter returns a Counter incremented by the size of each formatted record
+// output by the named target.
+func (c *Collector) WrittenBytesCounter(target string) (logr.Counter, error) {
+	return c.targetValue("written_bytes", target)
+}
+
+// FormatErrorCounter returns a Counter incremented for each record the named target's
+// formatter failed to format.
+func (c *Collector) FormatErrorCounter(target string) (logr.Counter, error) {
+	return c.targetValue("format_errors", target)
+}
+
+// WriteErrorCounter returns a Counter incremented for each record the named target
+// failed to write.
+func (c *Collector) WriteErrorCounter(target string) (logr.Counter, error) {
+	return c.targetValue("write_errors", target)
+}
+
+// WriteLatencyHistogram returns a Histogram observing the duration of the named
+// target's writes.
+func (c *Collector) WriteLatencyHistogram(target string) (logr.Histogram, error) {
+	return c.targetHistogram("write_duration_seconds", target)
+}
+
+// EnqueueWaitHistogram returns a Histogram observing the time logging blocked because
+// the named target's queue was full.
+func (c *Collector) EnqueueWaitHistogram(target string) (logr.Histogram, error) {
+	return c.targetHistogram("enqueue_wait_seconds", target)
+}
+
+// LastWriteAgeGauge returns a Gauge set to the time since the named target last wrote
+// successfully.
+func (c *Collector) LastWriteAgeGauge(target string) (logr.Gauge, error) {
+	return c.targetValue("last_write_age_seconds", target)
+}
+
+// RemoveTarget removes all metrics for the named target.
+func (c *Collector) RemoveTarget(target string) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	for _, f := range c.families {
+		for key, ser := range f.series {
+			for _, l := range ser.labelPairs() {
+				if l.name == LabelTarget && l.value == target {
+					delete(f.series, key)
+				}
+			}
+		}
+	}
+}
+
+// Value returns the current value of a metric, identified by its name excluding the
+// namespace and any "_total" suffix, e.g. "logged", and its label values in order. For
+// histograms the number of observations is returned. Returns an error if the metric does
+// not exist.
+func (c *Collector) Value(name string, labelValues ...string) (float64, error) {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	f, ok := c.families[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown metric %s", name)
+	}
+	for _, ser := range f.series {
+		labels := ser.labelPairs()
+		if len(labels) != len(labelValues) {
+			continue
+		}
+		match := true
+		for i, l := range labels {
+			if l.value != labelValues[i] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return ser.count(), nil
+		}
+	}
+	return 0, errors.New("metric not found")
+}
+
+// sortedFamilies returns the families ordered by name, for stable output.
+func (c *Collector) sortedFamilies() []*family {
+	families := make([]*family, 0, len(c.families))
+	for _, f := range c.families {
+		families = append(families, f)
+	}
+	sort.Slice(families, func(i, j int) bool { return families[i].name < families[j].name })
+	return families
+}
+
+// sortedSeries returns the family's series ordered by label values.
+func (f *family) sortedSeries() []series {
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	sorted := make([]series, 0, len(keys))
+	for _, key := range keys {
+		sorted = append(sorted, f.series[key])
+	}
+	return sorted
+}
+
+// labelKey returns a unique key for a set of label values.
+func labelKey(labels []labelPair) string {
+	var sb strings.Builder
+	for _, l := range labels {
+		sb.WriteString(l.value)
+		sb.WriteByte(0)
+	}
+	return sb.String()
+}
@@ -0,0 +1,246 @@
+package metrics_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr/v2"
+	"github.com/mattermost/logr/v2/formatters"
+	"github.com/mattermost/logr/v2/metrics"
+	"github.com/mattermost/logr/v2/targets"
+	"github.com/mattermost/logr/v2/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollector(t *testing.T) {
+	collector, err := metrics.NewCollector(metrics.Options{})
+	require.NoError(t, err)
+
+	lgr, err := logr.New(logr.SetMetricsCollector(collector, 1000), logr.OnLoggerError(func(error) {}))
+	require.NoError(t, err)
+	defer lgr.Shutdown()
+
+	filter := &logr.StdFilter{Lvl: logr.Info}
+	err = lgr.AddTarget(targets.NewWriterTarget(&bytes.Buffer{}), "good", filter, &formatters.Plain{}, 100)
+	require.NoError(t, err)
+	err = lgr.AddTarget(test.NewFailingTarget(), "bad", filter, &formatters.Plain{}, 100)
+	require.NoError(t, err)
+
+	logger := lgr.NewLogger()
+	logger.Info("one")
+	logger.Info("two")
+	logger.Debug("filtered")
+	require.NoError(t, lgr.Flush())
+
+	assertValue(t, collector, 2, "logged", "good")
+	assertValue(t, collector, 0, "errors", "good")
+	assertValue(t, collector, 2, "errors", "bad")
+	assertValue(t, collector, 2, "logged", "_logr")
+
+	out := scrape(t, collector, "")
+	assert.Contains(t, out, "# HELP logr_logged_total Number of log records output by a target.\n# TYPE logr_logged_total counter\n")
+	assert.Contains(t, out, `logr_logged_total{target="_logr"} 2`+"\n")
+	assert.Contains(t, out, `logr_logged_total{target="good"} 2`+"\n")
+	assert.Contains(t, out, `logr_errors_total{target="bad"} 2`+"\n")
+	assert.Contains(t, out, "# TYPE logr_queue_size gauge\n")
+	assert.NotContains(t, out, "logr_spilled_total", "families without series are omitted")
+	assert.NotContains(t, out, "# EOF")
+
+	// removed targets are no longer exposed.
+	err = lgr.RemoveTargets(context.Background(), func(ti logr.TargetInfo) bool { return ti.Name == "bad" })
+	require.NoError(t, err)
+	out = scrape(t, collector, "")
+	assert.NotContains(t, out, `target="bad"`)
+	assert.Contains(t, out, `target="good"`)
+	_, err = collector.Value("errors", "bad")
+	assert.Error(t, err)
+}
+
+func TestCollectorSharedName(t *testing.T) {
+	collector, err := metrics.NewCollector(metrics.Options{})
+	require.NoError(t, err)
+
+	lgr, err := logr.New(logr.SetMetricsCollector(collector, 1000))
+	require.NoError(t, err)
+	defer lgr.Shutdown()
+
+	filter := &logr.StdFilter{Lvl: logr.Info}
+	require.NoError(t, lgr.AddTarget(targets.NewWriterTarget(&bytes.Buffer{}), "same", filter, &formatters.Plain{}, 100))
+	require.NoError(t, lgr.AddTarget(targets.NewWriterTarget(&bytes.Buffer{}), "same", filter, &formatters.Plain{}, 100))
+
+	lgr.NewLogger().Info("hello")
+	require.NoError(t, lgr.Flush())
+	assertValue(t, collector, 2, "logged", "same")
+
+	// metrics are kept while another target has the name.
+	var removed bool
+	err = lgr.RemoveTargets(context.Background(), func(ti logr.TargetInfo) bool {
+		remove := !removed
+		removed = true
+		return remove
+	})
+	require.NoError(t, err)
+	require.Len(t, lgr.TargetInfos(), 1)
+	assertValue(t, collector, 2, "logged", "same")
+}
+
+func TestCollectorOpenMetrics(t *testing.T) {
+	collector, err := metrics.NewCollector(metrics.Options{Namespace: "myapp_log"})
+	require.NoError(t, err)
+
+	counter, err := collector.DroppedCounter(`odd "name"`)
+	require.NoError(t, err)
+	counter.Add(3)
+	gauge, err := collector.QueueSizeGauge("q")
+	require.NoError(t, err)
+	gauge.Set(1.5)
+	gauge.Sub(0.5)
+
+	out := scrape(t, collector, "application/openmetrics-text")
+	assert.Contains(t, out, "# TYPE myapp_log_dropped counter\n")
+	assert.Contains(t, out, `myapp_log_dropped_total{target="odd \"name\""} 3`+"\n")
+	assert.Contains(t, out, `myapp_log_queue_size{target="q"} 1`+"\n")
+	assert.True(t, strings.HasSuffix(out, "# EOF\n"))
+
+	_, err = collector.LoggedCounter("")
+	assert.Error(t, err)
+	_, err = metrics.NewCollector(metrics.Options{Namespace: "bad-name"})
+	assert.Error(t, err)
+}
+
+func TestCollectorExtended(t *testing.T) {
+	collector, err := metrics.NewCollector(metrics.Options{})
+	require.NoError(t, err)
+
+	lgr, err := logr.New(logr.SetMetricsCollector(collector, 250), logr.OnLoggerError(func(error) {}))
+	require.NoError(t, err)
+	defer lgr.Shutdown()
+
+	filter := logr.NewCustomFilter(logr.Info, logr.Error, logr.Level{ID: 100})
+	err = lgr.AddTarget(targets.NewWriterTarget(&bytes.Buffer{}), "good", filter, &formatters.Plain{DisableTimestamp: true}, 100)
+	require.NoError(t, err)
+	err = lgr.AddTarget(targets.NewWriterTarget(&bytes.Buffer{}), "badfmt", filter, failingFormatter{}, 100)
+	require.NoError(t, err)
+	err = lgr.AddTarget(test.NewFailingTarget(), "badwrite", filter, &formatters.Plain{}, 100)
+	require.NoError(t, err)
+
+	logger := lgr.NewLogger()
+	logger.Info("one")
+	logger.Info("two")
+	logger.Error("three")
+	logger.Log(logr.Level{ID: 100}, "four")
+	require.NoError(t, lgr.Flush())
+
+	assertLevelValue(t, collector, 2, "good", "info")
+	assertLevelValue(t, collector, 1, "good", "error")
+	assertLevelValue(t, collector, 1, "good", "100")
+	assertValue(t, collector, 4, "write_duration_seconds", "good")
+	assertValue(t, collector, 0, "format_errors", "good")
+	written, err := collector.Value("written_bytes", "good")
+	require.NoError(t, err)
+	assert.Greater(t, written, float64(len("info one three four")))
+
+	assertValue(t, collector, 4, "format_errors", "badfmt")
+	assertValue(t, collector, 0, "write_errors", "badfmt")
+	assertValue(t, collector, 4, "errors", "badfmt")
+	assertValue(t, collector, 0, "write_duration_seconds", "badfmt")
+
+	assertValue(t, collector, 0, "format_errors", "badwrite")
+	assertValue(t, collector, 4, "write_errors", "badwrite")
+	assertValue(t, collector, 4, "write_duration_seconds", "badwrite")
+	_, err = collector.Value("level_logged", "badwrite", "info")
+	assert.Error(t, err)
+
+	// the age of the last write is updated periodically.
+	require.Eventually(t, func() bool {
+		age, _ := collector.Value("last_write_age_seconds", "good")
+		return age > 0
+	}, 5*time.Second, 50*time.Millisecond)
+
+	out := scrape(t, collector, "")
+	assert.Contains(t, out, `logr_level_logged_total{target="good",level="info"} 2`+"\n")
+	assert.Contains(t, out, "# TYPE logr_write_duration_seconds histogram\n")
+	assert.Contains(t, out, `logr_write_duration_seconds_bucket{target="good",le="+Inf"} 4`+"\n")
+	assert.Contains(t, out, `logr_write_duration_seconds_count{target="good"} 4`+"\n")
+	assert.Contains(t, out, `logr_write_duration_seconds_sum{target="good"} `)
+}
+
+func TestCollectorHistogram(t *testing.T) {
+	collector, err := metrics.NewCollector(metrics.Options{DurationBuckets: []float64{0.1, 1}})
+	require.NoError(t, err)
+
+	hist, err := collector.EnqueueWaitHistogram("q")
+	require.NoError(t, err)
+	hist.Observe(0.05)
+	hist.Observe(0.1)
+	hist.Observe(0.5)
+	hist.Observe(2)
+
+	out := scrape(t, collector, "application/openmetrics-text")
+	assert.Contains(t, out, `logr_enqueue_wait_seconds_bucket{target="q",le="0.1"} 2
+logr_enqueue_wait_seconds_bucket{target="q",le="1"} 3
+logr_enqueue_wait_seconds_bucket{target="q",le="+Inf"} 4
+logr_enqueue_wait_seconds_sum{target="q"} 2.65
+logr_enqueue_wait_seconds_count{target="q"} 4
+`)
+
+	_, err = metrics.NewCollector(metrics.Options{DurationBuckets: []float64{1, 0.1}})
+	assert.Error(t, err)
+}
+
+// failingFormatter is a formatter that always fails.
+type failingFormatter struct{}
+
+func (failingFormatter) Format(rec *logr.LogRec, level logr.Level, buf *bytes.Buffer) (*bytes.Buffer, error) {
+	return nil, errors.New("failingFormatter always fails")
+}
+
+func (failingFormatter) IsStacktraceNeeded() bool {
+	return false
+}
+
+func assertLevelValue(t *testing.T, collector *metrics.Collector, expected float64, target string, level string) {
+	t.Helper()
+	val, err := collector.Value("level_logged", target, level)
+	require.NoError(t, err)
+	assert.Equal(t, expected, val, "level_logged{target=%s,level=%s}", target, level)
+}
+
+func assertValue(t *testing.T, collector *metrics.Collector, expected float64, name string, target string) {
+	t.Helper()
+	val, err := collector.Value(name, target)
+	require.NoError(t, err)
+	assert.Equal(t, expected, val, "%s{target=%s}", name, target)
+}
+
+func scrape(t *testing.T, collector *metrics.Collector, accept string) string {
+	t.Helper()
+	server := httptest.NewServer(collector)
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	if accept != "" {
+		assert.Equal(t, metrics.ContentTypeOpenMetrics, resp.Header.Get("Content-Type"))
+	} else {
+		assert.Equal(t, metrics.ContentTypeText, resp.Header.Get("Content-Type"))
+	}
+	b, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(b)
+}
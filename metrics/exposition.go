@@ -0,0 +1,138 @@
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ContentTypeText is the content type of the Prometheus text exposition format.
+	ContentTypeText = "text/plain; version=0.0.4; charset=utf-8"
+
+	// ContentTypeOpenMetrics is the content type of the OpenMetrics text format.
+	ContentTypeOpenMetrics = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+)
+
+// ServeHTTP writes all metrics in the OpenMetrics format if the request accepts
+// "application/openmetrics-text", otherwise in the Prometheus text format.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.Contains(r.Header.Get("Accept"), "application/openmetrics-text") {
+		w.Header().Set("Content-Type", ContentTypeOpenMetrics)
+		_ = c.WriteOpenMetrics(w)
+		return
+	}
+	w.Header().Set("Content-Type", ContentTypeText)
+	_ = c.WriteText(w)
+}
+
+// WriteText writes all metrics in the Prometheus text exposition format.
+func (c *Collector) WriteText(w io.Writer) error {
+	return c.write(w, false)
+}
+
+// WriteOpenMetrics writes all metrics in the OpenMetrics text format.
+func (c *Collector) WriteOpenMetrics(w io.Writer) error {
+	return c.write(w, true)
+}
+
+func (c *Collector) write(w io.Writer, openMetrics bool) error {
+	bw := bufio.NewWriter(w)
+
+	c.mux.RLock()
+	for _, f := range c.sortedFamilies() {
+		if len(f.series) == 0 {
+			continue
+		}
+		name := c.namespace + "_" + f.name
+		sample := name
+		if f.typ == typeCounter {
+			sample += "_total"
+			if !openMetrics {
+				// Prometheus text format names counter families by their samples.
+				name = sample
+			}
+		}
+
+		bw.WriteString("# HELP " + name + " " + escapeHelp(f.help) + "\n")
+		bw.WriteString("# TYPE " + name + " " + string(f.typ) + "\n")
+		for _, ser := range f.sortedSeries() {
+			switch ser := ser.(type) {
+			case *value:
+				writeSample(bw, sample, ser.labels, ser.get())
+			case *histogram:
+				writeHistogram(bw, sample, ser)
+			}
+		}
+	}
+	c.mux.RUnlock()
+
+	if openMetrics {
+		bw.WriteString("# EOF\n")
+	}
+	return bw.Flush()
+}
+
+// writeSample writes a single sample line.
+func writeSample(bw *bufio.Writer, name string, labels []labelPair, val float64) {
+	bw.WriteString(name)
+	if len(labels) > 0 {
+		bw.WriteByte('{')
+		for i, l := range labels {
+			if i > 0 {
+				bw.WriteByte(',')
+			}
+			bw.WriteString(l.name)
+			bw.WriteString(`="`)
+			bw.WriteString(escapeLabelValue(l.value))
+			bw.WriteByte('"')
+		}
+		bw.WriteByte('}')
+	}
+	bw.WriteByte(' ')
+	bw.WriteString(formatValue(val))
+	bw.WriteByte('\n')
+}
+
+// writeHistogram writes the cumulative bucket counts, sum and count of a histogram.
+func writeHistogram(bw *bufio.Writer, name string, h *histogram) {
+	buckets, sum, count := h.snapshot()
+
+	labels := make([]labelPair, len(h.labels), len(h.labels)+1)
+	copy(labels, h.labels)
+	for i, n := range buckets {
+		le := math.Inf(1)
+		if i < len(h.upper) {
+			le = h.upper[i]
+		}
+		writeSample(bw, name+"_bucket", append(labels, labelPair{"le", formatValue(le)}), float64(n))
+	}
+	writeSample(bw, name+"_sum", h.labels, sum)
+	writeSample(bw, name+"_count", h.labels, float64(count))
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+var helpReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func escapeLabelValue(s string) string {
+	return labelValueReplacer.Replace(s)
+}
+
+func escapeHelp(s string) string {
+	return helpReplacer.Replace(s)
+}
+
+func formatValue(val float64) string {
+	switch {
+	case math.IsInf(val, 1):
+		return "+Inf"
+	case math.IsInf(val, -1):
+		return "-Inf"
+	case math.IsNaN(val):
+		return "NaN"
+	}
+	return strconv.FormatFloat(val, 'g', -1, 64)
+}
@@ -3,9 +3,11 @@ package logr_test
 import (
 	"bytes"
 	"testing"
+	"time"
 
 	"github.com/mattermost/logr/v2"
 	"github.com/mattermost/logr/v2/formatters"
+	"github.com/mattermost/logr/v2/metrics"
 	"github.com/mattermost/logr/v2/targets"
 	"github.com/mattermost/logr/v2/test"
 	"github.com/stretchr/testify/require"
@@ -139,3 +141,66 @@ func TestLogr_SetMetricsCollector(t *testing.T) {
 		require.EqualValues(t, 0, metricsTarget2.Errors)
 	})
 }
+
+func TestExtendedMetrics(t *testing.T) {
+	t.Run("batch writes", func(t *testing.T) {
+		collector, err := metrics.NewCollector(metrics.Options{})
+		require.NoError(t, err)
+		lgr, err := logr.New(logr.SetMetricsCollector(collector, 1000))
+		require.NoError(t, err)
+		defer lgr.Shutdown()
+
+		target := &batchTarget{maxRecords: 10, maxLatency: time.Hour}
+		err = lgr.AddTarget(target, "batch", &logr.StdFilter{Lvl: logr.Info}, &formatters.Plain{DisableTimestamp: true}, 1000)
+		require.NoError(t, err)
+
+		logger := lgr.NewLogger()
+		for i := 0; i < 15; i++ {
+			logger.Info("batched")
+		}
+		logger.Warn("batched")
+		require.NoError(t, lgr.Flush())
+
+		output, _, _ := target.get()
+		written, err := collector.Value("written_bytes", "batch")
+		require.NoError(t, err)
+		require.EqualValues(t, len(output), written)
+
+		count, err := collector.Value("level_logged", "batch", "info")
+		require.NoError(t, err)
+		require.EqualValues(t, 15, count)
+		count, err = collector.Value("level_logged", "batch", "warn")
+		require.NoError(t, err)
+		require.EqualValues(t, 1, count)
+
+		// one observation per batch.
+		count, err = collector.Value("write_duration_seconds", "batch")
+		require.NoError(t, err)
+		require.EqualValues(t, 2, count)
+	})
+
+	t.Run("enqueue wait", func(t *testing.T) {
+		collector, err := metrics.NewCollector(metrics.Options{})
+		require.NoError(t, err)
+		lgr, err := logr.New(logr.SetMetricsCollector(collector, 1000))
+		require.NoError(t, err)
+		defer lgr.Shutdown()
+
+		target := test.NewSlowTarget(&bytes.Buffer{}, 20)
+		err = lgr.AddTarget(target, "slow", &logr.StdFilter{Lvl: logr.Info}, &formatters.Plain{}, 1)
+		require.NoError(t, err)
+
+		logger := lgr.NewLogger()
+		for i := 0; i < 5; i++ {
+			logger.Info("slow")
+		}
+		require.NoError(t, lgr.Flush())
+
+		blocked, err := collector.Value("blocked", "slow")
+		require.NoError(t, err)
+		require.Greater(t, blocked, float64(0))
+		count, err := collector.Value("enqueue_wait_seconds", "slow")
+		require.NoError(t, err)
+		require.Equal(t, blocked, count)
+	})
+}
@@ -24,6 +24,7 @@ type options struct {
 	metricsUpdateFreqMillis int64
 	stackFilter             map[string]struct{}
 	maxFieldLen             int
+	contextExtractor        ContextExtractorFunc
 }
 
 // MaxQueueSize is the maximum number of log records that can be queued.
@@ -76,7 +77,8 @@ func OnTargetQueueFull(f func(target Target, rec *LogRec, maxQueueSize int) bool
 	}
 }
 
-// OnExit, when not nil, is called when a FatalXXX style log API is called.
+// OnExit, when not nil, is called when a FatalXXX style log API is called,
+// after all targets have been flushed (bounded by `FlushTimeout`).
 // When nil, the default behavior is to cleanly shut down this Logr and
 // call `os.Exit(code)`.
 func OnExit(f func(code int)) Option {
@@ -86,9 +88,10 @@ func OnExit(f func(code int)) Option {
 	}
 }
 
-// OnPanic, when not nil, is called when a PanicXXX style log API is called.
-// When nil, the default behavior is to cleanly shut down this Logr and
-// call `panic(err)`.
+// OnPanic, when not nil, is called when a PanicXXX style log API is called,
+// after all targets have been flushed (bounded by `FlushTimeout`).
+// When nil, the default behavior is to call `panic(msg)`. The Logr is not
+// shut down since the panic may be recovered.
 func OnPanic(f func(err interface{})) Option {
 	return func(l *Logr) error {
 		l.options.onPanic = f
@@ -98,7 +101,8 @@ func OnPanic(f func(err interface{})) Option {
 
 // EnqueueTimeout is the amount of time a log record can take to be queued.
 // This only applies to blocking enqueue which happen after `logr.OnQueueFull`
-// is called and returns false.
+// is called and returns false. For `Logger.LogCtx`, a context deadline is used
+// instead when present.
 func EnqueueTimeout(dur time.Duration) Option {
 	return func(l *Logr) error {
 		l.options.enqueueTimeout = dur
@@ -106,6 +110,15 @@ func EnqueueTimeout(dur time.Duration) Option {
 	}
 }
 
+// ContextExtractor sets a function that returns fields, such as a request ID or
+// trace/span IDs, to add to log records logged via `Logger.LogCtx`.
+func ContextExtractor(f ContextExtractorFunc) Option {
+	return func(l *Logr) error {
+		l.options.contextExtractor = f
+		return nil
+	}
+}
+
 // ShutdownTimeout is the amount of time `logr.Shutdown` can execute before
 // timing out. An alternative is to use `logr.ShutdownWithContext` and supply
 // a timeout.
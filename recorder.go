@@ -0,0 +1,206 @@
+package logr
+
+import (
+	"context"
+	"sync"
+)
+
+// BackfillKey is the key of the field added to log records output by a flight recorder.
+const BackfillKey = "backfill"
+
+// FlightRecorder is an optional interface that can be implemented by a `Filter` to
+// buffer log records at levels the filter does not enable, instead of discarding them.
+// Records are buffered per `Logger` created via `Logger.WithRecorder`, or per context
+// created via `ContextWithRecorder`. When a record at a trigger level is logged through
+// the same Logger or context, the buffered records are output to the target first,
+// marked as backfill via a `BackfillKey` field.
+//
+// This provides debug detail for failing requests without the cost of outputting debug
+// records for every successful one.
+type FlightRecorder interface {
+	// IsLevelCaptured returns true if records at the level should be buffered when the
+	// level is not enabled.
+	IsLevelCaptured(level Level) bool
+
+	// IsLevelTrigger returns true if a record at the level outputs the buffered records.
+	IsLevelTrigger(level Level) bool
+}
+
+// FlightRecorderFilter wraps a Filter to buffer records at levels up to and including
+// `Capture` that the Filter does not enable, and output them when a record at `Trigger`
+// or more severe is logged. Levels are compared by ID as with `StdFilter`.
+type FlightRecorderFilter struct {
+	Filter
+	Capture Level
+	Trigger Level
+}
+
+// NewFlightRecorderFilter creates a Filter that buffers records at levels up to capture
+// that filter does not enable, until a record at trigger or more severe is logged.
+func NewFlightRecorderFilter(filter Filter, capture Level, trigger Level) *FlightRecorderFilter {
+	return &FlightRecorderFilter{
+		Filter:  filter,
+		Capture: capture,
+		Trigger: trigger,
+	}
+}
+
+// IsLevelCaptured returns true if the level is at or above the `Capture` verbosity.
+func (ff *FlightRecorderFilter) IsLevelCaptured(level Level) bool {
+	return level.ID <= ff.Capture.ID
+}
+
+// IsLevelTrigger returns true if the level is at or above the `Trigger` severity.
+func (ff *FlightRecorderFilter) IsLevelTrigger(level Level) bool {
+	return level.ID <= ff.Trigger.ID
+}
+
+// IsRecordEnabled applies the wrapped Filter's `RecordFilter`, if any.
+func (ff *FlightRecorderFilter) IsRecordEnabled(rec *LogRec) bool {
+	if rf, ok := ff.Filter.(RecordFilter); ok {
+		return rf.IsRecordEnabled(rec)
+	}
+	return true
+}
+
+// Sample applies the wrapped Filter's `RecordSampler`, if any.
+func (ff *FlightRecorderFilter) Sample(rec *LogRec) bool {
+	if sampler, ok := ff.Filter.(RecordSampler); ok {
+		return sampler.Sample(rec)
+	}
+	return true
+}
+
+// IsStacktraceNeeded returns true if the wrapped Filter requires stack traces.
+func (ff *FlightRecorderFilter) IsStacktraceNeeded() bool {
+	if sn, ok := ff.Filter.(interface{ IsStacktraceNeeded() bool }); ok {
+		return sn.IsStacktraceNeeded()
+	}
+	return false
+}
+
+// recorder is a bounded buffer of log records captured by a flight recorder.
+type recorder struct {
+	mux  sync.Mutex
+	recs []*LogRec
+	max  int
+}
+
+func newRecorder(maxRecords int) *recorder {
+	if maxRecords <= 0 {
+		maxRecords = DefaultRecorderMaxRecords
+	}
+	return &recorder{max: maxRecords}
+}
+
+// add buffers a log record, discarding the oldest if full.
+func (r *recorder) add(rec *LogRec) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if len(r.recs) == r.max {
+		copy(r.recs, r.recs[1:])
+		r.recs = r.recs[:r.max-1]
+	}
+	r.recs = append(r.recs, rec)
+}
+
+// drain removes and returns all buffered log records, oldest first.
+func (r *recorder) drain() []*LogRec {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	recs := r.recs
+	r.recs = nil
+	return recs
+}
+
+// WithRecorder creates a new `Logger` with a flight recorder buffer holding up to
+// maxRecords log records, or `DefaultRecorderMaxRecords` if maxRecords is not positive.
+// Loggers derived via `With` and `Named` share the buffer. See `FlightRecorder`.
+func (logger Logger) WithRecorder(maxRecords int) Logger {
+	l := logger
+	l.recorder = newRecorder(maxRecords)
+	return l
+}
+
+type recorderCtxKey struct{}
+
+// ContextWithRecorder returns a copy of ctx carrying a flight recorder buffer holding up
+// to maxRecords log records. Records logged via `Logger.LogCtx` with the context are
+// buffered there instead of in any buffer of the Logger. See `FlightRecorder`.
+func ContextWithRecorder(ctx context.Context, maxRecords int) context.Context {
+	return context.WithValue(ctx, recorderCtxKey{}, newRecorder(maxRecords))
+}
+
+func recorderFromContext(ctx context.Context) *recorder {
+	r, _ := ctx.Value(recorderCtxKey{}).(*recorder)
+	return r
+}
+
+// enqueueRecorded adds a log record to the Logr queue if enabled, first outputting any
+// records buffered in r if the level is a trigger, or buffers the record in r if the
+// level is captured. r may be nil.
+func (lgr *Logr) enqueueRecorded(ctx context.Context, r *recorder, status LevelStatus, rec *LogRec) {
+	if r != nil && status.trigger {
+		for _, buffered := range r.drain() {
+			lgr.enqueueCtx(ctx, buffered.asBackfill(rec.level))
+		}
+	}
+
+	if status.Enabled {
+		lgr.enqueueCtx(ctx, rec)
+	}
+
+	// buffer after enqueueing, so field limits have been applied.
+	if r != nil && status.capture && !status.trigger {
+		r.add(rec)
+	}
+}
+
+// isRecording returns true if a log record with the status should be created even
+// if not enabled, because it is captured by a flight recorder.
+func (status LevelStatus) isRecording(r *recorder) bool {
+	return r != nil && status.capture
+}
+
+// asBackfill returns a copy of an unprepared log record marked as backfill output by a
+// flight recorder, triggered by a record at the trigger level.
+func (rec *LogRec) asBackfill(trigger Level) *LogRec {
+	fields := make([]Field, 0, len(rec.fields)+1)
+	fields = append(fields, rec.fields...)
+	fields = append(fields, Bool(BackfillKey, true))
+
+	return &LogRec{
+		time:          rec.time,
+		level:         rec.level,
+		logger:        rec.logger,
+		msg:           rec.msg,
+		newline:       rec.newline,
+		fields:        fields,
+		stackPC:       rec.stackPC,
+		stackCount:    rec.stackCount,
+		levelOverride: rec.levelOverride,
+		backfill:      &trigger,
+	}
+}
+
+// IsBackfill returns true if this log record was buffered by a flight recorder and
+// output because a record at a trigger level was logged. See `FlightRecorder`.
+func (rec *LogRec) IsBackfill() bool {
+	return rec.backfill != nil
+}
+
+// acceptsBackfill returns true if this target should output a backfill log record, meaning
+// the target captures the record's level without enabling it, and triggers on the level
+// of the record that caused the backfill.
+func (h *TargetHost) acceptsBackfill(rec *LogRec) bool {
+	fr := h.filter.Load().recorder
+	if fr == nil || h.paused.Load() {
+		return false
+	}
+	if enabled, _ := h.IsLevelEnabled(rec.level); enabled || (rec.levelOverride && h.acceptsOverride()) {
+		return false // already output when logged.
+	}
+	return fr.IsLevelCaptured(rec.level) && fr.IsLevelTrigger(*rec.backfill)
+}
@@ -0,0 +1,226 @@
+package logr_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr/v2"
+	"github.com/mattermost/logr/v2/formatters"
+	"github.com/mattermost/logr/v2/targets"
+	"github.com/mattermost/logr/v2/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlightRecorder(t *testing.T) {
+	lgr, buf := newRecorderTestLogr(t)
+	logger := lgr.NewLogger().WithRecorder(10).With(logr.String("req", "1"))
+
+	logger.Debug("d1")
+	logger.Info("i1")
+	logger.Trace("not captured")
+	logger.Debug("d2")
+	logger.Error("boom")
+	logger.Debug("d3")
+	require.NoError(t, lgr.Shutdown())
+
+	want := []string{
+		"info i1 req=1",
+		"debug d1 req=1 backfill=true",
+		"debug d2 req=1 backfill=true",
+		"error boom req=1",
+	}
+	assert.Equal(t, want, recorderLines(buf))
+}
+
+func TestFlightRecorderNoTrigger(t *testing.T) {
+	lgr, buf := newRecorderTestLogr(t)
+
+	logger := lgr.NewLogger().WithRecorder(10)
+	logger.Debug("buffered")
+	logger.Warn("not a trigger")
+
+	// loggers without a recorder discard disabled levels as usual.
+	plain := lgr.NewLogger()
+	plain.Debug("discarded")
+	plain.Error("error")
+	require.NoError(t, lgr.Shutdown())
+
+	assert.Equal(t, []string{"warn not a trigger", "error error"}, recorderLines(buf))
+}
+
+func TestFlightRecorderBounded(t *testing.T) {
+	lgr, buf := newRecorderTestLogr(t)
+	logger := lgr.NewLogger().WithRecorder(2)
+
+	logger.Debug("d1")
+	logger.Debug("d2")
+	logger.Debug("d3")
+	logger.Error("boom")
+	logger.Error("again")
+	require.NoError(t, lgr.Shutdown())
+
+	want := []string{
+		"debug d2 backfill=true",
+		"debug d3 backfill=true",
+		"error boom",
+		"error again",
+	}
+	assert.Equal(t, want, recorderLines(buf))
+}
+
+func TestFlightRecorderSharedByDerivedLoggers(t *testing.T) {
+	lgr, buf := newRecorderTestLogr(t)
+	logger := lgr.NewLogger().WithRecorder(10)
+
+	logger.Named("store").Debug("query")
+	logger.With(logr.Int("n", 1)).Error("failed")
+
+	// a new recorder is not shared.
+	other := logger.WithRecorder(10)
+	other.Debug("other")
+	logger.Error("failed again")
+	require.NoError(t, lgr.Shutdown())
+
+	want := []string{
+		"debug query backfill=true",
+		"error failed n=1",
+		"error failed again",
+	}
+	assert.Equal(t, want, recorderLines(buf))
+}
+
+func TestFlightRecorderOtherTargets(t *testing.T) {
+	lgr, buf := newRecorderTestLogr(t)
+
+	// a target with debug enabled outputs debug records once, as usual.
+	debugBuf := &test.Buffer{}
+	formatter := &formatters.Plain{DisableTimestamp: true}
+	err := lgr.AddTarget(targets.NewWriterTarget(debugBuf), "debug", &logr.StdFilter{Lvl: logr.Debug}, formatter, 100)
+	require.NoError(t, err)
+
+	logger := lgr.NewLogger().WithRecorder(10)
+	logger.Debug("d1")
+	logger.Error("boom")
+	require.NoError(t, lgr.Shutdown())
+
+	assert.Equal(t, []string{"debug d1 backfill=true", "error boom"}, recorderLines(buf))
+	assert.Equal(t, []string{"debug d1", "error boom"}, recorderLines(debugBuf))
+}
+
+func TestFlightRecorderContext(t *testing.T) {
+	lgr, buf := newRecorderTestLogr(t)
+	logger := lgr.NewLogger()
+
+	ctx1 := logr.ContextWithRecorder(context.Background(), 10)
+	ctx2 := logr.ContextWithRecorder(context.Background(), 10)
+
+	logger.LogCtx(ctx1, logr.Debug, "req1 detail")
+	logger.LogCtx(ctx2, logr.Debug, "req2 detail")
+	logger.LogCtx(ctx2, logr.Error, "req2 failed")
+	logger.LogCtx(ctx1, logr.Info, "req1 done")
+	require.NoError(t, lgr.Shutdown())
+
+	want := []string{
+		"debug req2 detail backfill=true",
+		"error req2 failed",
+		"info req1 done",
+	}
+	assert.Equal(t, want, recorderLines(buf))
+}
+
+func TestFlightRecorderSugar(t *testing.T) {
+	lgr, buf := newRecorderTestLogr(t)
+	sugar := lgr.NewLogger().WithRecorder(10).Sugar()
+
+	sugar.Debug("d1")
+	sugar.Debugf("d%d", 2)
+	sugar.Tracef("not captured")
+	sugar.Errorf("boom")
+	require.NoError(t, lgr.Shutdown())
+
+	want := []string{
+		"debug d1 backfill=true",
+		"debug d2 backfill=true",
+		"error boom",
+	}
+	assert.Equal(t, want, recorderLines(buf))
+}
+
+func TestFlightRecorderIsBackfill(t *testing.T) {
+	lgr, err := logr.New()
+	require.NoError(t, err)
+
+	var backfill []bool
+	target := &recTarget{fn: func(rec *logr.LogRec) { backfill = append(backfill, rec.IsBackfill()) }}
+	filter := logr.NewFlightRecorderFilter(&logr.StdFilter{Lvl: logr.Info}, logr.Debug, logr.Error)
+	require.NoError(t, lgr.AddTarget(target, "rec", filter, &formatters.Plain{}, 100))
+
+	logger := lgr.NewLogger().WithRecorder(10)
+	logger.Debug("d1")
+	logger.Error("boom")
+	require.NoError(t, lgr.Shutdown())
+
+	assert.Equal(t, []bool{true, false}, backfill)
+}
+
+func TestFlightRecorderBackfillCopies(t *testing.T) {
+	lgr, err := logr.New()
+	require.NoError(t, err)
+
+	redactor, err := logr.NewRedactor(logr.RedactOptions{MaskKeys: []string{"password"}})
+	require.NoError(t, err)
+
+	var backfill []bool
+	target := &recTarget{fn: func(rec *logr.LogRec) {
+		redacted := redactor.Redact(rec)
+		assert.True(t, rec != redacted, "redacted record should be a copy")
+		backfill = append(backfill, rec.WithTime(time.Now()).IsBackfill(), redacted.IsBackfill())
+	}}
+	filter := logr.NewFlightRecorderFilter(&logr.StdFilter{Lvl: logr.Info}, logr.Debug, logr.Error)
+	require.NoError(t, lgr.AddTarget(target, "rec", filter, &formatters.Plain{}, 100))
+
+	logger := lgr.NewLogger().WithRecorder(10).With(logr.String("password", "hunter2"))
+	logger.Debug("d1")
+	logger.Error("boom")
+	require.NoError(t, lgr.Shutdown())
+
+	assert.Equal(t, []bool{true, true, false, false}, backfill)
+}
+
+func newRecorderTestLogr(t *testing.T) (*logr.Logr, *test.Buffer) {
+	t.Helper()
+
+	lgr, err := logr.New()
+	require.NoError(t, err)
+
+	buf := &test.Buffer{}
+	filter := logr.NewFlightRecorderFilter(&logr.StdFilter{Lvl: logr.Info}, logr.Debug, logr.Error)
+	formatter := &formatters.Plain{DisableTimestamp: true}
+	err = lgr.AddTarget(targets.NewWriterTarget(buf), "recorder", filter, formatter, 100)
+	require.NoError(t, err)
+	return lgr, buf
+}
+
+func recorderLines(buf *test.Buffer) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		lines = append(lines, strings.TrimSpace(line))
+	}
+	return lines
+}
+
+type recTarget struct {
+	fn func(rec *logr.LogRec)
+}
+
+func (rt *recTarget) Init() error { return nil }
+
+func (rt *recTarget) Write(p []byte, rec *logr.LogRec) (int, error) {
+	rt.fn(rec)
+	return len(p), nil
+}
+
+func (rt *recTarget) Shutdown() error { return nil }
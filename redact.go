@@ -0,0 +1,341 @@
+package logr
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// RedactedValue replaces masked fields, scrubbed text and `Secret` field values in
+// formatted output.
+const RedactedValue = "[REDACTED]"
+
+// Scrubber replaces text matching a regular expression within log messages and string fields.
+type Scrubber struct {
+	// Regexp matches the text to replace.
+	Regexp *regexp.Regexp
+
+	// Validate optionally confirms a match, such as checking the Luhn checksum of a credit
+	// card number. Matches failing validation are left unchanged.
+	Validate func(s string) bool
+
+	// Hash replaces matches with a keyed HMAC instead of `RedactedValue`, so values can
+	// still be correlated across log records.
+	Hash bool
+}
+
+var (
+	// ScrubEmails replaces email addresses.
+	ScrubEmails = Scrubber{
+		Regexp: regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`),
+	}
+
+	// ScrubCreditCards replaces credit card numbers, with or without space or dash
+	// separators, that pass the Luhn checksum.
+	ScrubCreditCards = Scrubber{
+		Regexp:   regexp.MustCompile(`\b(?:\d[ \-]?){12,18}\d\b`),
+		Validate: luhnValid,
+	}
+
+	// ScrubTokens replaces bearer tokens and JSON Web Tokens.
+	ScrubTokens = Scrubber{
+		Regexp: regexp.MustCompile(`(?i)\bbearer\s+[a-z0-9\-._~+/]+=*|\beyJ[a-z0-9_\-]+\.[a-z0-9_\-]+\.[a-z0-9_\-]+`),
+	}
+)
+
+// RedactOptions configures a Redactor.
+//
+// Keys are matched case-insensitively and may contain glob wildcards, where '*' matches
+// any sequence of characters and '?' matches any single character; for example "*password*"
+// or "user.?mail". When a key matches more than one list, drop takes precedence over hash,
+// which takes precedence over mask.
+type RedactOptions struct {
+	// MaskKeys are fields whose values are replaced with `RedactedValue`.
+	MaskKeys []string
+
+	// DropKeys are fields removed from log records.
+	DropKeys []string
+
+	// HashKeys are fields whose values are replaced with a keyed HMAC, so they can be
+	// correlated without being revealed.
+	HashKeys []string
+
+	// HashSecret is the HMAC key used for HashKeys and Scrubbers with Hash enabled.
+	HashSecret []byte
+
+	// Scrubbers replace matching text within log messages and string, error and
+	// `fmt.Stringer` fields.
+	Scrubbers []Scrubber
+
+	// RevealSecrets outputs the values of `Secret` fields instead of `RedactedValue`.
+	RevealSecrets bool
+}
+
+// Redactor masks, drops, hashes or scrubs sensitive data in log records before they are
+// formatted. Use `NewRedactingFormatter` to apply a Redactor to a target.
+type Redactor struct {
+	mask keyMatcher
+	drop keyMatcher
+	hash keyMatcher
+
+	hashSecret    []byte
+	scrubbers     []Scrubber
+	revealSecrets bool
+}
+
+// NewRedactor creates a Redactor for the specified options.
+func NewRedactor(opts RedactOptions) (*Redactor, error) {
+	r := &Redactor{
+		hashSecret:    append([]byte(nil), opts.HashSecret...),
+		scrubbers:     append([]Scrubber(nil), opts.Scrubbers...),
+		revealSecrets: opts.RevealSecrets,
+	}
+
+	var err error
+	if r.mask, err = newKeyMatcher(opts.MaskKeys); err != nil {
+		return nil, err
+	}
+	if r.drop, err = newKeyMatcher(opts.DropKeys); err != nil {
+		return nil, err
+	}
+	if r.hash, err = newKeyMatcher(opts.HashKeys); err != nil {
+		return nil, err
+	}
+
+	needSecret := !r.hash.empty()
+	for _, sc := range r.scrubbers {
+		if sc.Regexp == nil {
+			return nil, errors.New("scrubber regexp cannot be nil")
+		}
+		needSecret = needSecret || sc.Hash
+	}
+	if needSecret && len(r.hashSecret) == 0 {
+		return nil, errors.New("hash secret is required for hashed keys or scrubbers")
+	}
+	return r, nil
+}
+
+// Redact returns a log record with the redaction rules applied. The original record is
+// returned if nothing was redacted, otherwise a shallow copy; the original record is
+// never modified since it is shared by all targets.
+func (r *Redactor) Redact(rec *LogRec) *LogRec {
+	msg := rec.Msg()
+	scrubbed := r.scrub(msg)
+
+	fields := rec.Fields()
+	var redacted []Field // nil until a field changes.
+	for i, field := range fields {
+		f, keep, changed := r.redactField(field)
+		if changed && redacted == nil {
+			redacted = make([]Field, 0, len(fields))
+			redacted = append(redacted, fields[:i]...)
+		}
+		if redacted != nil && keep {
+			redacted = append(redacted, f)
+		}
+	}
+
+	if redacted == nil {
+		if scrubbed == msg {
+			return rec
+		}
+		redacted = fields
+	}
+	return rec.withFields(scrubbed, redacted)
+}
+
+// redactField applies the rules to a single field. Returns the redacted field, whether
+// the field should be kept, and whether the field changed.
+func (r *Redactor) redactField(field Field) (Field, bool, bool) {
+	switch {
+	case r.drop.match(field.Key):
+		return field, false, true
+	case r.hash.match(field.Key):
+		return String(field.Key, r.hashValue(fieldString(field.Reveal()))), true, true
+	case r.mask.match(field.Key):
+		return String(field.Key, RedactedValue), true, true
+	}
+
+	changed := false
+	if field.Type == SecretType {
+		if !r.revealSecrets {
+			return field, true, false
+		}
+		field = field.Reveal()
+		changed = true
+	}
+
+	if len(r.scrubbers) > 0 {
+		switch field.Type {
+		case StringType:
+			if s := r.scrub(field.String); s != field.String {
+				field.String = s
+				changed = true
+			}
+		case StringerType, ErrorType:
+			orig := fieldString(field)
+			if s := r.scrub(orig); s != orig {
+				field = String(field.Key, s)
+				changed = true
+			}
+		}
+	}
+	return field, true, changed
+}
+
+// scrub applies the scrubbers to s, returning s unchanged if nothing matched.
+func (r *Redactor) scrub(s string) string {
+	for _, sc := range r.scrubbers {
+		if !sc.Regexp.MatchString(s) {
+			continue
+		}
+		sc := sc
+		s = sc.Regexp.ReplaceAllStringFunc(s, func(match string) string {
+			if sc.Validate != nil && !sc.Validate(match) {
+				return match
+			}
+			if sc.Hash {
+				return r.hashValue(match)
+			}
+			return RedactedValue
+		})
+	}
+	return s
+}
+
+// hashValue returns the hex encoded, truncated HMAC-SHA256 of s.
+func (r *Redactor) hashValue(s string) string {
+	mac := hmac.New(sha256.New, r.hashSecret)
+	mac.Write([]byte(s))
+	return "hmac:" + hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// fieldString returns the field value as a string using default formatting.
+func fieldString(field Field) string {
+	if field.Type == StringType {
+		return field.String
+	}
+	var buf bytes.Buffer
+	_ = field.ValueString(&buf, nil)
+	return buf.String()
+}
+
+// RedactingFormatter applies a Redactor to log records before formatting them with the
+// wrapped Formatter.
+type RedactingFormatter struct {
+	Formatter
+	redactor *Redactor
+}
+
+// NewRedactingFormatter creates a Formatter that redacts log records before formatting
+// them with formatter.
+func NewRedactingFormatter(formatter Formatter, redactor *Redactor) *RedactingFormatter {
+	return &RedactingFormatter{
+		Formatter: formatter,
+		redactor:  redactor,
+	}
+}
+
+// Format redacts the log record and formats it with the wrapped Formatter.
+func (rf *RedactingFormatter) Format(rec *LogRec, level Level, buf *bytes.Buffer) (*bytes.Buffer, error) {
+	return rf.Formatter.Format(rf.redactor.Redact(rec), level, buf)
+}
+
+// keyMatcher matches field keys against exact names and glob patterns, ignoring case.
+type keyMatcher struct {
+	exact map[string]struct{}
+	globs []string
+}
+
+func newKeyMatcher(patterns []string) (keyMatcher, error) {
+	var km keyMatcher
+	for _, p := range patterns {
+		if p == "" {
+			return km, errors.New("key pattern cannot be empty")
+		}
+		p = strings.ToLower(p)
+		if strings.ContainsAny(p, "*?") {
+			km.globs = append(km.globs, p)
+			continue
+		}
+		if km.exact == nil {
+			km.exact = make(map[string]struct{})
+		}
+		km.exact[p] = struct{}{}
+	}
+	return km, nil
+}
+
+func (km keyMatcher) empty() bool {
+	return len(km.exact) == 0 && len(km.globs) == 0
+}
+
+func (km keyMatcher) match(key string) bool {
+	if km.empty() {
+		return false
+	}
+	key = strings.ToLower(key)
+	if _, ok := km.exact[key]; ok {
+		return true
+	}
+	for _, g := range km.globs {
+		if globMatch(g, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// globMatch reports whether s matches pattern, where '*' matches any sequence of
+// characters and '?' matches any single character.
+func globMatch(pattern, s string) bool {
+	var p, i int
+	star, mark := -1, 0
+	for i < len(s) {
+		switch {
+		case p < len(pattern) && (pattern[p] == '?' || pattern[p] == s[i]):
+			p++
+			i++
+		case p < len(pattern) && pattern[p] == '*':
+			star, mark = p, i
+			p++
+		case star >= 0:
+			p = star + 1
+			mark++
+			i = mark
+		default:
+			return false
+		}
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+// luhnValid returns true if the digits in s pass the Luhn checksum.
+func luhnValid(s string) bool {
+	var sum, count int
+	double := false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		count++
+		double = !double
+	}
+	return count >= 13 && sum%10 == 0
+}
@@ -0,0 +1,139 @@
+package logr_test
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/logr/v2"
+	"github.com/mattermost/logr/v2/formatters"
+	"github.com/mattermost/logr/v2/targets"
+	"github.com/mattermost/logr/v2/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretMaskedByFormatters(t *testing.T) {
+	lgr, err := logr.New()
+	require.NoError(t, err)
+
+	fmts := map[string]logr.Formatter{
+		"json":  &formatters.JSON{},
+		"plain": &formatters.Plain{},
+		"gelf":  &formatters.Gelf{},
+	}
+	bufs := make(map[string]*test.Buffer)
+	for name, formatter := range fmts {
+		buf := &test.Buffer{}
+		bufs[name] = buf
+		err = lgr.AddTarget(targets.NewWriterTarget(buf), name, &logr.StdFilter{Lvl: logr.Info}, formatter, 100)
+		require.NoError(t, err)
+	}
+
+	lgr.NewLogger().Info("login", logr.Secret("password", "hunter2"), logr.String("user", "sarah"))
+	require.NoError(t, lgr.Shutdown())
+
+	for name, buf := range bufs {
+		assert.NotContains(t, buf.String(), "hunter2", name)
+		assert.Contains(t, buf.String(), logr.RedactedValue, name)
+		assert.Contains(t, buf.String(), "sarah", name)
+	}
+}
+
+func TestRedactor(t *testing.T) {
+	redactor, err := logr.NewRedactor(logr.RedactOptions{
+		MaskKeys:   []string{"*password*", "token"},
+		DropKeys:   []string{"internal.?"},
+		HashKeys:   []string{"user_email"},
+		HashSecret: []byte("s3cret"),
+		Scrubbers:  []logr.Scrubber{logr.ScrubEmails, logr.ScrubCreditCards, logr.ScrubTokens},
+	})
+	require.NoError(t, err)
+
+	lgr, err := logr.New()
+	require.NoError(t, err)
+
+	formatter := &formatters.Plain{DisableTimestamp: true, DisableLevel: true}
+	bufRedacted := &test.Buffer{}
+	err = lgr.AddTarget(targets.NewWriterTarget(bufRedacted), "redacted", &logr.StdFilter{Lvl: logr.Info},
+		logr.NewRedactingFormatter(formatter, redactor), 100)
+	require.NoError(t, err)
+
+	bufRaw := &test.Buffer{}
+	err = lgr.AddTarget(targets.NewWriterTarget(bufRaw), "raw", &logr.StdFilter{Lvl: logr.Info}, formatter, 100)
+	require.NoError(t, err)
+
+	logger := lgr.NewLogger().With(logr.String("DB_Password", "pw1"))
+	logger.Info("contact bob@example.com about card 4111 1111 1111 1111",
+		logr.String("token", "abc"),
+		logr.String("internal.x", "dropped"),
+		logr.String("user_email", "alice@example.com"),
+		logr.String("note", "order 1234567890123 by carol@example.org"),
+		logr.Err(errors.New("auth failed: Bearer eyJhbGciOi.eyJzdWIiOi.c2lnbmF0dXJl")),
+	)
+	logger.Info("contact bob@example.com about card 4111 1111 1111 1111",
+		logr.String("user_email", "alice@example.com"),
+	)
+	require.NoError(t, lgr.Shutdown())
+
+	lines := strings.Split(strings.TrimSpace(bufRedacted.String()), "\n")
+	require.Len(t, lines, 2)
+	out := lines[0]
+
+	for _, s := range []string{"pw1", "abc", "dropped", "internal.x", "alice@example.com", "bob@example.com",
+		"carol@example.org", "4111 1111 1111 1111", "eyJ"} {
+		assert.NotContains(t, out, s)
+	}
+	assert.Contains(t, out, "contact [REDACTED] about card [REDACTED]")
+	assert.Contains(t, out, `DB_Password="[REDACTED]"`)
+	assert.Contains(t, out, `token="[REDACTED]"`)
+	assert.Contains(t, out, "order 1234567890123", "numbers failing the Luhn check are kept")
+	assert.Contains(t, out, "auth failed: [REDACTED]")
+
+	// hashed values are consistent across records.
+	hash := regexp.MustCompile(`user_email="(hmac:[0-9a-f]{32})"`)
+	m1 := hash.FindStringSubmatch(lines[0])
+	m2 := hash.FindStringSubmatch(lines[1])
+	require.Len(t, m1, 2)
+	require.Len(t, m2, 2)
+	assert.Equal(t, m1[1], m2[1])
+
+	// targets without redaction see the original record.
+	assert.Contains(t, bufRaw.String(), "alice@example.com")
+	assert.Contains(t, bufRaw.String(), "pw1")
+	assert.Contains(t, bufRaw.String(), "internal.x=dropped")
+}
+
+func TestRedactorRevealSecrets(t *testing.T) {
+	redactor, err := logr.NewRedactor(logr.RedactOptions{RevealSecrets: true})
+	require.NoError(t, err)
+
+	lgr, err := logr.New()
+	require.NoError(t, err)
+
+	buf := &test.Buffer{}
+	formatter := logr.NewRedactingFormatter(&formatters.JSON{}, redactor)
+	err = lgr.AddTarget(targets.NewWriterTarget(buf), "audit", &logr.StdFilter{Lvl: logr.Info}, formatter, 100)
+	require.NoError(t, err)
+
+	lgr.NewLogger().Info("audit", logr.Secret("email", "sarah@example.com"), logr.Secret("attempts", 3))
+	require.NoError(t, lgr.Shutdown())
+
+	assert.Contains(t, buf.String(), `"email":"sarah@example.com"`)
+	assert.Contains(t, buf.String(), `"attempts":3`)
+}
+
+func TestNewRedactorInvalid(t *testing.T) {
+	_, err := logr.NewRedactor(logr.RedactOptions{HashKeys: []string{"email"}})
+	assert.Error(t, err, "hash keys require a secret")
+
+	_, err = logr.NewRedactor(logr.RedactOptions{Scrubbers: []logr.Scrubber{{Regexp: regexp.MustCompile("x"), Hash: true}}})
+	assert.Error(t, err, "hashed scrubbers require a secret")
+
+	_, err = logr.NewRedactor(logr.RedactOptions{MaskKeys: []string{""}})
+	assert.Error(t, err)
+
+	_, err = logr.NewRedactor(logr.RedactOptions{Scrubbers: []logr.Scrubber{{}}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,100 @@
+package slogadapter
+
+import (
+	"log/slog"
+
+	"github.com/mattermost/logr/v2"
+)
+
+// attrsToFields converts slog attributes to Logr fields. Keys are prefixed with
+// `prefix`. Groups are converted to `logr.Map` fields when groupsAsMaps is true,
+// otherwise they are flattened using dotted keys.
+func attrsToFields(prefix string, attrs []slog.Attr, groupsAsMaps bool) []logr.Field {
+	fields := make([]logr.Field, 0, len(attrs))
+	for _, attr := range attrs {
+		fields = appendAttr(fields, prefix, attr, groupsAsMaps)
+	}
+	return fields
+}
+
+func appendAttr(fields []logr.Field, prefix string, attr slog.Attr, groupsAsMaps bool) []logr.Field {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	if attr.Value.Kind() == slog.KindGroup {
+		groupAttrs := attr.Value.Group()
+		if len(groupAttrs) == 0 {
+			return fields
+		}
+		// groups with empty keys are inlined.
+		if attr.Key == "" {
+			for _, ga := range groupAttrs {
+				fields = appendAttr(fields, prefix, ga, groupsAsMaps)
+			}
+			return fields
+		}
+		if groupsAsMaps {
+			return append(fields, logr.Map(prefix+attr.Key, attrsToMap(groupAttrs)))
+		}
+		for _, ga := range groupAttrs {
+			fields = appendAttr(fields, prefix+attr.Key+".", ga, groupsAsMaps)
+		}
+		return fields
+	}
+	return append(fields, valueToField(prefix+attr.Key, attr.Value))
+}
+
+func valueToField(key string, val slog.Value) logr.Field {
+	switch val.Kind() {
+	case slog.KindString:
+		return logr.String(key, val.String())
+	case slog.KindInt64:
+		return logr.Int(key, val.Int64())
+	case slog.KindUint64:
+		return logr.Uint(key, val.Uint64())
+	case slog.KindFloat64:
+		return logr.Float(key, val.Float64())
+	case slog.KindBool:
+		return logr.Bool(key, val.Bool())
+	case slog.KindDuration:
+		return logr.Duration(key, val.Duration())
+	case slog.KindTime:
+		return logr.Time(key, val.Time())
+	default:
+		return logr.Any(key, val.Any())
+	}
+}
+
+// attrsToMap converts slog attributes to a map suitable for a `logr.Map` field.
+// Nested groups become nested maps.
+func attrsToMap(attrs []slog.Attr) map[string]any {
+	m := make(map[string]any, len(attrs))
+	for _, attr := range attrs {
+		attr.Value = attr.Value.Resolve()
+		if attr.Equal(slog.Attr{}) {
+			continue
+		}
+		if attr.Value.Kind() != slog.KindGroup {
+			v := attr.Value.Any()
+			if err, ok := v.(error); ok {
+				v = err.Error()
+			}
+			m[attr.Key] = v
+			continue
+		}
+		groupAttrs := attr.Value.Group()
+		if len(groupAttrs) == 0 {
+			continue
+		}
+		if attr.Key == "" {
+			for k, v := range attrsToMap(groupAttrs) {
+				m[k] = v
+			}
+			continue
+		}
+		m[attr.Key] = attrsToMap(groupAttrs)
+	}
+	return m
+}
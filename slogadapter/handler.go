@@ -0,0 +1,200 @@
+// Package slogadapter provides a `log/slog` handler backed by Logr.
+package slogadapter
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+
+	"github.com/mattermost/logr/v2"
+)
+
+// LevelMapper converts a slog.Level to a logr.Level.
+type LevelMapper func(level slog.Level) logr.Level
+
+// Options provides optional parameters for a Handler.
+type Options struct {
+	// LevelMapper converts slog levels to Logr levels. If nil then
+	// `DefaultLevelMapper` is used.
+	LevelMapper LevelMapper
+
+	// GroupsAsMaps, when true, outputs slog groups as `logr.Map` fields
+	// instead of flattening them to dotted keys (e.g. "group.key").
+	GroupsAsMaps bool
+}
+
+// Handler is a slog.Handler that outputs records to a Logr.Logger.
+type Handler struct {
+	logger       logr.Logger
+	levelMapper  LevelMapper
+	groupsAsMaps bool
+
+	// prefix for keys when groups are flattened to dotted keys.
+	prefix string
+	// open groups when groups are output as maps.
+	groups []group
+}
+
+// group is a group opened via `WithGroup` along with any attributes added to it
+// via `WithAttrs`. Only used when groups are output as maps.
+type group struct {
+	name  string
+	attrs []slog.Attr
+}
+
+// NewHandler creates a slog.Handler that outputs records to the specified Logger.
+// Any fields already added to the Logger are included with every record.
+// opts may be nil.
+func NewHandler(logger logr.Logger, opts *Options) *Handler {
+	h := &Handler{
+		logger:      logger,
+		levelMapper: DefaultLevelMapper,
+	}
+	if opts != nil {
+		if opts.LevelMapper != nil {
+			h.levelMapper = opts.LevelMapper
+		}
+		h.groupsAsMaps = opts.GroupsAsMaps
+	}
+	return h
+}
+
+// NewLogger creates a slog.Logger that outputs records to the specified Logger.
+// opts may be nil.
+func NewLogger(logger logr.Logger, opts *Options) *slog.Logger {
+	return slog.New(NewHandler(logger, opts))
+}
+
+// DefaultLevelMapper maps the four slog levels to the Logr levels of the same name.
+// Levels below `slog.LevelDebug` map to `logr.Trace`. Levels above `slog.LevelError`
+// map to `logr.Error`; they never map to `logr.Fatal` or `logr.Panic` since those
+// terminate the program.
+func DefaultLevelMapper(level slog.Level) logr.Level {
+	switch {
+	case level < slog.LevelDebug:
+		return logr.Trace
+	case level < slog.LevelInfo:
+		return logr.Debug
+	case level < slog.LevelWarn:
+		return logr.Info
+	case level < slog.LevelError:
+		return logr.Warn
+	default:
+		return logr.Error
+	}
+}
+
+// Enabled reports whether records at the mapped level are output by at least one
+// target or captured by the logger's flight recorder. Level overrides for the
+// logger's name are applied.
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.logger.LevelStatus(h.levelMapper(level)).Enabled
+}
+
+// Handle converts the slog.Record to a Logr log record and queues it for output.
+// The record's program counter is used to provide caller info and stack traces.
+func (h *Handler) Handle(_ context.Context, r slog.Record) error {
+	lvl := h.levelMapper(r.Level)
+
+	// LogWithCallers discards records the logger would not output or capture, and
+	// handles Fatal and Panic even when disabled.
+	status := h.logger.LevelStatus(lvl)
+	var pcs []uintptr
+	if status.Stacktrace && r.PC != 0 {
+		pcs = callers(r.PC)
+	}
+
+	h.logger.LogWithCallers(r.Time, lvl, r.Message, pcs, h.recordFields(r)...)
+	return nil
+}
+
+// WithAttrs returns a new Handler whose records include the specified attributes.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	h2 := *h
+
+	if h.groupsAsMaps && len(h.groups) > 0 {
+		// attributes belong to the innermost open group.
+		h2.groups = make([]group, len(h.groups))
+		copy(h2.groups, h.groups)
+		last := &h2.groups[len(h2.groups)-1]
+		last.attrs = append(append([]slog.Attr{}, last.attrs...), attrs...)
+		return &h2
+	}
+
+	h2.logger = h.logger.With(attrsToFields(h.prefix, attrs, h.groupsAsMaps)...)
+	return &h2
+}
+
+// WithGroup returns a new Handler with the named group opened. Keys of all
+// subsequent attributes are qualified by the group name.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+
+	if h.groupsAsMaps {
+		h2.groups = make([]group, len(h.groups), len(h.groups)+1)
+		copy(h2.groups, h.groups)
+		h2.groups = append(h2.groups, group{name: name})
+		return &h2
+	}
+
+	h2.prefix = h.prefix + name + "."
+	return &h2
+}
+
+// recordFields converts the record's attributes to fields, nesting them within
+// any open groups.
+func (h *Handler) recordFields(r slog.Record) []logr.Field {
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+
+	if !h.groupsAsMaps || len(h.groups) == 0 {
+		return attrsToFields(h.prefix, attrs, h.groupsAsMaps)
+	}
+
+	// build the nested maps starting with the innermost group.
+	var m map[string]any
+	for i := len(h.groups) - 1; i >= 0; i-- {
+		grp := h.groups[i]
+		groupAttrs := append(append([]slog.Attr{}, grp.attrs...), attrs...)
+		if m != nil {
+			groupAttrs = append(groupAttrs, slog.Any(h.groups[i+1].name, m))
+		}
+		m = attrsToMap(groupAttrs)
+		if len(m) == 0 {
+			// empty groups are ignored.
+			m = nil
+		}
+		attrs = nil
+	}
+
+	if m == nil {
+		return nil
+	}
+	return []logr.Field{logr.Map(h.groups[0].name, m)}
+}
+
+// callers returns the stack starting at pc, or just pc if the current stack
+// does not contain it.
+func callers(pc uintptr) []uintptr {
+	pcs := make([]uintptr, logr.DefaultMaxStackFrames+10)
+	n := runtime.Callers(3, pcs)
+	for i := 0; i < n; i++ {
+		if pcs[i] == pc {
+			end := n
+			if end-i > logr.DefaultMaxStackFrames {
+				end = i + logr.DefaultMaxStackFrames
+			}
+			return pcs[i:end]
+		}
+	}
+	return []uintptr{pc}
+}
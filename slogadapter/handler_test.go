@@ -0,0 +1,175 @@
+package slogadapter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/logr/v2"
+	"github.com/mattermost/logr/v2/formatters"
+	"github.com/mattermost/logr/v2/targets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLogr(t *testing.T, buf *bytes.Buffer, filter logr.Filter) *logr.Logr {
+	lgr, err := logr.New()
+	require.NoError(t, err)
+
+	formatter := &formatters.JSON{DisableTimestamp: true, EnableCaller: true}
+	err = lgr.AddTarget(targets.NewWriterTarget(buf), "slog", filter, formatter, 1000)
+	require.NoError(t, err)
+	return lgr
+}
+
+func decodeLines(t *testing.T, s string) []map[string]any {
+	var recs []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(s), "\n") {
+		rec := make(map[string]any)
+		require.NoError(t, json.Unmarshal([]byte(line), &rec), line)
+		recs = append(recs, rec)
+	}
+	return recs
+}
+
+func TestHandler(t *testing.T) {
+	buf := &bytes.Buffer{}
+	lgr := newTestLogr(t, buf, &logr.StdFilter{Lvl: logr.Info, Stacktrace: logr.Error})
+
+	slogger := NewLogger(lgr.NewLogger().With(logr.String("app", "test")), nil)
+
+	slogger.Debug("not output")
+	slogger.Info("hello", "count", 3, "ok", true)
+	slogger.With("user", "sam").WithGroup("req").Warn("grouped", "id", "abc", slog.Group("sub", "x", 1.5))
+	slogger.Error("failed", "err", errors.New("boom"))
+
+	require.NoError(t, lgr.Shutdown())
+
+	recs := decodeLines(t, buf.String())
+	require.Len(t, recs, 3)
+
+	assert.Equal(t, "info", recs[0]["level"])
+	assert.Equal(t, "hello", recs[0]["msg"])
+	assert.Equal(t, "test", recs[0]["app"])
+	assert.EqualValues(t, 3, recs[0]["count"])
+	assert.Equal(t, true, recs[0]["ok"])
+	assert.Contains(t, recs[0]["caller"], "slogadapter/handler_test.go:")
+
+	assert.Equal(t, "warn", recs[1]["level"])
+	assert.Equal(t, "sam", recs[1]["user"])
+	assert.Equal(t, "abc", recs[1]["req.id"])
+	assert.EqualValues(t, 1.5, recs[1]["req.sub.x"])
+
+	assert.Equal(t, "error", recs[2]["level"])
+	assert.Equal(t, "boom", recs[2]["err"])
+	require.NotEmpty(t, recs[2]["stacktrace"])
+	frames := recs[2]["stacktrace"].([]any)
+	first := frames[0].(map[string]any)
+	assert.Contains(t, first["File"], "handler_test.go")
+}
+
+func TestHandlerGroupsAsMaps(t *testing.T) {
+	buf := &bytes.Buffer{}
+	lgr := newTestLogr(t, buf, &logr.StdFilter{Lvl: logr.Debug})
+
+	slogger := NewLogger(lgr.NewLogger(), &Options{GroupsAsMaps: true})
+
+	slogger.WithGroup("req").With("id", "abc").WithGroup("db").Info("query", "rows", 7)
+	slogger.Info("inline", slog.Group("g", "a", "b"))
+	slogger.WithGroup("empty").Info("no attrs")
+
+	require.NoError(t, lgr.Shutdown())
+
+	recs := decodeLines(t, buf.String())
+	require.Len(t, recs, 3)
+
+	req := recs[0]["req"].(map[string]any)
+	assert.Equal(t, "abc", req["id"])
+	db := req["db"].(map[string]any)
+	assert.EqualValues(t, 7, db["rows"])
+
+	g := recs[1]["g"].(map[string]any)
+	assert.Equal(t, "b", g["a"])
+
+	assert.NotContains(t, recs[2], "empty")
+}
+
+func TestHandlerEnabled(t *testing.T) {
+	buf := &bytes.Buffer{}
+	custom := logr.Level{ID: 100, Name: "verbose"}
+	lgr := newTestLogr(t, buf, logr.NewCustomFilter(logr.Warn, custom))
+	defer lgr.Shutdown()
+
+	mapper := func(level slog.Level) logr.Level {
+		if level == slog.LevelDebug {
+			return custom
+		}
+		return DefaultLevelMapper(level)
+	}
+	h := NewHandler(lgr.NewLogger(), &Options{LevelMapper: mapper})
+
+	assert.True(t, h.Enabled(context.Background(), slog.LevelDebug))
+	assert.False(t, h.Enabled(context.Background(), slog.LevelInfo))
+	assert.True(t, h.Enabled(context.Background(), slog.LevelWarn))
+	assert.False(t, h.Enabled(context.Background(), slog.LevelError))
+}
+
+func TestDefaultLevelMapper(t *testing.T) {
+	tests := []struct {
+		level slog.Level
+		want  logr.Level
+	}{
+		{slog.LevelDebug - 4, logr.Trace},
+		{slog.LevelDebug, logr.Debug},
+		{slog.LevelInfo, logr.Info},
+		{slog.LevelInfo + 2, logr.Info},
+		{slog.LevelWarn, logr.Warn},
+		{slog.LevelError, logr.Error},
+		{slog.LevelError + 8, logr.Error},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, DefaultLevelMapper(tt.level), tt.level.String())
+	}
+}
+
+func TestHandlerLevelOverride(t *testing.T) {
+	buf := &bytes.Buffer{}
+	lgr := newTestLogr(t, buf, &logr.StdFilter{Lvl: logr.Info})
+	require.NoError(t, lgr.SetTargetLevelOverrides("slog", true))
+	require.NoError(t, lgr.SetLevelOverride("store", logr.Debug))
+
+	slogger := NewLogger(lgr.NewLogger().Named("store"), nil)
+	assert.True(t, slogger.Enabled(context.Background(), slog.LevelDebug))
+	slogger.Debug("query")
+	NewLogger(lgr.NewLogger().Named("api"), nil).Debug("not output")
+
+	require.NoError(t, lgr.Shutdown())
+
+	recs := decodeLines(t, buf.String())
+	require.Len(t, recs, 1)
+	assert.Equal(t, "debug", recs[0]["level"])
+	assert.Equal(t, "query", recs[0]["msg"])
+}
+
+func TestHandlerFlightRecorder(t *testing.T) {
+	buf := &bytes.Buffer{}
+	filter := logr.NewFlightRecorderFilter(&logr.StdFilter{Lvl: logr.Info}, logr.Debug, logr.Error)
+	lgr := newTestLogr(t, buf, filter)
+
+	slogger := NewLogger(lgr.NewLogger().WithRecorder(10), nil)
+	assert.True(t, slogger.Enabled(context.Background(), slog.LevelDebug))
+	slogger.Debug("detail")
+	slogger.Error("failed")
+
+	require.NoError(t, lgr.Shutdown())
+
+	recs := decodeLines(t, buf.String())
+	require.Len(t, recs, 2)
+	assert.Equal(t, "detail", recs[0]["msg"])
+	assert.Equal(t, true, recs[0]["backfill"])
+	assert.Equal(t, "failed", recs[1]["msg"])
+}
@@ -0,0 +1,177 @@
+package logr
+
+import (
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// targetStats are counters maintained for each target whether or not a
+// MetricsCollector is configured. See `Logr.TargetStatuses`.
+type targetStats struct {
+	logged     atomic.Uint64
+	errors     atomic.Uint64
+	dropped    atomic.Uint64
+	blocked    atomic.Uint64
+	suppressed atomic.Uint64
+	failing    atomic.Bool // true if the most recent write failed
+	lastErr    atomic.Pointer[targetError]
+}
+
+type targetError struct {
+	msg  string
+	time time.Time
+}
+
+// setLastError records the most recent formatting or write error for this target.
+func (h *TargetHost) setLastError(err error) {
+	h.stats.failing.Store(true)
+	h.stats.lastErr.Store(&targetError{msg: err.Error(), time: time.Now()})
+}
+
+// TargetStatus provides a snapshot of a target's configuration and health.
+type TargetStatus struct {
+	Name          string    `json:"name"`
+	Type          string    `json:"type"`
+	Formatter     string    `json:"formatter"`
+	Levels        []Level   `json:"levels"`
+	QueueSize     int       `json:"queue_size"`
+	QueueCapacity int       `json:"queue_capacity"`
+	Paused        bool      `json:"paused"`
+	Healthy       bool      `json:"healthy"`
+	Logged        uint64    `json:"logged"`
+	Errors        uint64    `json:"errors"`
+	Dropped       uint64    `json:"dropped"`
+	Blocked       uint64    `json:"blocked"`
+	Suppressed    uint64    `json:"suppressed"`
+	LastError     string    `json:"last_error,omitempty"`
+	LastErrorTime time.Time `json:"last_error_time,omitempty"`
+}
+
+// TargetStatuses returns a snapshot of the status of all targets added to this lgr.
+// A target is healthy unless its most recent write failed.
+func (lgr *Logr) TargetStatuses() []TargetStatus {
+	statuses := make([]TargetStatus, 0)
+
+	lgr.tmux.RLock()
+	defer lgr.tmux.RUnlock()
+
+	for _, host := range lgr.targetHosts {
+		statuses = append(statuses, host.status())
+	}
+	return statuses
+}
+
+func (h *TargetHost) status() TargetStatus {
+	status := TargetStatus{
+		Name:          h.String(),
+		Type:          fmt.Sprintf("%T", h.target),
+		Formatter:     fmt.Sprintf("%T", h.formatter),
+		Levels:        FilterLevels(h.filter.Load().Filter),
+		QueueSize:     len(h.in),
+		QueueCapacity: cap(h.in),
+		Paused:        h.paused.Load(),
+		Healthy:       !h.stats.failing.Load(),
+		Logged:        h.stats.logged.Load(),
+		Errors:        h.stats.errors.Load(),
+		Dropped:       h.stats.dropped.Load(),
+		Blocked:       h.stats.blocked.Load(),
+		Suppressed:    h.stats.suppressed.Load(),
+	}
+	if lastErr := h.stats.lastErr.Load(); lastErr != nil {
+		status.LastError = lastErr.msg
+		status.LastErrorTime = lastErr.time
+	}
+	return status
+}
+
+// FilterLevels returns the levels enabled by a filter, ordered by ID. The standard
+// levels are checked for any filter; custom levels are only known for a `CustomFilter`,
+// including one wrapped by a `MatchFilter`, `SampledFilter` or `FlightRecorderFilter`.
+func FilterLevels(filter Filter) []Level {
+	levels := make([]Level, 0)
+	seen := make(map[LevelID]bool)
+
+	for _, lvl := range []Level{Panic, Fatal, Error, Warn, Info, Debug, Trace} {
+		if level, enabled := filter.GetEnabledLevel(lvl); enabled {
+			levels = append(levels, level)
+			seen[level.ID] = true
+		}
+	}
+
+	var custom *CustomFilter
+	for custom == nil && filter != nil {
+		switch f := filter.(type) {
+		case *CustomFilter:
+			custom = f
+		case *MatchFilter:
+			filter = f.Filter
+		case *SampledFilter:
+			filter = f.Filter
+		case *FlightRecorderFilter:
+			filter = f.Filter
+		default:
+			filter = nil
+		}
+	}
+	if custom != nil {
+		for _, level := range custom.Levels() {
+			if !seen[level.ID] {
+				levels = append(levels, level)
+			}
+		}
+	}
+
+	sort.Slice(levels, func(i, j int) bool { return levels[i].ID < levels[j].ID })
+	return levels
+}
+
+// withFilterLevels returns a copy of filter with the Filter wrapped by any `MatchFilter`,
+// `SampledFilter` or `FlightRecorderFilter` replaced by a `CustomFilter` enabling levels.
+// Samplers are shared with the copy so sampling state is kept.
+func withFilterLevels(filter Filter, levels []Level) Filter {
+	switch f := filter.(type) {
+	case *MatchFilter:
+		return NewMatchFilter(withFilterLevels(f.Filter, levels), f.Matcher)
+	case *SampledFilter:
+		return NewSampledFilter(withFilterLevels(f.Filter, levels), f.Samplers...)
+	case *FlightRecorderFilter:
+		return NewFlightRecorderFilter(withFilterLevels(f.Filter, levels), f.Capture, f.Trigger)
+	default:
+		return NewCustomFilter(levels...)
+	}
+}
+
+// PauseTarget stops the named target(s) from outputting log records until
+// `ResumeTarget` is called. Records logged while paused are discarded for the target;
+// records already queued are still written. Returns an error if no target with the
+// name exists.
+func (lgr *Logr) PauseTarget(name string) error {
+	return lgr.setTargetPaused(name, true)
+}
+
+// ResumeTarget resumes output for the named target(s) paused by `PauseTarget`.
+// Returns an error if no target with the name exists.
+func (lgr *Logr) ResumeTarget(name string) error {
+	return lgr.setTargetPaused(name, false)
+}
+
+func (lgr *Logr) setTargetPaused(name string, paused bool) error {
+	lgr.tmux.RLock()
+	defer lgr.tmux.RUnlock()
+
+	var found bool
+	for _, host := range lgr.targetHosts {
+		if host.String() == name {
+			host.paused.Store(paused)
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("target %s not found", name)
+	}
+
+	lgr.ResetLevelCache()
+	return nil
+}
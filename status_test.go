@@ -0,0 +1,89 @@
+package logr_test
+
+import (
+	"testing"
+
+	"github.com/mattermost/logr/v2"
+	"github.com/mattermost/logr/v2/formatters"
+	"github.com/mattermost/logr/v2/targets"
+	"github.com/mattermost/logr/v2/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTargetStatuses(t *testing.T) {
+	lgr, err := logr.New(logr.OnLoggerError(func(error) {}))
+	require.NoError(t, err)
+	defer lgr.Shutdown()
+
+	audit := logr.Level{ID: 100, Name: "audit"}
+	buf := &test.Buffer{}
+	err = lgr.AddTarget(targets.NewWriterTarget(buf), "good", &logr.StdFilter{Lvl: logr.Warn}, &formatters.Plain{}, 50)
+	require.NoError(t, err)
+	filter := logr.NewMatchFilter(logr.NewCustomFilter(audit, logr.Error), logr.FieldExists("user"))
+	err = lgr.AddTarget(test.NewFailingTarget(), "bad", filter, &formatters.JSON{}, 20)
+	require.NoError(t, err)
+
+	logger := lgr.NewLogger()
+	logger.Error("failed", logr.String("user", "sarah"))
+	logger.Warn("warning")
+	require.NoError(t, lgr.Flush())
+
+	statuses := lgr.TargetStatuses()
+	require.Len(t, statuses, 2)
+
+	good := statuses[0]
+	assert.Equal(t, "good", good.Name)
+	assert.Equal(t, "*targets.Writer", good.Type)
+	assert.Equal(t, "*formatters.Plain", good.Formatter)
+	assert.Equal(t, []string{"panic", "fatal", "error", "warn"}, levelNames(good.Levels))
+	assert.Equal(t, 50, good.QueueCapacity)
+	assert.EqualValues(t, 2, good.Logged)
+	assert.True(t, good.Healthy)
+	assert.Empty(t, good.LastError)
+
+	bad := statuses[1]
+	assert.Equal(t, "*formatters.JSON", bad.Formatter)
+	assert.Equal(t, []string{"error", "audit"}, levelNames(bad.Levels))
+	assert.EqualValues(t, 0, bad.Logged)
+	assert.EqualValues(t, 1, bad.Errors)
+	assert.False(t, bad.Healthy)
+	assert.Equal(t, "FailingTarget always fails", bad.LastError)
+	assert.False(t, bad.LastErrorTime.IsZero())
+}
+
+func TestPauseTarget(t *testing.T) {
+	lgr, err := logr.New()
+	require.NoError(t, err)
+	defer lgr.Shutdown()
+
+	buf := &test.Buffer{}
+	err = lgr.AddTarget(targets.NewWriterTarget(buf), "paused", &logr.StdFilter{Lvl: logr.Info}, &formatters.Plain{}, 50)
+	require.NoError(t, err)
+
+	logger := lgr.NewLogger()
+	require.NoError(t, lgr.PauseTarget("paused"))
+	assert.False(t, logger.IsLevelEnabled(logr.Info))
+	assert.True(t, lgr.TargetStatuses()[0].Paused)
+
+	logger.Info("while paused")
+	require.NoError(t, lgr.ResumeTarget("paused"))
+	assert.True(t, logger.IsLevelEnabled(logr.Info))
+	logger.Info("after resume")
+	require.NoError(t, lgr.Flush())
+
+	assert.NotContains(t, buf.String(), "while paused")
+	assert.Contains(t, buf.String(), "after resume")
+	assert.False(t, lgr.TargetStatuses()[0].Paused)
+
+	assert.Error(t, lgr.PauseTarget("missing"))
+	assert.Error(t, lgr.ResumeTarget("missing"))
+}
+
+func levelNames(levels []logr.Level) []string {
+	names := make([]string, 0, len(levels))
+	for _, level := range levels {
+		names = append(names, level.Name)
+	}
+	return names
+}
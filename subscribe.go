@@ -0,0 +1,139 @@
+package logr
+
+import (
+	"sync"
+)
+
+// subscriber receives log records from `fanout` via a buffered channel.
+type subscriber struct {
+	filter       Filter
+	recordFilter RecordFilter
+	ch           chan *LogRec
+	closed       bool // guarded by Logr.smux
+}
+
+// Subscribe attaches a temporary listener to the live stream of log records, without
+// adding a target. Records at levels enabled by filter are sent to the returned channel
+// after being prepared, so fields are available. The caller and stack frames are only
+// available when captured for the level, either because a target needs them or because
+// the level enabled by filter has `Level.Stacktrace` set. If filter implements
+// `RecordFilter` it is applied to each record.
+//
+// Subscribers never block logging: if the channel buffer of bufferSize records is full,
+// the subscriber is dropped and the channel closed. The channel is also closed when
+// cancel is called or the Logr is shut down. cancel can be called more than once.
+func (lgr *Logr) Subscribe(filter Filter, bufferSize int) (<-chan *LogRec, func()) {
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+	sub := &subscriber{
+		filter: filter,
+		ch:     make(chan *LogRec, bufferSize),
+	}
+	if rf, ok := filter.(RecordFilter); ok {
+		sub.recordFilter = rf
+	}
+
+	lgr.smux.Lock()
+	if lgr.IsShutdown() {
+		lgr.smux.Unlock()
+		close(sub.ch)
+		return sub.ch, func() {}
+	}
+	lgr.subscribers = append(lgr.subscribers, sub)
+	lgr.smux.Unlock()
+
+	lgr.ResetLevelCache()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() { lgr.unsubscribe(sub) })
+	}
+	return sub.ch, cancel
+}
+
+// unsubscribe removes a subscriber and closes its channel.
+func (lgr *Logr) unsubscribe(sub *subscriber) {
+	lgr.smux.Lock()
+	if sub.closed {
+		lgr.smux.Unlock()
+		return
+	}
+	sub.closed = true
+	close(sub.ch)
+
+	subs := make([]*subscriber, 0, len(lgr.subscribers))
+	for _, s := range lgr.subscribers {
+		if s != sub {
+			subs = append(subs, s)
+		}
+	}
+	lgr.subscribers = subs
+	lgr.smux.Unlock()
+
+	lgr.ResetLevelCache()
+}
+
+// closeSubscribers removes all subscribers and closes their channels.
+func (lgr *Logr) closeSubscribers() {
+	lgr.smux.Lock()
+	defer lgr.smux.Unlock()
+
+	for _, sub := range lgr.subscribers {
+		sub.closed = true
+		close(sub.ch)
+	}
+	lgr.subscribers = nil
+}
+
+// hasSubscribers returns true if at least one subscriber exists.
+func (lgr *Logr) hasSubscribers() bool {
+	lgr.smux.RLock()
+	defer lgr.smux.RUnlock()
+	return len(lgr.subscribers) > 0
+}
+
+// subscribersLevelStatus updates status for levels enabled by any subscriber.
+func (lgr *Logr) subscribersLevelStatus(lvl Level, status *LevelStatus) {
+	lgr.smux.RLock()
+	defer lgr.smux.RUnlock()
+
+	for _, sub := range lgr.subscribers {
+		if level, enabled := sub.filter.GetEnabledLevel(lvl); enabled {
+			status.Enabled = true
+			if level.Stacktrace {
+				status.Stacktrace = true
+			}
+		}
+	}
+}
+
+// publish sends a log record to all subscribers whose filter enables it, dropping any
+// subscriber whose channel is full.
+func (lgr *Logr) publish(rec *LogRec) {
+	if rec.backfill != nil {
+		return // subscribers enabling the level received the record when logged.
+	}
+
+	var slow []*subscriber
+
+	lgr.smux.RLock()
+	for _, sub := range lgr.subscribers {
+		if _, enabled := sub.filter.GetEnabledLevel(rec.Level()); !enabled {
+			continue
+		}
+		if sub.recordFilter != nil && !sub.recordFilter.IsRecordEnabled(rec) {
+			continue
+		}
+		select {
+		case sub.ch <- rec:
+		default:
+			slow = append(slow, sub)
+		}
+	}
+	lgr.smux.RUnlock()
+
+	for _, sub := range slow {
+		lgr.unsubscribe(sub)
+	}
+}
@@ -0,0 +1,134 @@
+package logr_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr/v2"
+	"github.com/mattermost/logr/v2/formatters"
+	"github.com/mattermost/logr/v2/targets"
+	"github.com/mattermost/logr/v2/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscribe(t *testing.T) {
+	lgr, err := logr.New()
+	require.NoError(t, err)
+	defer lgr.Shutdown()
+
+	buf := &test.Buffer{}
+	err = lgr.AddTarget(targets.NewWriterTarget(buf), "info", &logr.StdFilter{Lvl: logr.Info}, &formatters.Plain{}, 100)
+	require.NoError(t, err)
+
+	logger := lgr.NewLogger()
+	assert.False(t, logger.IsLevelEnabled(logr.Debug))
+
+	// debug records are created while subscribed, but not output to the target.
+	filter := logr.NewMatchFilter(&logr.StdFilter{Lvl: logr.Debug}, logr.FieldEquals("user", "sarah"))
+	ch, cancel := lgr.Subscribe(filter, 10)
+	assert.True(t, logger.IsLevelEnabled(logr.Debug))
+
+	logger.Debug("debug sarah", logr.String("user", "sarah"))
+	logger.Debug("debug wiggin", logr.String("user", "wiggin"))
+	logger.Trace("trace sarah", logr.String("user", "sarah"))
+	logger.Info("info sarah", logr.String("user", "sarah"))
+	require.NoError(t, lgr.Flush())
+
+	assert.Equal(t, "debug sarah", receive(t, ch).Msg())
+	rec := receive(t, ch)
+	assert.Equal(t, "info sarah", rec.Msg())
+	assert.Equal(t, "sarah", rec.Fields()[0].String)
+	assert.Empty(t, ch)
+
+	cancel()
+	cancel()
+	_, ok := <-ch
+	assert.False(t, ok)
+	assert.False(t, logger.IsLevelEnabled(logr.Debug))
+
+	assert.NotContains(t, buf.String(), "debug")
+	assert.Contains(t, buf.String(), "info sarah")
+}
+
+func TestSubscribeStacktrace(t *testing.T) {
+	lgr, err := logr.New()
+	require.NoError(t, err)
+	defer lgr.Shutdown()
+
+	logger := lgr.NewLogger()
+
+	// frames are only captured for levels the filter requests stack traces for.
+	ch, cancel := lgr.Subscribe(&logr.StdFilter{Lvl: logr.Debug, Stacktrace: logr.Error}, 10)
+	defer cancel()
+
+	logger.Info("no frames")
+	logger.Error("frames")
+	require.NoError(t, lgr.Flush())
+
+	rec := receive(t, ch)
+	assert.Empty(t, rec.StackFrames())
+	assert.Empty(t, rec.Caller())
+
+	rec = receive(t, ch)
+	assert.NotEmpty(t, rec.StackFrames())
+	assert.Contains(t, rec.Caller(), "subscribe_test.go")
+}
+
+func TestSubscribeSlow(t *testing.T) {
+	lgr, err := logr.New()
+	require.NoError(t, err)
+	defer lgr.Shutdown()
+
+	slow, cancelSlow := lgr.Subscribe(&logr.StdFilter{Lvl: logr.Info}, 2)
+	defer cancelSlow()
+	fast, cancelFast := lgr.Subscribe(&logr.StdFilter{Lvl: logr.Info}, 100)
+	defer cancelFast()
+
+	logger := lgr.NewLogger()
+	for i := 0; i < 5; i++ {
+		logger.Info("msg", logr.Int("i", i))
+	}
+	require.NoError(t, lgr.Flush())
+
+	// the slow subscriber is dropped after its buffer filled.
+	var got int
+	for range slow {
+		got++
+	}
+	assert.Equal(t, 2, got)
+	assert.Len(t, fast, 5)
+}
+
+func TestSubscribeShutdown(t *testing.T) {
+	lgr, err := logr.New()
+	require.NoError(t, err)
+
+	ch, cancel := lgr.Subscribe(&logr.StdFilter{Lvl: logr.Info}, 10)
+	defer cancel()
+
+	lgr.NewLogger().Info("last")
+	require.NoError(t, lgr.Shutdown())
+
+	assert.Equal(t, "last", receive(t, ch).Msg())
+	_, ok := <-ch
+	assert.False(t, ok)
+
+	// subscribing after shutdown returns a closed channel.
+	ch, cancel = lgr.Subscribe(&logr.StdFilter{Lvl: logr.Info}, 10)
+	defer cancel()
+	_, ok = <-ch
+	assert.False(t, ok)
+}
+
+func receive(t *testing.T, ch <-chan *logr.LogRec) *logr.LogRec {
+	t.Helper()
+	select {
+	case rec, ok := <-ch:
+		require.True(t, ok, "channel closed")
+		return rec
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out waiting for log record")
+	}
+	return nil
+}
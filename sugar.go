@@ -9,8 +9,15 @@ type Sugar struct {
 	logger Logger
 }
 
+// enabled returns true if a record at the level would be output by a target or
+// captured by the logger's flight recorder, or if the level terminates the program.
+func (s Sugar) enabled(lvl Level) bool {
+	status, _ := s.logger.levelStatus(lvl)
+	return status.Enabled || status.isRecording(s.logger.recorder) || isTerminalLevel(lvl)
+}
+
 func (s Sugar) sugarLog(lvl Level, msg string, args ...interface{}) {
-	if s.logger.IsLevelEnabled(lvl) {
+	if s.enabled(lvl) {
 		fields := make([]Field, 0, len(args))
 		for _, arg := range args {
 			fields = append(fields, Any("", arg))
@@ -49,12 +56,14 @@ func (s Sugar) Error(msg string, args ...interface{}) {
 	s.sugarLog(Error, msg, args...)
 }
 
-// Fatal is a convenience method equivalent to `Log(FatalLevel, msg, args...)`
+// Fatal is a convenience method equivalent to `Log(FatalLevel, msg, args...)`.
+// All targets are flushed and `OnExit` is called.
 func (s Sugar) Fatal(msg string, args ...interface{}) {
 	s.sugarLog(Fatal, msg, args...)
 }
 
-// Panic is a convenience method equivalent to `Log(PanicLevel, msg, args...)`
+// Panic is a convenience method equivalent to `Log(PanicLevel, msg, args...)`.
+// All targets are flushed and `OnPanic` is called.
 func (s Sugar) Panic(msg string, args ...interface{}) {
 	s.sugarLog(Panic, msg, args...)
 }
@@ -67,7 +76,7 @@ func (s Sugar) Panic(msg string, args ...interface{}) {
 // if so, generates a log record that is added to the main
 // queue (channel). Arguments are handled in the manner of fmt.Printf.
 func (s Sugar) Logf(lvl Level, format string, args ...interface{}) {
-	if s.logger.IsLevelEnabled(lvl) {
+	if s.enabled(lvl) {
 		var msg string
 		if format == "" {
 			msg = fmt.Sprint(args...)
@@ -108,12 +117,14 @@ func (s Sugar) Errorf(format string, args ...interface{}) {
 	s.Logf(Error, format, args...)
 }
 
-// Fatalf is a convenience method equivalent to `Logf(FatalLevel, args...)`
+// Fatalf is a convenience method equivalent to `Logf(FatalLevel, args...)`.
+// All targets are flushed and `OnExit` is called.
 func (s Sugar) Fatalf(format string, args ...interface{}) {
 	s.Logf(Fatal, format, args...)
 }
 
-// Panicf is a convenience method equivalent to `Logf(PanicLevel, args...)`
+// Panicf is a convenience method equivalent to `Logf(PanicLevel, args...)`.
+// All targets are flushed and `OnPanic` is called.
 func (s Sugar) Panicf(format string, args ...interface{}) {
 	s.Logf(Panic, format, args...)
 }
@@ -154,11 +165,13 @@ func (s Sugar) Errorw(msg string, keyValuePairs ...interface{}) {
 }
 
 // Fatalw outputs at fatal level with the specified key/value pairs converted to fields.
+// All targets are flushed and `OnExit` is called.
 func (s Sugar) Fatalw(msg string, keyValuePairs ...interface{}) {
 	s.logger.Log(Fatal, msg, s.argsToFields(keyValuePairs)...)
 }
 
 // Panicw outputs at panic level with the specified key/value pairs converted to fields.
+// All targets are flushed and `OnPanic` is called.
 func (s Sugar) Panicw(msg string, keyValuePairs ...interface{}) {
 	s.logger.Log(Panic, msg, s.argsToFields(keyValuePairs)...)
 }
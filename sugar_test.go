@@ -136,3 +136,30 @@ func makeSugar(buf *bytes.Buffer) (logr.Sugar, func() error, error) {
 	}
 	return sugar, shutdown, nil
 }
+
+func TestSugarFatalPanic(t *testing.T) {
+	buf := &bytes.Buffer{}
+	formatter := &formatters.Plain{DisableTimestamp: true, Delim: " | "}
+	filter := &logr.StdFilter{Lvl: logr.Debug}
+
+	var exitCode int
+	var panicVal interface{}
+	lgr, _ := logr.New(
+		logr.OnExit(func(code int) { exitCode = code }),
+		logr.OnPanic(func(err interface{}) { panicVal = err }),
+	)
+	err := lgr.AddTarget(targets.NewWriterTarget(buf), "sugarTest", filter, formatter, 3000)
+	require.NoError(t, err)
+	sugar := lgr.NewLogger().Sugar()
+
+	sugar.Fatalf("fatal %d", 42)
+	assert.Equal(t, 1, exitCode)
+	assert.Contains(t, buf.String(), "fatal 42")
+
+	sugar.Panicw("panic msg", "prop1", "foo")
+	assert.Equal(t, "panic msg", panicVal)
+	assert.Contains(t, buf.String(), "prop1=foo")
+
+	err = lgr.Shutdown()
+	require.NoError(t, err)
+}
@@ -0,0 +1,82 @@
+package tail
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/mattermost/logr/v2"
+)
+
+// stdLevels are the levels that can be specified by name in query parameters.
+var stdLevels = []logr.Level{logr.Panic, logr.Fatal, logr.Error, logr.Warn, logr.Info, logr.Debug, logr.Trace}
+
+// NewFilter creates a Filter from query parameters. See `Handler` for the parameters.
+func NewFilter(query url.Values) (logr.Filter, error) {
+	var filter logr.Filter
+
+	switch {
+	case query.Get("levels") != "":
+		var levels []logr.Level
+		for _, s := range strings.Split(query.Get("levels"), ",") {
+			level, err := parseLevel(strings.TrimSpace(s))
+			if err != nil {
+				return nil, err
+			}
+			levels = append(levels, level)
+		}
+		filter = logr.NewCustomFilter(levels...)
+	case query.Get("level") != "":
+		level, err := parseLevel(query.Get("level"))
+		if err != nil {
+			return nil, err
+		}
+		filter = &logr.StdFilter{Lvl: level}
+	default:
+		filter = &logr.StdFilter{Lvl: logr.Info}
+	}
+
+	var matchers []logr.RecordMatcher
+	for key, vals := range query {
+		if !strings.HasPrefix(key, "field.") {
+			continue
+		}
+		name := strings.TrimPrefix(key, "field.")
+		if name == "" {
+			return nil, fmt.Errorf("missing field name in '%s'", key)
+		}
+		for _, val := range vals {
+			matchers = append(matchers, logr.FieldEquals(name, val))
+		}
+	}
+	if contains := query.Get("contains"); contains != "" {
+		matchers = append(matchers, logr.MatcherFunc(func(rec *logr.LogRec) bool {
+			return strings.Contains(rec.Msg(), contains)
+		}))
+	}
+	if prefix := query.Get("logger"); prefix != "" {
+		matchers = append(matchers, logr.MatcherFunc(func(rec *logr.LogRec) bool {
+			return strings.HasPrefix(rec.Logger().Name(), prefix)
+		}))
+	}
+
+	if len(matchers) == 0 {
+		return filter, nil
+	}
+	return logr.NewMatchFilter(filter, logr.And(matchers...)), nil
+}
+
+// parseLevel returns the standard level with the name, or a level with the numeric ID.
+func parseLevel(s string) (logr.Level, error) {
+	for _, level := range stdLevels {
+		if strings.EqualFold(s, level.Name) {
+			return level, nil
+		}
+	}
+	id, err := strconv.ParseUint(s, 10, 32)
+	if err != nil || id > logr.MaxLevelID {
+		return logr.Level{}, fmt.Errorf("invalid level '%s'", s)
+	}
+	return logr.Level{ID: logr.LevelID(id), Name: s}, nil
+}
@@ -0,0 +1,225 @@
+// Package tail provides an `http.Handler` that streams live log records from a Logr,
+// similar to `tail -f`, as Server-Sent Events or newline delimited JSON.
+package tail
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mattermost/logr/v2"
+	"github.com/mattermost/logr/v2/formatters"
+)
+
+const (
+	// DefaultBufferSize is the default number of records buffered per stream before
+	// a slow client is dropped.
+	DefaultBufferSize = 1000
+
+	// DefaultMaxDuration is the default maximum duration of a stream.
+	DefaultMaxDuration = 10 * time.Minute
+
+	// DefaultKeepAlive is the default interval between keep-alive comments sent on
+	// idle Server-Sent Event streams.
+	DefaultKeepAlive = 15 * time.Second
+
+	FormatSSE    = "sse"
+	FormatNDJSON = "ndjson"
+
+	ContentTypeSSE    = "text/event-stream"
+	ContentTypeNDJSON = "application/x-ndjson"
+)
+
+// Options provides optional parameters for a Handler.
+type Options struct {
+	// BufferSize is the number of records buffered per stream. A client that falls
+	// further behind is disconnected so logging is never blocked. Defaults to
+	// DefaultBufferSize.
+	BufferSize int
+
+	// MaxDuration is the maximum duration of a stream; the `duration` query parameter
+	// can only shorten it. Defaults to DefaultMaxDuration.
+	MaxDuration time.Duration
+
+	// KeepAlive is the interval between keep-alive comments on idle Server-Sent Event
+	// streams. Defaults to DefaultKeepAlive.
+	KeepAlive time.Duration
+
+	// Formatter formats each record. Defaults to `formatters.JSON`. For NDJSON the
+	// formatter must output a single line per record.
+	Formatter logr.Formatter
+}
+
+// Handler is an `http.Handler` streaming live log records matching filters taken from
+// query parameters:
+//
+//	level=<name>            records at this level or more severe, e.g. "debug"; defaults to "info".
+//	levels=<name|id>,...    records at exactly these levels, including custom level IDs.
+//	field.<key>=<value>     records with a field equal to value; may be repeated.
+//	contains=<text>         records whose message contains text.
+//	logger=<prefix>         records from loggers whose name starts with prefix.
+//	format=sse|ndjson       output format; defaults to Server-Sent Events if the request
+//	                        accepts "text/event-stream", otherwise NDJSON.
+//	duration=<duration>     stream duration, e.g. "5m", up to `MaxDuration`.
+//
+// The handler does not authenticate requests; mount it behind the application's
+// authorization since records may contain sensitive data.
+type Handler struct {
+	lgr         *logr.Logr
+	bufferSize  int
+	maxDuration time.Duration
+	keepAlive   time.Duration
+	formatter   logr.Formatter
+}
+
+// NewHandler creates a Handler that streams records logged via lgr.
+func NewHandler(lgr *logr.Logr, opts Options) *Handler {
+	h := &Handler{
+		lgr:         lgr,
+		bufferSize:  opts.BufferSize,
+		maxDuration: opts.MaxDuration,
+		keepAlive:   opts.KeepAlive,
+		formatter:   opts.Formatter,
+	}
+	if h.bufferSize <= 0 {
+		h.bufferSize = DefaultBufferSize
+	}
+	if h.maxDuration <= 0 {
+		h.maxDuration = DefaultMaxDuration
+	}
+	if h.keepAlive <= 0 {
+		h.keepAlive = DefaultKeepAlive
+	}
+	if h.formatter == nil {
+		h.formatter = &formatters.JSON{}
+	}
+	return h
+}
+
+// ServeHTTP streams records until the client disconnects, the stream duration elapses,
+// the client falls behind, or the Logr is shut down.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	query := r.URL.Query()
+	filter, err := NewFilter(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	format := query.Get("format")
+	switch format {
+	case "":
+		format = FormatNDJSON
+		if strings.Contains(r.Header.Get("Accept"), ContentTypeSSE) {
+			format = FormatSSE
+		}
+	case FormatSSE, FormatNDJSON:
+	default:
+		http.Error(w, fmt.Sprintf("invalid format '%s'", format), http.StatusBadRequest)
+		return
+	}
+
+	duration := h.maxDuration
+	if s := query.Get("duration"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			http.Error(w, fmt.Sprintf("invalid duration '%s'", s), http.StatusBadRequest)
+			return
+		}
+		if d < duration {
+			duration = d
+		}
+	}
+
+	ch, cancel := h.lgr.Subscribe(filter, h.bufferSize)
+	defer cancel()
+
+	if format == FormatSSE {
+		w.Header().Set("Content-Type", ContentTypeSSE)
+	} else {
+		w.Header().Set("Content-Type", ContentTypeNDJSON)
+	}
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // disable proxy buffering, e.g. nginx.
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	keepAlive := time.NewTicker(h.keepAlive)
+	defer keepAlive.Stop()
+
+	buf := &bytes.Buffer{}
+	for {
+		select {
+		case rec, ok := <-ch:
+			if !ok {
+				// dropped for falling behind, or the Logr was shut down.
+				if format == FormatSSE {
+					fmt.Fprint(w, "event: end\ndata: stream closed\n\n")
+					flusher.Flush()
+				}
+				return
+			}
+			buf.Reset()
+			if err := h.write(buf, rec, format); err != nil {
+				h.lgr.ReportError(fmt.Errorf("tail cannot format record: %w", err))
+				continue
+			}
+			if _, err := w.Write(buf.Bytes()); err != nil {
+				return
+			}
+			// send any other queued records before flushing.
+			if len(ch) == 0 {
+				flusher.Flush()
+			}
+		case <-keepAlive.C:
+			if format == FormatSSE {
+				fmt.Fprint(w, ": keep-alive\n\n")
+				flusher.Flush()
+			}
+		case <-timer.C:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// write formats a record in the stream format.
+func (h *Handler) write(buf *bytes.Buffer, rec *logr.LogRec, format string) error {
+	// output a stack trace if one was captured when the record was logged.
+	level := rec.Level()
+	level.Stacktrace = len(rec.StackFrames()) > 0
+
+	if _, err := h.formatter.Format(rec, level, buf); err != nil {
+		return err
+	}
+	out := bytes.TrimRight(buf.Bytes(), "\r\n")
+
+	if format == FormatNDJSON {
+		buf.Truncate(len(out))
+		buf.WriteByte('\n')
+		return nil
+	}
+
+	// each line of a multiline event is prefixed with "data: ".
+	event := make([]byte, 0, len(out)+16)
+	for _, line := range bytes.Split(out, []byte("\n")) {
+		event = append(event, "data: "...)
+		event = append(event, bytes.TrimRight(line, "\r")...)
+		event = append(event, '\n')
+	}
+	buf.Reset()
+	buf.Write(event)
+	buf.WriteByte('\n')
+	return nil
+}
@@ -0,0 +1,141 @@
+package tail_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr/v2"
+	"github.com/mattermost/logr/v2/tail"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlerNDJSON(t *testing.T) {
+	lgr, err := logr.New()
+	require.NoError(t, err)
+	defer lgr.Shutdown()
+
+	server := httptest.NewServer(tail.NewHandler(lgr, tail.Options{}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "?level=debug&field.user=sarah&duration=5s")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, tail.ContentTypeNDJSON, resp.Header.Get("Content-Type"))
+
+	// the subscription exists once the response headers are received.
+	logger := lgr.NewLogger()
+	logger.Debug("login", logr.String("user", "sarah"))
+	logger.Debug("login", logr.String("user", "wiggin"))
+	logger.Trace("verbose", logr.String("user", "sarah"))
+	logger.Error("failed", logr.String("user", "sarah"))
+
+	scanner := bufio.NewScanner(resp.Body)
+	var docs []map[string]interface{}
+	for len(docs) < 2 && scanner.Scan() {
+		var doc map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &doc), scanner.Text())
+		docs = append(docs, doc)
+	}
+	require.Len(t, docs, 2)
+	assert.Equal(t, "debug", docs[0]["level"])
+	assert.Equal(t, "login", docs[0]["msg"])
+	assert.Equal(t, "sarah", docs[0]["user"])
+	assert.Equal(t, "error", docs[1]["level"])
+}
+
+func TestHandlerSSE(t *testing.T) {
+	lgr, err := logr.New()
+	require.NoError(t, err)
+
+	server := httptest.NewServer(tail.NewHandler(lgr, tail.Options{}))
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"?levels=info,100&contains=order", nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept", tail.ContentTypeSSE)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, tail.ContentTypeSSE, resp.Header.Get("Content-Type"))
+
+	audit := logr.Level{ID: 100, Name: "audit"}
+	logger := lgr.NewLogger()
+	logger.Info("order placed")
+	logger.Warn("order delayed")
+	logger.Info("login")
+	logger.Log(audit, "order refunded")
+
+	// the stream ends when the Logr shuts down.
+	require.NoError(t, lgr.Shutdown())
+
+	body := readAll(t, resp)
+	events := strings.Split(strings.TrimSpace(body), "\n\n")
+	require.Len(t, events, 3, body)
+	assert.Contains(t, events[0], `"msg":"order placed"`)
+	assert.True(t, strings.HasPrefix(events[1], "data: {"))
+	assert.Contains(t, events[1], `"level":"audit"`)
+	assert.Equal(t, "event: end\ndata: stream closed", events[2])
+}
+
+func TestHandlerDuration(t *testing.T) {
+	lgr, err := logr.New()
+	require.NoError(t, err)
+	defer lgr.Shutdown()
+
+	server := httptest.NewServer(tail.NewHandler(lgr, tail.Options{MaxDuration: 50 * time.Millisecond}))
+	defer server.Close()
+
+	start := time.Now()
+	resp, err := http.Get(server.URL + "?duration=1h")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	readAll(t, resp)
+	assert.Less(t, int64(time.Since(start)), int64(5*time.Second))
+}
+
+func TestHandlerInvalid(t *testing.T) {
+	lgr, err := logr.New()
+	require.NoError(t, err)
+	defer lgr.Shutdown()
+
+	handler := tail.NewHandler(lgr, tail.Options{})
+	for _, query := range []string{"level=loud", "levels=info,x", "format=xml", "duration=-1s", "duration=soon", "field.=x"} {
+		t.Run(query, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?"+query, nil))
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		})
+	}
+}
+
+func TestNewFilter(t *testing.T) {
+	filter, err := tail.NewFilter(url.Values{})
+	require.NoError(t, err)
+	_, enabled := filter.GetEnabledLevel(logr.Info)
+	assert.True(t, enabled)
+	_, enabled = filter.GetEnabledLevel(logr.Debug)
+	assert.False(t, enabled)
+
+	filter, err = tail.NewFilter(url.Values{"level": {"WARN"}})
+	require.NoError(t, err)
+	_, enabled = filter.GetEnabledLevel(logr.Error)
+	assert.True(t, enabled)
+	_, enabled = filter.GetEnabledLevel(logr.Info)
+	assert.False(t, enabled)
+}
+
+func readAll(t *testing.T, resp *http.Response) string {
+	t.Helper()
+	b, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(b)
+}
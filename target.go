@@ -1,6 +1,7 @@
 package logr
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -23,12 +24,59 @@ type Target interface {
 	Shutdown() error
 }
 
+// BatchTarget is an optional interface implemented by targets that can output
+// multiple log records at once, such as network targets where each write is costly.
+// When implemented, the TargetHost collects formatted records until `maxRecords`
+// are queued or the oldest record has waited `maxLatency`, then calls `WriteBatch`.
+// Flush and shutdown always write any partial batch.
+type BatchTarget interface {
+	// BatchSize returns the maximum number of records per batch and the maximum amount
+	// of time a record can wait for the batch to fill. Batching is disabled if
+	// maxRecords is less than 2, in which case `Write` is called for each record.
+	BatchSize() (maxRecords int, maxLatency time.Duration)
+
+	// WriteBatch outputs a batch of log records to this target's destination. bufs
+	// contains the formatted bytes for each record in recs and is only valid until
+	// WriteBatch returns.
+	WriteBatch(recs []*LogRec, bufs [][]byte) error
+}
+
+// CallerTarget is an optional interface implemented by targets that output the caller
+// of each record themselves, independent of the formatter. When `IsCallerNeeded` returns
+// true, stack frames are captured for every record the target accepts.
+type CallerTarget interface {
+	IsCallerNeeded() bool
+}
+
+// Reopener is an optional interface implemented by targets writing to files that may be
+// rotated by external tools such as logrotate. `Logr.Reopen` calls `Reopen` to close the
+// file and open it again at its configured path.
+type Reopener interface {
+	Reopen() error
+}
+
 type targetMetrics struct {
 	queueSizeGauge Gauge
 	loggedCounter  Counter
 	errorCounter   Counter
 	droppedCounter Counter
 	blockedCounter Counter
+
+	suppressedCounter Counter // optional; see SamplingMetricsCollector.
+
+	extended *extendedMetrics // optional; see ExtendedMetricsCollector.
+}
+
+type extendedMetrics struct {
+	collector          ExtendedMetricsCollector
+	levelCounters      map[LevelID]Counter // only accessed by the target's read loop.
+	bytesCounter       Counter
+	formatErrorCounter Counter
+	writeErrorCounter  Counter
+	writeLatency       Histogram
+	enqueueWait        Histogram
+	lastWriteAgeGauge  Gauge
+	lastWrite          atomic.Int64 // unix nanos of the last successful write.
 }
 
 type targetHostOptions struct {
@@ -46,13 +94,18 @@ type TargetHost struct {
 	target Target
 	name   string
 
-	filter    Filter
+	filter    atomic.Pointer[hostFilter] // can be swapped via `Logr.SetTargetFilter`.
 	formatter Formatter
 
 	in            chan *LogRec
 	quit          chan struct{} // closed by Shutdown to exit read loop
 	done          chan struct{} // closed when read loop exited
 	targetMetrics *targetMetrics
+	stats         targetStats
+	batch         *batch
+	paused        atomic.Bool
+	overrides     atomic.Bool // outputs records enabled by a level override.
+	callerNeeded  bool        // target outputs the caller itself; see CallerTarget.
 
 	shutdown int32
 }
@@ -61,7 +114,6 @@ func newTargetHost(target Target, options targetHostOptions) (*TargetHost, error
 	host := &TargetHost{
 		target:    target,
 		name:      options.name,
-		filter:    options.filter,
 		formatter: options.formatter,
 		in:        make(chan *LogRec, options.maxQueueSize),
 		quit:      make(chan struct{}),
@@ -72,13 +124,22 @@ func newTargetHost(target Target, options targetHostOptions) (*TargetHost, error
 		host.name = fmt.Sprintf("%T", target)
 	}
 
-	if host.filter == nil {
-		host.filter = &StdFilter{Lvl: Fatal}
+	filter := options.filter
+	if filter == nil {
+		filter = &StdFilter{Lvl: Fatal}
 	}
+	host.setFilter(filter)
 	if host.formatter == nil {
 		host.formatter = &DefaultFormatter{}
 	}
 
+	if bt, ok := target.(BatchTarget); ok {
+		host.batch = newBatch(bt)
+	}
+	if ct, ok := target.(CallerTarget); ok {
+		host.callerNeeded = ct.IsCallerNeeded()
+	}
+
 	err := host.initMetrics(options.metrics)
 	if err != nil {
 		return nil, err
@@ -117,6 +178,16 @@ func (h *TargetHost) initMetrics(metrics *metrics) error {
 	if tmetrics.blockedCounter, err = metrics.collector.BlockedCounter(h.name); err != nil {
 		return err
 	}
+	if smc, ok := metrics.collector.(SamplingMetricsCollector); ok {
+		if tmetrics.suppressedCounter, err = smc.SuppressedCounter(h.name); err != nil {
+			return err
+		}
+	}
+	if emc, ok := metrics.collector.(ExtendedMetricsCollector); ok {
+		if tmetrics.extended, err = newExtendedMetrics(emc, h.name); err != nil {
+			return err
+		}
+	}
 	h.targetMetrics = tmetrics
 
 	updateFreqMillis := metrics.updateFreqMillis
@@ -127,16 +198,89 @@ func (h *TargetHost) initMetrics(metrics *metrics) error {
 		updateFreqMillis = 250 // don't peg the CPU
 	}
 
+	if twm, ok := h.target.(TargetWithMetrics); ok {
+		if err = twm.EnableMetrics(metrics.collector, updateFreqMillis); err != nil {
+			return err
+		}
+	}
+
 	go h.startMetricsUpdater(updateFreqMillis)
 	return nil
 }
 
+func newExtendedMetrics(collector ExtendedMetricsCollector, name string) (*extendedMetrics, error) {
+	var err error
+	em := &extendedMetrics{
+		collector:     collector,
+		levelCounters: make(map[LevelID]Counter),
+	}
+	if em.bytesCounter, err = collector.WrittenBytesCounter(name); err != nil {
+		return nil, err
+	}
+	if em.formatErrorCounter, err = collector.FormatErrorCounter(name); err != nil {
+		return nil, err
+	}
+	if em.writeErrorCounter, err = collector.WriteErrorCounter(name); err != nil {
+		return nil, err
+	}
+	if em.writeLatency, err = collector.WriteLatencyHistogram(name); err != nil {
+		return nil, err
+	}
+	if em.enqueueWait, err = collector.EnqueueWaitHistogram(name); err != nil {
+		return nil, err
+	}
+	if em.lastWriteAgeGauge, err = collector.LastWriteAgeGauge(name); err != nil {
+		return nil, err
+	}
+	em.lastWrite.Store(time.Now().UnixNano())
+	return em, nil
+}
+
+// hostFilter holds a target's filter along with any optional interfaces it implements,
+// resolved once so they can be swapped atomically as a unit.
+type hostFilter struct {
+	Filter
+	recordFilter     RecordFilter
+	sampler          RecordSampler
+	recorder         FlightRecorder
+	stacktraceNeeded bool // filter requires stack traces, e.g. to match by caller.
+}
+
+// setFilter replaces this target's filter. The Logr level cache must be reset afterwards.
+func (h *TargetHost) setFilter(filter Filter) {
+	hf := &hostFilter{Filter: filter}
+	if rf, ok := filter.(RecordFilter); ok {
+		hf.recordFilter = rf
+	}
+	if sampler, ok := filter.(RecordSampler); ok {
+		hf.sampler = sampler
+	}
+	if fr, ok := filter.(FlightRecorder); ok {
+		hf.recorder = fr
+	}
+	if sn, ok := filter.(interface{ IsStacktraceNeeded() bool }); ok {
+		hf.stacktraceNeeded = sn.IsStacktraceNeeded()
+	}
+	h.filter.Store(hf)
+}
+
 // IsLevelEnabled returns true if this target should emit logs for the specified level.
+// No levels are enabled while the target is paused.
 func (h *TargetHost) IsLevelEnabled(lvl Level) (enabled bool, level Level) {
-	level, enabled = h.filter.GetEnabledLevel(lvl)
+	if h.paused.Load() {
+		return false, level
+	}
+	level, enabled = h.filter.Load().GetEnabledLevel(lvl)
 	return enabled, level
 }
 
+// acceptsOverride returns true if this target outputs records enabled by a level
+// override even when its filter excludes the record's level. Paused targets accept
+// nothing. See `Logr.SetTargetLevelOverrides`.
+func (h *TargetHost) acceptsOverride() bool {
+	return h.overrides.Load() && !h.paused.Load()
+}
+
 // Shutdown stops processing log records after making best
 // effort to flush queue.
 func (h *TargetHost) Shutdown(ctx context.Context) error {
@@ -162,6 +306,17 @@ func (h *TargetHost) Log(rec *LogRec) {
 		return
 	}
 
+	if rec.flush == nil {
+		filter := h.filter.Load()
+		if filter.recordFilter != nil && !filter.recordFilter.IsRecordEnabled(rec) {
+			return
+		}
+		if filter.sampler != nil && !filter.sampler.Sample(rec) {
+			h.incSuppressedCounter()
+			return
+		}
+	}
+
 	lgr := rec.Logger().Logr()
 	select {
 	case h.in <- rec:
@@ -173,11 +328,13 @@ func (h *TargetHost) Log(rec *LogRec) {
 		}
 		h.incBlockedCounter()
 
+		start := time.Now()
 		select {
 		case <-time.After(lgr.options.enqueueTimeout):
 			lgr.ReportError(fmt.Errorf("target enqueue timeout for log rec [%v]", rec))
 		case h.in <- rec: // block until success or timeout
 		}
+		h.observeEnqueueWait(start)
 	}
 }
 
@@ -188,29 +345,120 @@ func (h *TargetHost) setQueueSizeGauge(val float64) {
 }
 
 func (h *TargetHost) incLoggedCounter() {
+	h.stats.logged.Add(1)
+	h.stats.failing.Store(false)
 	if h.targetMetrics != nil {
 		h.targetMetrics.loggedCounter.Inc()
 	}
 }
 
+func (h *TargetHost) addLoggedCounter(val float64) {
+	h.stats.logged.Add(uint64(val))
+	h.stats.failing.Store(false)
+	if h.targetMetrics != nil {
+		h.targetMetrics.loggedCounter.Add(val)
+	}
+}
+
 func (h *TargetHost) incErrorCounter() {
+	h.stats.errors.Add(1)
 	if h.targetMetrics != nil {
 		h.targetMetrics.errorCounter.Inc()
 	}
 }
 
+func (h *TargetHost) addErrorCounter(val float64) {
+	h.stats.errors.Add(uint64(val))
+	if h.targetMetrics != nil {
+		h.targetMetrics.errorCounter.Add(val)
+	}
+}
+
 func (h *TargetHost) incDroppedCounter() {
+	h.stats.dropped.Add(1)
 	if h.targetMetrics != nil {
 		h.targetMetrics.droppedCounter.Inc()
 	}
 }
 
 func (h *TargetHost) incBlockedCounter() {
+	h.stats.blocked.Add(1)
 	if h.targetMetrics != nil {
 		h.targetMetrics.blockedCounter.Inc()
 	}
 }
 
+func (h *TargetHost) incFormatErrorCounter() {
+	if h.targetMetrics != nil && h.targetMetrics.extended != nil {
+		h.targetMetrics.extended.formatErrorCounter.Inc()
+	}
+}
+
+func (h *TargetHost) addWriteErrorCounter(val float64) {
+	if h.targetMetrics != nil && h.targetMetrics.extended != nil {
+		h.targetMetrics.extended.writeErrorCounter.Add(val)
+	}
+}
+
+// writeStart returns the time a write started, or the zero time if write latency is
+// not measured.
+func (h *TargetHost) writeStart() time.Time {
+	if h.targetMetrics != nil && h.targetMetrics.extended != nil {
+		return time.Now()
+	}
+	return time.Time{}
+}
+
+func (h *TargetHost) observeWriteLatency(start time.Time) {
+	if !start.IsZero() {
+		h.targetMetrics.extended.writeLatency.Observe(time.Since(start).Seconds())
+	}
+}
+
+func (h *TargetHost) observeEnqueueWait(start time.Time) {
+	if h.targetMetrics != nil && h.targetMetrics.extended != nil {
+		h.targetMetrics.extended.enqueueWait.Observe(time.Since(start).Seconds())
+	}
+}
+
+// addWritten counts a record of size bytes output successfully. Must only be called
+// by the target's read loop.
+func (h *TargetHost) addWritten(rec *LogRec, size int) {
+	if h.targetMetrics == nil || h.targetMetrics.extended == nil {
+		return
+	}
+	em := h.targetMetrics.extended
+	level := rec.Level()
+
+	counter, ok := em.levelCounters[level.ID]
+	if !ok {
+		var err error
+		if counter, err = em.collector.LevelLoggedCounter(h.name, level); err != nil {
+			rec.Logger().Logr().ReportError(fmt.Errorf("target %s cannot create counter for level %s: %w", h.name, level.Name, err))
+		}
+		em.levelCounters[level.ID] = counter // nil on error, so creation is not retried.
+	}
+	if counter != nil {
+		counter.Inc()
+	}
+	em.bytesCounter.Add(float64(size))
+	em.lastWrite.Store(time.Now().UnixNano())
+}
+
+func (h *TargetHost) setLastWriteAgeGauge() {
+	if h.targetMetrics != nil && h.targetMetrics.extended != nil {
+		em := h.targetMetrics.extended
+		em.lastWriteAgeGauge.Set(time.Since(time.Unix(0, em.lastWrite.Load())).Seconds())
+	}
+}
+
+func (h *TargetHost) incSuppressedCounter() {
+	h.stats.suppressed.Add(1)
+	if h.targetMetrics != nil && h.targetMetrics.suppressedCounter != nil {
+		h.targetMetrics.suppressedCounter.Inc()
+	}
+}
+
 // String returns a name for this target.
 func (h *TargetHost) String() string {
 	return h.name
@@ -235,37 +483,68 @@ func (h *TargetHost) start() {
 			if rec.flush != nil {
 				h.flush(rec.flush)
 			} else {
-				err := h.writeRec(rec)
-				if err != nil {
-					h.incErrorCounter()
-					rec.Logger().Logr().ReportError(err)
-				} else {
-					h.incLoggedCounter()
-				}
+				h.process(rec)
 			}
+		case <-h.batch.timeout():
+			h.writeBatch()
 		case <-h.quit:
+			h.writeBatch()
 			return
 		}
 	}
 }
 
-func (h *TargetHost) writeRec(rec *LogRec) error {
-	level, enabled := h.filter.GetEnabledLevel(rec.Level())
-	if !enabled {
-		// how did we get here?
-		return fmt.Errorf("level %s not enabled for target %s", rec.Level().Name, h.name)
+// process writes a log record to the target, or adds it to the current batch
+// if the target supports batching.
+func (h *TargetHost) process(rec *LogRec) {
+	if h.batch.enabled() {
+		h.addToBatch(rec)
+		return
 	}
 
+	err := h.writeRec(rec)
+	if err != nil {
+		h.incErrorCounter()
+		h.setLastError(err)
+		rec.Logger().Logr().ReportError(err)
+	} else {
+		h.incLoggedCounter()
+	}
+}
+
+func (h *TargetHost) writeRec(rec *LogRec) error {
 	buf := rec.logger.lgr.BorrowBuffer()
 	defer rec.logger.lgr.ReleaseBuffer(buf)
 
-	buf, err := h.formatter.Format(rec, level, buf)
+	buf, err := h.formatRec(rec, buf)
 	if err != nil {
+		h.incFormatErrorCounter()
 		return err
 	}
 
+	start := h.writeStart()
 	_, err = h.target.Write(buf.Bytes(), rec)
-	return err
+	h.observeWriteLatency(start)
+	if err != nil {
+		h.addWriteErrorCounter(1)
+		return err
+	}
+	h.addWritten(rec, buf.Len())
+	return nil
+}
+
+// formatRec formats a log record using this target's formatter.
+func (h *TargetHost) formatRec(rec *LogRec, buf *bytes.Buffer) (*bytes.Buffer, error) {
+	level, enabled := h.filter.Load().GetEnabledLevel(rec.Level())
+	if !enabled {
+		if !rec.levelOverride && rec.backfill == nil {
+			// how did we get here?
+			return nil, fmt.Errorf("level %s not enabled for target %s", rec.Level().Name, h.name)
+		}
+		level = rec.Level()
+		level.Stacktrace = false
+	}
+	return h.formatter.Format(rec, level, buf)
 }
 
 // startMetricsUpdater updates the metrics for any polled values every `updateFreqMillis` seconds until
@@ -277,6 +556,7 @@ func (h *TargetHost) startMetricsUpdater(updateFreqMillis int64) {
 			return
 		case <-time.After(time.Duration(updateFreqMillis) * time.Millisecond):
 			h.setQueueSizeGauge(float64(len(h.in)))
+			h.setLastWriteAgeGauge()
 		}
 	}
 }
@@ -285,18 +565,14 @@ func (h *TargetHost) startMetricsUpdater(updateFreqMillis int64) {
 func (h *TargetHost) flush(done chan<- struct{}) {
 	for {
 		var rec *LogRec
-		var err error
 		select {
 		case rec = <-h.in:
 			// ignore any redundant flush records.
 			if rec.flush == nil {
-				err = h.writeRec(rec)
-				if err != nil {
-					h.incErrorCounter()
-					rec.Logger().Logr().ReportError(err)
-				}
+				h.process(rec)
 			}
 		default:
+			h.writeBatch()
 			done <- struct{}{}
 			return
 		}
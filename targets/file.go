@@ -2,7 +2,13 @@ package targets
 
 import (
 	"errors"
+	"fmt"
 	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strconv"
 
 	"github.com/mattermost/logr/v2"
 	"gopkg.in/natefinch/lumberjack.v2"
@@ -14,15 +20,36 @@ type FileOptions struct {
 	// os.TempDir() if empty.
 	Filename string `json:"filename"`
 
+	// FilenamePattern enables time-based rotation, naming each file by expanding
+	// strftime-style conversion specifications (%Y, %y, %m, %d, %H, %M, %S, %j, %b, %a)
+	// with the start of its rotation period, e.g. "logs/app-%Y%m%d.log". Files for the
+	// same period are appended to, including across restarts. Filename is ignored when
+	// this is set.
+	FilenamePattern string `json:"filename_pattern"`
+
+	// Rotation is the rotation schedule used with FilenamePattern, either "daily"
+	// (default) or "hourly".
+	Rotation string `json:"rotation"`
+
+	// UTC uses UTC instead of local time for rotation periods and filenames.
+	UTC bool `json:"utc"`
+
+	// Symlink is the path of a symlink kept pointing at the current log file when using
+	// FilenamePattern, e.g. "logs/current".
+	Symlink string `json:"symlink"`
+
 	// MaxSize is the maximum size in megabytes of the log file before it gets
-	// rotated. It defaults to 100 megabytes.
+	// rotated. It defaults to 100 megabytes. When using FilenamePattern there is
+	// no default, and files exceeding MaxSize within a rotation period are
+	// numbered, e.g. "app-20240101.1.log".
 	MaxSize int `json:"max_size"`
 
 	// MaxAge is the maximum number of days to retain old log files based on the
 	// timestamp encoded in their filename.  Note that a day is defined as 24
 	// hours and may not exactly correspond to calendar days due to daylight
 	// savings, leap seconds, etc. The default is not to remove old log files
-	// based on age.
+	// based on age. When using FilenamePattern, the modification time of old
+	// log files is used instead.
 	MaxAge int `json:"max_age"`
 
 	// MaxBackups is the maximum number of old log files to retain.  The default
@@ -30,26 +57,213 @@ type FileOptions struct {
 	// deleted.)
 	MaxBackups int `json:"max_backups"`
 
+	// MaxTotalSize is the maximum total size in megabytes of old log files to retain
+	// when using FilenamePattern. The default is not to remove old log files based on
+	// total size.
+	MaxTotalSize int `json:"max_total_size"`
+
 	// Compress determines if the rotated log files should be compressed
 	// using gzip. The default is not to perform compression.
 	Compress bool `json:"compress"`
+
+	// Compression is the method used to compress rotated log files when using
+	// FilenamePattern, either "gzip" or one added via RegisterCompression, e.g. "zstd".
+	Compression string `json:"compression"`
+
+	// RotateHooks are called in order after each rotation when using FilenamePattern,
+	// after any compression.
+	RotateHooks []RotateHook `json:"-"`
+
+	// ExternalRotation disables rotation by this target, for files rotated by an external
+	// tool such as logrotate. Instead, Filename is reopened when ReopenSignal is received,
+	// when `Logr.Reopen` is called, or when the path no longer refers to the open file
+	// or the file was truncated (copytruncate).
+	ExternalRotation bool `json:"external_rotation"`
+
+	// ReopenSignal is the signal, "SIGHUP", "SIGUSR1" or "SIGUSR2", that causes the file to
+	// be reopened when using ExternalRotation. Not supported on Windows.
+	ReopenSignal string `json:"reopen_signal"`
+
+	// ReopenCheckMillis is how often to check whether the file was moved, removed or
+	// truncated when using ExternalRotation. Defaults to DefaultReopenCheckMillis; a
+	// negative value disables checking.
+	ReopenCheckMillis int `json:"reopen_check_millis"`
+
+	// FileMode is the permission bits, in octal, for new log files, e.g. "0640".
+	// Defaults to "0644", except for size based rotation where new files default
+	// to "0600" and rotated files keep the mode of the file they replace.
+	FileMode string `json:"file_mode"`
+
+	// Owner is the user name or ID to own new log files. Defaults to the process user.
+	// Not supported on Windows.
+	Owner string `json:"owner"`
+
+	// Group is the group name or ID to own new log files. Defaults to the process group.
+	// Not supported on Windows.
+	Group string `json:"group"`
 }
 
 func (fo FileOptions) CheckValid() error {
-	if fo.Filename == "" {
-		return errors.New("filename cannot be empty")
+	if _, err := fo.fileMode(); err != nil {
+		return err
+	}
+	if _, _, err := fo.ownership(); err != nil {
+		return err
+	}
+	if fo.Compression != "" && fo.FilenamePattern == "" {
+		return errors.New("compression requires filename_pattern")
+	}
+
+	if fo.ExternalRotation {
+		if fo.Filename == "" {
+			return errors.New("filename cannot be empty")
+		}
+		if fo.FilenamePattern != "" {
+			return errors.New("external_rotation cannot be used with filename_pattern")
+		}
+		if fo.ReopenSignal != "" {
+			if _, err := parseReopenSignal(fo.ReopenSignal); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if fo.ReopenSignal != "" {
+		return errors.New("reopen_signal requires external_rotation")
+	}
+
+	if fo.FilenamePattern == "" {
+		if fo.Filename == "" {
+			return errors.New("filename cannot be empty")
+		}
+		return nil
+	}
+
+	if err := checkPattern(fo.FilenamePattern, fo.Rotation); err != nil {
+		return err
+	}
+	if fo.Compression != "" {
+		if _, ok := getCompression(fo.Compression); !ok {
+			return fmt.Errorf("unknown compression '%s'", fo.Compression)
+		}
+	}
+	if fo.MaxSize < 0 || fo.MaxAge < 0 || fo.MaxBackups < 0 || fo.MaxTotalSize < 0 {
+		return errors.New("max_size, max_age, max_backups and max_total_size cannot be negative")
 	}
 	return nil
 }
 
-// File outputs log records to a file which can be log rotated based on size or age.
-// Uses `https://github.com/natefinch/lumberjack` for rotation.
+// fileMode returns the permission bits for new log files.
+func (fo FileOptions) fileMode() (os.FileMode, error) {
+	if fo.FileMode == "" {
+		return 0644, nil
+	}
+	mode, err := strconv.ParseUint(fo.FileMode, 8, 32)
+	if err != nil || mode > 0777 {
+		return 0, fmt.Errorf("invalid file_mode '%s'", fo.FileMode)
+	}
+	return os.FileMode(mode), nil
+}
+
+// ownership returns the user and group IDs for new log files, or -1 if not set.
+func (fo FileOptions) ownership() (uid int, gid int, err error) {
+	uid, gid = -1, -1
+	if fo.Owner == "" && fo.Group == "" {
+		return uid, gid, nil
+	}
+	if runtime.GOOS == "windows" {
+		return uid, gid, errors.New("owner and group are not supported on this platform")
+	}
+
+	if fo.Owner != "" {
+		id := fo.Owner
+		if _, err := strconv.Atoi(id); err != nil {
+			u, err := user.Lookup(fo.Owner)
+			if err != nil {
+				return uid, gid, fmt.Errorf("invalid owner '%s': %w", fo.Owner, err)
+			}
+			id = u.Uid
+		}
+		if uid, err = strconv.Atoi(id); err != nil {
+			return -1, gid, fmt.Errorf("invalid owner '%s'", fo.Owner)
+		}
+	}
+	if fo.Group != "" {
+		id := fo.Group
+		if _, err := strconv.Atoi(id); err != nil {
+			g, err := user.LookupGroup(fo.Group)
+			if err != nil {
+				return uid, gid, fmt.Errorf("invalid group '%s': %w", fo.Group, err)
+			}
+			id = g.Gid
+		}
+		if gid, err = strconv.Atoi(id); err != nil {
+			return uid, -1, fmt.Errorf("invalid group '%s'", fo.Group)
+		}
+	}
+	return uid, gid, nil
+}
+
+// openLogFile opens the file for appending, creating it and any directories as needed.
+// New files are given the mode and ownership configured in opts.
+func openLogFile(path string, opts FileOptions) (*os.File, error) {
+	mode, err := opts.fileMode()
+	if err != nil {
+		return nil, err
+	}
+	uid, gid, err := opts.ownership()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("cannot create directory for log file: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_APPEND|os.O_WRONLY, mode)
+	if errors.Is(err, os.ErrExist) {
+		f, err = os.OpenFile(path, os.O_APPEND|os.O_WRONLY, mode)
+		if err != nil {
+			return nil, fmt.Errorf("cannot open log file: %w", err)
+		}
+		return f, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot create log file: %w", err)
+	}
+
+	// the mode passed to OpenFile is subject to umask.
+	if err := f.Chmod(mode); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("cannot set log file mode: %w", err)
+	}
+	if uid != -1 || gid != -1 {
+		if err := f.Chown(uid, gid); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("cannot set log file owner: %w", err)
+		}
+	}
+	return f, nil
+}
+
+// File outputs log records to a file which can be log rotated based on size or age,
+// or on a daily or hourly schedule when FileOptions.FilenamePattern is set, or rotated
+// externally when FileOptions.ExternalRotation is set.
+// Uses `https://github.com/natefinch/lumberjack` for size based rotation.
 type File struct {
-	out io.WriteCloser
+	opts FileOptions
+	out  io.WriteCloser
 }
 
 // NewFileTarget creates a target capable of outputting log records to a rotated file.
 func NewFileTarget(opts FileOptions) *File {
+	if opts.ExternalRotation {
+		return &File{opts: opts, out: newReopeningFile(opts)}
+	}
+	if opts.FilenamePattern != "" {
+		return &File{opts: opts, out: newRotatingFile(opts)}
+	}
+
 	lumber := &lumberjack.Logger{
 		Filename:   opts.Filename,
 		MaxSize:    opts.MaxSize,
@@ -57,18 +271,51 @@ func NewFileTarget(opts FileOptions) *File {
 		MaxAge:     opts.MaxAge,
 		Compress:   opts.Compress,
 	}
-	f := &File{out: lumber}
+	f := &File{opts: opts, out: lumber}
 	return f
 }
 
 // Init is called once to initialize the target.
 func (f *File) Init() error {
+	if r, ok := f.out.(*reopeningFile); ok {
+		return r.init()
+	}
+	if r, ok := f.out.(*rotatingFile); ok {
+		return r.err
+	}
+
+	// lumberjack keeps the mode and ownership of an existing file, so create the file
+	// upfront if a mode or ownership is configured.
+	if _, ok := f.out.(*lumberjack.Logger); ok && f.opts.Filename != "" &&
+		(f.opts.FileMode != "" || f.opts.Owner != "" || f.opts.Group != "") {
+		file, err := openLogFile(f.opts.Filename, f.opts)
+		if err != nil {
+			return err
+		}
+		return file.Close()
+	}
 	return nil
 }
 
-// Write outputs bytes to this file target.
+// Reopen closes and reopens the file when using external rotation. It does nothing
+// for files rotated by this target.
+func (f *File) Reopen() error {
+	if r, ok := f.out.(*reopeningFile); ok {
+		return r.Reopen()
+	}
+	return nil
+}
+
+// Write outputs bytes to this file target. Errors from compression, retention or
+// rotate hooks do not fail the write; they are reported via `Logr.ReportError`.
 func (f *File) Write(p []byte, rec *logr.LogRec) (int, error) {
-	return f.out.Write(p)
+	n, err := f.out.Write(p)
+	if r, ok := f.out.(*rotatingFile); ok {
+		if millErr := r.takeMillErr(); millErr != nil {
+			rec.Logger().Logr().ReportError(fmt.Errorf("file target rotation error: %w", millErr))
+		}
+	}
+	return n, err
 }
 
 // Shutdown is called once to free/close any resources.
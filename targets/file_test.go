@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -43,12 +44,12 @@ func ExampleFile() {
 
 func TestFilePlain(t *testing.T) {
 	plain := &formatters.Plain{Delim: " | "}
-	file(t, plain, "./logs/test_lumberjack_plain.log")
+	file(t, plain, filepath.Join(t.TempDir(), "test_lumberjack_plain.log"))
 }
 
 func TestFileJSON(t *testing.T) {
 	json := &formatters.JSON{}
-	file(t, json, "./logs/test_lumberjack_json.log")
+	file(t, json, filepath.Join(t.TempDir(), "test_lumberjack_json.log"))
 }
 
 func file(t *testing.T, formatter logr.Formatter, filename string) {
@@ -0,0 +1,320 @@
+package targets
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/mattermost/logr/v2"
+)
+
+const (
+	ContentTypeJSON   = "application/json"
+	ContentTypeNDJSON = "application/x-ndjson"
+)
+
+// Http outputs log records to an HTTP endpoint via POST requests, optionally batching
+// multiple records per request as NDJSON.
+type Http struct {
+	options *HttpOptions
+	client  *http.Client
+
+	// marshal writes the request body for a batch of formatted records. Defaults to
+	// one record per line.
+	marshal func(w io.Writer, bufs [][]byte) error
+
+	ctx      context.Context
+	cancel   context.CancelFunc
+	shutdown chan struct{}
+}
+
+// HttpOptions provides parameters for posting log records to an HTTP endpoint.
+type HttpOptions struct {
+	// URL is the endpoint log records are posted to.
+	URL string `json:"url"`
+
+	// Headers are added to every request, e.g. for auth tokens.
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// ContentType overrides the Content-Type header. Defaults to `application/x-ndjson`
+	// when batching is enabled, otherwise `application/json`.
+	ContentType string `json:"content_type,omitempty"`
+
+	// Gzip enables gzip compression of request bodies.
+	Gzip bool `json:"gzip"`
+
+	// BatchSize is the maximum number of log records sent per request as
+	// newline delimited records. Batching is disabled when less than 2.
+	BatchSize int `json:"batch_size,omitempty"`
+
+	// BatchLatencyMillis is the maximum time a log record can wait for a batch to
+	// fill before the batch is sent. Defaults to DefaultBatchLatencyMillis.
+	BatchLatencyMillis int64 `json:"batch_latency_millis,omitempty"`
+
+	// TimeoutSecs is the timeout for each request. Defaults to WriteTimeoutSecs.
+	TimeoutSecs int `json:"timeout_secs,omitempty"`
+
+	// MaxRetries is the maximum number of times a failed request is retried, with
+	// exponential backoff. Zero retries until success or shutdown, the same as the TCP
+	// target. A negative value disables retries.
+	MaxRetries int `json:"max_retries,omitempty"`
+
+	// RetryClientErrors determines whether requests that fail with a 4xx status code
+	// are retried. By default they are discarded since resending the same request is
+	// unlikely to succeed. 408 (Request Timeout) and 429 (Too Many Requests) are always
+	// retried. 5xx status codes and network errors are always retried.
+	RetryClientErrors bool `json:"retry_client_errors"`
+
+	// Cert is a path to a .pem or .crt file, or a base64 encoded cert, used to verify
+	// the server. See `GetCertPoolOrNil`.
+	Cert string `json:"cert"`
+
+	// Insecure disables verification of the server's certificate chain and host name.
+	Insecure bool `json:"insecure"`
+}
+
+func (ho HttpOptions) CheckValid() error {
+	if ho.URL == "" {
+		return errors.New("missing url")
+	}
+	u, err := url.Parse(ho.URL)
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid url scheme '%s'", u.Scheme)
+	}
+	if ho.BatchSize < 0 {
+		return errors.New("batch_size cannot be less than zero")
+	}
+	if ho.BatchLatencyMillis < 0 {
+		return errors.New("batch_latency_millis cannot be less than zero")
+	}
+	if ho.TimeoutSecs < 0 {
+		return errors.New("timeout_secs cannot be less than zero")
+	}
+	return nil
+}
+
+// NewHttpTarget creates a target capable of posting log records to an HTTP endpoint, with or without TLS.
+func NewHttpTarget(options *HttpOptions) *Http {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Http{
+		options:  options,
+		ctx:      ctx,
+		cancel:   cancel,
+		shutdown: make(chan struct{}),
+	}
+}
+
+// Init is called once to initialize the target.
+func (h *Http) Init() error {
+	if err := h.options.CheckValid(); err != nil {
+		return err
+	}
+
+	tlsconfig := &tls.Config{
+		InsecureSkipVerify: h.options.Insecure,
+	}
+	pool, err := GetCertPoolOrNil(h.options.Cert)
+	if err != nil {
+		return err
+	}
+	if pool != nil {
+		tlsconfig.RootCAs = pool
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsconfig
+
+	timeout := h.options.TimeoutSecs
+	if timeout == 0 {
+		timeout = WriteTimeoutSecs
+	}
+
+	h.client = &http.Client{
+		Transport: transport,
+		Timeout:   time.Second * time.Duration(timeout),
+	}
+	return nil
+}
+
+// errHttpShutdown is returned for records not delivered because the target was shut
+// down while retrying.
+var errHttpShutdown = errors.New("shut down before delivery")
+
+// Write posts a single formatted log record to the endpoint.
+// Called by dedicated target goroutine and will block until success, retries are
+// exhausted, or shutdown.
+func (h *Http) Write(p []byte, rec *logr.LogRec) (int, error) {
+	if err := h.post([][]byte{p}, rec); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// BatchSize returns the maximum number of records per request and the maximum amount
+// of time a record can wait for the batch to fill.
+func (h *Http) BatchSize() (int, time.Duration) {
+	latency := h.options.BatchLatencyMillis
+	if latency == 0 {
+		latency = DefaultBatchLatencyMillis
+	}
+	return h.options.BatchSize, time.Millisecond * time.Duration(latency)
+}
+
+// WriteBatch posts multiple formatted log records to the endpoint in a single request,
+// one record per line.
+func (h *Http) WriteBatch(recs []*logr.LogRec, bufs [][]byte) error {
+	return h.post(bufs, recs[0])
+}
+
+// Shutdown is called once to free/close any resources.
+// Target queue is already drained when this is called.
+func (h *Http) Shutdown() error {
+	close(h.shutdown)
+	h.cancel()
+	if h.client != nil {
+		h.client.CloseIdleConnections()
+	}
+	return nil
+}
+
+// String returns a string representation of this target.
+func (h *Http) String() string {
+	return fmt.Sprintf("HttpTarget[%s]", h.options.URL)
+}
+
+// post sends the records to the endpoint, retrying with backoff as needed.
+func (h *Http) post(bufs [][]byte, rec *logr.LogRec) error {
+	body, err := h.encodeBody(bufs)
+	if err != nil {
+		return err
+	}
+
+	reporter := rec.Logger().Logr().ReportError
+	backoff := RetryBackoffMillis
+	for try := 0; ; try++ {
+		select {
+		case <-h.shutdown:
+			return fmt.Errorf("log target %s discarded %d record(s): %w", h.String(), len(bufs), errHttpShutdown)
+		default:
+		}
+
+		retry, err := h.send(body)
+		if err == nil {
+			return nil
+		}
+
+		if !retry || (h.options.MaxRetries != 0 && try >= h.options.MaxRetries) {
+			return fmt.Errorf("log target %s discarded %d record(s): %w", h.String(), len(bufs), err)
+		}
+
+		reporter(fmt.Errorf("log target %s post error: %w", h.String(), err))
+		backoff = h.sleep(backoff)
+	}
+}
+
+// send makes a single POST request. The returned bool indicates whether the
+// request can be retried.
+func (h *Http) send(body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(h.ctx, http.MethodPost, h.options.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	contentType := h.options.ContentType
+	if contentType == "" {
+		contentType = ContentTypeJSON
+		if h.options.BatchSize > 1 {
+			contentType = ContentTypeNDJSON
+		}
+	}
+	req.Header.Set("Content-Type", contentType)
+	if h.options.Gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	for k, v := range h.options.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests:
+		return true, fmt.Errorf("unexpected status: %s", resp.Status)
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		return h.options.RetryClientErrors, fmt.Errorf("unexpected status: %s", resp.Status)
+	default:
+		return true, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+}
+
+// encodeBody marshals the records, by default one per line, and optionally compresses the result.
+func (h *Http) encodeBody(bufs [][]byte) ([]byte, error) {
+	var body bytes.Buffer
+	var w io.Writer = &body
+
+	var zw *gzip.Writer
+	if h.options.Gzip {
+		zw = gzip.NewWriter(&body)
+		w = zw
+	}
+
+	marshal := h.marshal
+	if marshal == nil {
+		marshal = writeLines
+	}
+	if err := marshal(w, bufs); err != nil {
+		return nil, err
+	}
+
+	if zw != nil {
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+	}
+	return body.Bytes(), nil
+}
+
+// writeLines writes the records, one per line.
+func writeLines(w io.Writer, bufs [][]byte) error {
+	for _, b := range bufs {
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+		if len(b) == 0 || b[len(b)-1] != '\n' {
+			if _, err := w.Write(logr.Newline); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (h *Http) sleep(backoff int64) int64 {
+	select {
+	case <-h.shutdown:
+	case <-time.After(time.Millisecond * time.Duration(backoff)):
+	}
+
+	nextBackoff := backoff + (backoff >> 1)
+	if nextBackoff > MaxRetryBackoffMillis {
+		nextBackoff = MaxRetryBackoffMillis
+	}
+	return nextBackoff
+}
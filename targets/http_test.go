@@ -0,0 +1,243 @@
+package targets
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr/v2"
+	"github.com/mattermost/logr/v2/formatters"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type httpCollector struct {
+	mux      sync.Mutex
+	requests int
+	lines    []string
+	headers  []http.Header
+}
+
+func (c *httpCollector) handler(t *testing.T, status func(n int) int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c.mux.Lock()
+		defer c.mux.Unlock()
+		c.requests++
+
+		code := status(c.requests)
+		if code != http.StatusOK {
+			w.WriteHeader(code)
+			return
+		}
+
+		var body io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			zr, err := gzip.NewReader(r.Body)
+			require.NoError(t, err)
+			body = zr
+		}
+		scanner := bufio.NewScanner(body)
+		for scanner.Scan() {
+			c.lines = append(c.lines, scanner.Text())
+		}
+		c.headers = append(c.headers, r.Header.Clone())
+	}
+}
+
+func (c *httpCollector) get() (int, []string, []http.Header) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.requests, append([]string{}, c.lines...), c.headers
+}
+
+func alwaysOK(int) int { return http.StatusOK }
+
+func newHttpTestLogr(t *testing.T, opts *HttpOptions) *logr.Logr {
+	lgr, err := logr.New(logr.OnLoggerError(func(err error) {
+		t.Log("OnLoggerError", err)
+	}))
+	require.NoError(t, err)
+
+	filter := &logr.StdFilter{Lvl: logr.Info}
+	formatter := &formatters.JSON{DisableTimestamp: true}
+	err = lgr.AddTarget(NewHttpTarget(opts), "http", filter, formatter, 1000)
+	require.NoError(t, err)
+	return lgr
+}
+
+func TestHttpTarget(t *testing.T) {
+	t.Run("single records", func(t *testing.T) {
+		collector := &httpCollector{}
+		server := httptest.NewServer(collector.handler(t, alwaysOK))
+		defer server.Close()
+
+		lgr := newHttpTestLogr(t, &HttpOptions{
+			URL:     server.URL,
+			Headers: map[string]string{"Authorization": "Bearer xyz"},
+		})
+
+		logger := lgr.NewLogger().With(logr.String("name", "wiggin"))
+		logger.Info("I drink your milkshake!")
+		logger.Info("We don't need no badges!")
+		require.NoError(t, lgr.Shutdown())
+
+		requests, lines, headers := collector.get()
+		assert.Equal(t, 2, requests)
+		require.Len(t, lines, 2)
+		assert.Contains(t, lines[0], "milkshake")
+		assert.Equal(t, "Bearer xyz", headers[0].Get("Authorization"))
+		assert.Equal(t, ContentTypeJSON, headers[0].Get("Content-Type"))
+	})
+
+	t.Run("gzip NDJSON batches", func(t *testing.T) {
+		collector := &httpCollector{}
+		server := httptest.NewServer(collector.handler(t, alwaysOK))
+		defer server.Close()
+
+		lgr := newHttpTestLogr(t, &HttpOptions{
+			URL:                server.URL,
+			Gzip:               true,
+			BatchSize:          10,
+			BatchLatencyMillis: 60000,
+		})
+
+		logger := lgr.NewLogger()
+		for i := 0; i < 20; i++ {
+			logger.Info("batched", logr.Int("i", i))
+		}
+		require.NoError(t, lgr.Shutdown())
+
+		requests, lines, headers := collector.get()
+		assert.Equal(t, 2, requests)
+		require.Len(t, lines, 20)
+		for _, line := range lines {
+			var rec map[string]any
+			require.NoError(t, json.Unmarshal([]byte(line), &rec))
+			assert.Equal(t, "batched", rec["msg"])
+		}
+		assert.Equal(t, ContentTypeNDJSON, headers[0].Get("Content-Type"))
+		assert.Equal(t, "gzip", headers[0].Get("Content-Encoding"))
+	})
+
+	t.Run("server errors are retried", func(t *testing.T) {
+		collector := &httpCollector{}
+		server := httptest.NewServer(collector.handler(t, func(n int) int {
+			if n < 3 {
+				return http.StatusServiceUnavailable
+			}
+			return http.StatusOK
+		}))
+		defer server.Close()
+
+		lgr := newHttpTestLogr(t, &HttpOptions{URL: server.URL})
+
+		lgr.NewLogger().Info("eventually")
+		require.NoError(t, lgr.Shutdown())
+
+		requests, lines, _ := collector.get()
+		assert.Equal(t, 3, requests)
+		require.Len(t, lines, 1)
+		assert.Contains(t, lines[0], "eventually")
+	})
+
+	t.Run("client errors are discarded", func(t *testing.T) {
+		collector := &httpCollector{}
+		server := httptest.NewServer(collector.handler(t, func(n int) int {
+			if n == 1 {
+				return http.StatusBadRequest
+			}
+			return http.StatusOK
+		}))
+		defer server.Close()
+
+		var errCount int32
+		lgr, err := logr.New(logr.OnLoggerError(func(err error) {
+			if strings.Contains(err.Error(), "discarded") {
+				atomic.AddInt32(&errCount, 1)
+			}
+		}))
+		require.NoError(t, err)
+		err = lgr.AddTarget(NewHttpTarget(&HttpOptions{URL: server.URL}), "http",
+			&logr.StdFilter{Lvl: logr.Info}, &formatters.JSON{}, 1000)
+		require.NoError(t, err)
+
+		lgr.NewLogger().Info("rejected")
+		lgr.NewLogger().Info("accepted")
+		require.NoError(t, lgr.Shutdown())
+
+		requests, lines, _ := collector.get()
+		assert.Equal(t, 2, requests)
+		require.Len(t, lines, 1)
+		assert.Contains(t, lines[0], "accepted")
+		assert.EqualValues(t, 1, atomic.LoadInt32(&errCount))
+	})
+
+	t.Run("max retries", func(t *testing.T) {
+		collector := &httpCollector{}
+		server := httptest.NewServer(collector.handler(t, func(int) int {
+			return http.StatusInternalServerError
+		}))
+		defer server.Close()
+
+		lgr := newHttpTestLogr(t, &HttpOptions{URL: server.URL, MaxRetries: 2})
+
+		lgr.NewLogger().Info("never delivered")
+		require.NoError(t, lgr.Shutdown())
+
+		requests, lines, _ := collector.get()
+		assert.Equal(t, 3, requests)
+		assert.Empty(t, lines)
+	})
+}
+
+func TestHttpShutdownWhileRetrying(t *testing.T) {
+	collector := &httpCollector{}
+	server := httptest.NewServer(collector.handler(t, func(int) int {
+		return http.StatusServiceUnavailable
+	}))
+	defer server.Close()
+
+	lgr, err := logr.New()
+	require.NoError(t, err)
+	defer lgr.Shutdown()
+
+	h := NewHttpTarget(&HttpOptions{URL: server.URL})
+	require.NoError(t, h.Init())
+
+	rec := logr.NewLogRec(logr.Info, lgr.NewLogger(), "undelivered", nil, false)
+	result := make(chan error, 1)
+	go func() {
+		_, err := h.Write([]byte("undelivered\n"), rec)
+		result <- err
+	}()
+
+	require.Eventually(t, func() bool {
+		requests, _, _ := collector.get()
+		return requests > 0
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, h.Shutdown())
+
+	select {
+	case err := <-result:
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, errHttpShutdown), err.Error())
+	case <-time.After(5 * time.Second):
+		t.Fatal("write did not return after shutdown")
+	}
+}
+
+func TestHttpOptions_CheckValid(t *testing.T) {
+	assert.Error(t, HttpOptions{}.CheckValid())
+	assert.Error(t, HttpOptions{URL: "ftp://example.com"}.CheckValid())
+	assert.Error(t, HttpOptions{URL: "http://example.com", BatchSize: -1}.CheckValid())
+	assert.NoError(t, HttpOptions{URL: "https://example.com/logs"}.CheckValid())
+}
@@ -0,0 +1,362 @@
+//go:build linux
+// +build linux
+
+package targets
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"unsafe"
+
+	"github.com/mattermost/logr/v2"
+)
+
+const (
+	// DefaultJournaldSocket is the path of journald's native protocol socket.
+	DefaultJournaldSocket = "/run/systemd/journal/socket"
+
+	// journaldFieldPrefix is prepended to field names colliding with fields set by the target.
+	journaldFieldPrefix = "LOGR_"
+
+	// journaldMaxFieldName is the maximum length of a journal field name.
+	journaldMaxFieldName = 64
+)
+
+// journaldReserved lists the journal fields set by the Journald target.
+var journaldReserved = map[string]struct{}{
+	"MESSAGE": {}, "PRIORITY": {}, "SYSLOG_IDENTIFIER": {}, "LOGGER": {},
+	"CODE_FILE": {}, "CODE_LINE": {}, "CODE_FUNC": {},
+}
+
+// Journald outputs log records to the systemd journal via the native journal protocol,
+// preserving fields so they can be queried with e.g. `journalctl -o json` or
+// `journalctl FIELD=value`.
+//
+// Each record is sent with MESSAGE, PRIORITY and SYSLOG_IDENTIFIER, plus LOGGER for named
+// loggers and CODE_FILE, CODE_LINE and CODE_FUNC for the caller unless
+// `JournaldOptions.DisableCaller` is set. Field keys are upper-cased and sanitized to
+// valid journal field names. Since fields are output without the formatter, a redacting
+// formatter does not apply to them; use `SetRedactor` instead.
+type Journald struct {
+	params   *JournaldOptions
+	redactor atomic.Pointer[logr.Redactor]
+
+	conn       *net.UnixConn
+	addr       *net.UnixAddr
+	identifier string
+	severities map[logr.LevelID]int
+}
+
+// JournaldOptions provides parameters for writing to the systemd journal.
+type JournaldOptions struct {
+	// SocketPath is the path of journald's native socket. Defaults to DefaultJournaldSocket.
+	SocketPath string `json:"socket_path,omitempty"`
+
+	// SyslogIdentifier is output as SYSLOG_IDENTIFIER. Defaults to the executable name.
+	SyslogIdentifier string `json:"syslog_identifier,omitempty"`
+
+	// FieldPrefix is prepended to the journal field name of every log record field,
+	// e.g. "APP_".
+	FieldPrefix string `json:"field_prefix,omitempty"`
+
+	// FormattedMessage outputs the formatter's output as MESSAGE instead of the log
+	// record's message.
+	FormattedMessage bool `json:"formatted_message,omitempty"`
+
+	// Severities maps level IDs to syslog severity names ("emerg", "alert", "crit", "err",
+	// "warning", "notice", "info", "debug"), output as PRIORITY. Standard levels not in
+	// the map use the default mapping; custom levels not in the map use "info".
+	Severities map[logr.LevelID]string `json:"severities,omitempty"`
+
+	// DisableCaller omits CODE_FILE, CODE_LINE and CODE_FUNC, so the caller's stack is
+	// only captured if needed by the formatter or level.
+	DisableCaller bool `json:"disable_caller,omitempty"`
+}
+
+func (jo JournaldOptions) CheckValid() error {
+	if prefix := strings.ToUpper(jo.FieldPrefix); prefix != "" && journaldFieldName(prefix+"X") != prefix+"X" {
+		return fmt.Errorf("invalid field_prefix '%s'", jo.FieldPrefix)
+	}
+	for id, sev := range jo.Severities {
+		if _, ok := SyslogSeverities[sev]; !ok {
+			return fmt.Errorf("invalid severity '%s' for level %d", sev, id)
+		}
+	}
+	return nil
+}
+
+// NewJournaldTarget creates a target capable of outputting log records to the systemd journal.
+func NewJournaldTarget(params *JournaldOptions) (*Journald, error) {
+	if params == nil {
+		return nil, errors.New("params cannot be nil")
+	}
+
+	j := &Journald{
+		params: params,
+	}
+	return j, nil
+}
+
+// SetRedactor sets a Redactor applied to each record's message and fields before they
+// are sent to the journal.
+func (j *Journald) SetRedactor(redactor *logr.Redactor) {
+	j.redactor.Store(redactor)
+}
+
+// IsCallerNeeded returns true unless `DisableCaller` is set, so records are captured with
+// stack frames for CODE_FILE, CODE_LINE and CODE_FUNC.
+func (j *Journald) IsCallerNeeded() bool {
+	return !j.params.DisableCaller
+}
+
+// Init is called once to initialize the target.
+func (j *Journald) Init() error {
+	if err := j.params.CheckValid(); err != nil {
+		return err
+	}
+
+	j.severities = map[logr.LevelID]int{
+		logr.Panic.ID: SyslogSeverities["crit"],
+		logr.Fatal.ID: SyslogSeverities["crit"],
+		logr.Error.ID: SyslogSeverities["err"],
+		logr.Warn.ID:  SyslogSeverities["warning"],
+		logr.Info.ID:  SyslogSeverities["info"],
+		logr.Debug.ID: SyslogSeverities["debug"],
+		logr.Trace.ID: SyslogSeverities["debug"],
+	}
+	for id, sev := range j.params.Severities {
+		j.severities[id] = SyslogSeverities[sev]
+	}
+
+	j.identifier = j.params.SyslogIdentifier
+	if j.identifier == "" {
+		j.identifier = filepath.Base(os.Args[0])
+	}
+
+	path := j.params.SocketPath
+	if path == "" {
+		path = DefaultJournaldSocket
+	}
+	j.addr = &net.UnixAddr{Name: path, Net: "unixgram"}
+
+	// the socket is left unconnected so that writes survive journald restarts.
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"})
+	if err != nil {
+		return fmt.Errorf("cannot create journald socket: %w", err)
+	}
+	j.conn = conn
+	return nil
+}
+
+// Write outputs a log record to the journal.
+func (j *Journald) Write(p []byte, rec *logr.LogRec) (int, error) {
+	if redactor := j.redactor.Load(); redactor != nil {
+		rec = redactor.Redact(rec)
+	}
+	data := j.entry(p, rec)
+
+	_, _, err := j.conn.WriteMsgUnix(data, nil, j.addr)
+	if err == nil {
+		return len(p), nil
+	}
+	if !errors.Is(err, syscall.EMSGSIZE) && !errors.Is(err, syscall.ENOBUFS) {
+		return 0, fmt.Errorf("cannot write to journald: %w", err)
+	}
+
+	// entry is too large for a datagram; pass it via a file descriptor instead.
+	if err := j.writeViaFile(data); err != nil {
+		return 0, fmt.Errorf("cannot write large entry to journald: %w", err)
+	}
+	return len(p), nil
+}
+
+// entry serializes a log record using the journal export format.
+func (j *Journald) entry(p []byte, rec *logr.LogRec) []byte {
+	var buf bytes.Buffer
+
+	msg := rec.Msg()
+	if j.params.FormattedMessage {
+		msg = strings.TrimRight(string(p), " \t\r\n")
+	}
+	journaldAppend(&buf, "MESSAGE", msg)
+
+	severity, ok := j.severities[rec.Level().ID]
+	if !ok {
+		severity = SyslogSeverities["info"]
+	}
+	journaldAppend(&buf, "PRIORITY", strconv.Itoa(severity))
+	journaldAppend(&buf, "SYSLOG_IDENTIFIER", j.identifier)
+
+	if name := rec.Logger().Name(); name != "" {
+		journaldAppend(&buf, "LOGGER", name)
+	}
+
+	if !j.params.DisableCaller {
+		for _, frame := range rec.StackFrames() {
+			if frame.File == "" {
+				continue
+			}
+			journaldAppend(&buf, "CODE_FILE", frame.File)
+			journaldAppend(&buf, "CODE_LINE", strconv.Itoa(frame.Line))
+			journaldAppend(&buf, "CODE_FUNC", frame.Function)
+			break
+		}
+	}
+
+	for _, field := range rec.Fields() {
+		name := journaldFieldName(j.params.FieldPrefix + field.Key)
+		if name == "" {
+			continue
+		}
+		if _, ok := journaldReserved[name]; ok {
+			name = journaldFieldName(journaldFieldPrefix + name)
+		}
+
+		var val strings.Builder
+		if err := field.ValueString(&val, nil); err != nil {
+			val.Reset()
+			fmt.Fprintf(&val, "<error encoding field: %v>", err)
+		}
+		journaldAppend(&buf, name, val.String())
+	}
+	return buf.Bytes()
+}
+
+// writeViaFile writes the entry to a memfd, or an unlinked temp file if memfd is not
+// available, and passes the file descriptor to journald.
+func (j *Journald) writeViaFile(data []byte) error {
+	f, err := journaldMemfd(data)
+	if err != nil {
+		if f, err = journaldTempFile(data); err != nil {
+			return err
+		}
+	}
+	defer f.Close()
+
+	rights := syscall.UnixRights(int(f.Fd()))
+	_, _, err = j.conn.WriteMsgUnix(nil, rights, j.addr)
+	return err
+}
+
+// Shutdown is called once to free/close any resources.
+// Target queue is already drained when this is called.
+func (j *Journald) Shutdown() error {
+	if j.conn == nil {
+		return nil
+	}
+	return j.conn.Close()
+}
+
+// journaldAppend appends a field to the entry. Values containing newlines are written
+// with an explicit little-endian 64 bit length.
+func journaldAppend(buf *bytes.Buffer, name string, value string) {
+	buf.WriteString(name)
+	if !strings.ContainsRune(value, '\n') {
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+		return
+	}
+	buf.WriteByte('\n')
+	_ = binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+// journaldFieldName converts s to a valid journal field name: upper-case letters, digits
+// and underscores, not starting with an underscore or digit, at most 64 characters.
+// Returns an empty string if nothing valid remains.
+func journaldFieldName(s string) string {
+	b := []byte(strings.ToUpper(s))
+	for i, c := range b {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			b[i] = '_'
+		}
+	}
+	// leading underscores are reserved for trusted fields added by journald.
+	name := strings.TrimLeft(string(b), "_")
+	if name != "" && name[0] >= '0' && name[0] <= '9' {
+		name = "F" + name
+	}
+	if len(name) > journaldMaxFieldName {
+		name = name[:journaldMaxFieldName]
+	}
+	return name
+}
+
+// memfdCreateSyscall contains the memfd_create syscall number for each architecture.
+var memfdCreateSyscall = map[string]uintptr{
+	"386":     356,
+	"amd64":   319,
+	"arm":     385,
+	"arm64":   279,
+	"loong64": 279,
+	"ppc64":   360,
+	"ppc64le": 360,
+	"riscv64": 279,
+	"s390x":   350,
+}
+
+const (
+	mfdCloexec       = 0x1
+	mfdAllowSealing  = 0x2
+	fcntlAddSeals    = 1033
+	sealAll          = 0x1 | 0x2 | 0x4 | 0x8 // F_SEAL_SEAL, F_SEAL_SHRINK, F_SEAL_GROW, F_SEAL_WRITE
+	journaldFileName = "logr-journal"
+)
+
+// journaldMemfd returns a sealed memfd containing data.
+func journaldMemfd(data []byte) (*os.File, error) {
+	trap, ok := memfdCreateSyscall[runtime.GOARCH]
+	if !ok {
+		return nil, errors.New("memfd_create not supported")
+	}
+	name, err := syscall.BytePtrFromString(journaldFileName)
+	if err != nil {
+		return nil, err
+	}
+	fd, _, errno := syscall.Syscall(trap, uintptr(unsafe.Pointer(name)), mfdCloexec|mfdAllowSealing, 0)
+	if errno != 0 {
+		return nil, errno
+	}
+	f := os.NewFile(fd, journaldFileName)
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if _, _, errno := syscall.Syscall(syscall.SYS_FCNTL, fd, fcntlAddSeals, sealAll); errno != 0 {
+		f.Close()
+		return nil, errno
+	}
+	return f, nil
+}
+
+// journaldTempFile returns an unlinked temp file containing data.
+func journaldTempFile(data []byte) (*os.File, error) {
+	f, err := os.CreateTemp("/dev/shm", journaldFileName)
+	if err != nil {
+		if f, err = os.CreateTemp("", journaldFileName); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.Remove(f.Name()); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
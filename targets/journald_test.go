@@ -0,0 +1,206 @@
+//go:build linux
+// +build linux
+
+package targets_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr/v2"
+	"github.com/mattermost/logr/v2/formatters"
+	"github.com/mattermost/logr/v2/targets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJournald(t *testing.T) {
+	conn := listenJournald(t)
+	defer conn.Close()
+
+	audit := logr.Level{ID: 100, Name: "audit"}
+	params := &targets.JournaldOptions{
+		SocketPath:       conn.LocalAddr().String(),
+		SyslogIdentifier: "logrtest",
+		Severities:       map[logr.LevelID]string{audit.ID: "notice"},
+	}
+	logToJournald(t, params, func(logger logr.Logger) {
+		logger.Named("api").Warn("disk almost full",
+			logr.String("disk.path", "/data"),
+			logr.Int("pct", 95),
+			logr.String("message", "dup"),
+			logr.String("_trusted", "x"),
+			logr.String("notes", "line1\nline2"),
+		)
+		logger.Log(audit, "user logged in")
+	})
+
+	entry := readJournald(t, conn)
+	assert.Equal(t, "disk almost full", entry["MESSAGE"])
+	assert.Equal(t, "4", entry["PRIORITY"])
+	assert.Equal(t, "logrtest", entry["SYSLOG_IDENTIFIER"])
+	assert.Equal(t, "api", entry["LOGGER"])
+	assert.Equal(t, "/data", entry["DISK_PATH"])
+	assert.Equal(t, "95", entry["PCT"])
+	assert.Equal(t, "dup", entry["LOGR_MESSAGE"])
+	assert.Equal(t, "x", entry["TRUSTED"])
+	assert.Equal(t, "line1\nline2", entry["NOTES"])
+	assert.True(t, strings.HasSuffix(entry["CODE_FILE"], "journald_test.go"), entry["CODE_FILE"])
+	assert.NotEmpty(t, entry["CODE_LINE"])
+	assert.Contains(t, entry["CODE_FUNC"], "TestJournald")
+
+	entry = readJournald(t, conn)
+	assert.Equal(t, "user logged in", entry["MESSAGE"])
+	assert.Equal(t, "5", entry["PRIORITY"])
+}
+
+func TestJournaldDefaultCaller(t *testing.T) {
+	conn := listenJournald(t)
+	defer conn.Close()
+
+	lgr, err := logr.New()
+	require.NoError(t, err)
+	target, err := targets.NewJournaldTarget(&targets.JournaldOptions{SocketPath: conn.LocalAddr().String()})
+	require.NoError(t, err)
+
+	// neither the filter nor the formatter need a stack trace.
+	err = lgr.AddTarget(target, "journald", &logr.StdFilter{Lvl: logr.Info}, &formatters.Plain{}, 100)
+	require.NoError(t, err)
+	lgr.NewLogger().Info("with caller")
+	require.NoError(t, lgr.Shutdown())
+
+	entry := readJournald(t, conn)
+	assert.Equal(t, "with caller", entry["MESSAGE"])
+	assert.True(t, strings.HasSuffix(entry["CODE_FILE"], "journald_test.go"), entry["CODE_FILE"])
+	assert.NotEmpty(t, entry["CODE_LINE"])
+	assert.Contains(t, entry["CODE_FUNC"], "TestJournaldDefaultCaller")
+}
+
+func TestJournaldDisableCaller(t *testing.T) {
+	conn := listenJournald(t)
+	defer conn.Close()
+
+	params := &targets.JournaldOptions{
+		SocketPath:    conn.LocalAddr().String(),
+		DisableCaller: true,
+	}
+	logToJournald(t, params, func(logger logr.Logger) {
+		logger.Error("no caller")
+	})
+
+	entry := readJournald(t, conn)
+	assert.Equal(t, "no caller", entry["MESSAGE"])
+	assert.NotContains(t, entry, "CODE_FILE")
+	assert.NotContains(t, entry, "CODE_LINE")
+	assert.NotContains(t, entry, "CODE_FUNC")
+}
+
+func TestJournaldLargeEntry(t *testing.T) {
+	conn := listenJournald(t)
+	defer conn.Close()
+
+	params := &targets.JournaldOptions{
+		SocketPath:       conn.LocalAddr().String(),
+		FieldPrefix:      "app_",
+		FormattedMessage: true,
+	}
+	big := strings.Repeat("x", 1024*1024)
+	logToJournald(t, params, func(logger logr.Logger) {
+		logger.Error("huge", logr.String("payload", big))
+	})
+
+	entry := readJournald(t, conn)
+	assert.True(t, strings.HasPrefix(entry["MESSAGE"], "huge caller="), entry["MESSAGE"])
+	assert.Equal(t, "3", entry["PRIORITY"])
+	assert.Equal(t, big, entry["APP_PAYLOAD"])
+}
+
+func TestJournaldOptions_CheckValid(t *testing.T) {
+	assert.NoError(t, targets.JournaldOptions{FieldPrefix: "app_"}.CheckValid())
+	assert.Error(t, targets.JournaldOptions{FieldPrefix: "_app"}.CheckValid())
+	assert.Error(t, targets.JournaldOptions{FieldPrefix: "app-"}.CheckValid())
+	assert.Error(t, targets.JournaldOptions{Severities: map[logr.LevelID]string{100: "loud"}}.CheckValid())
+}
+
+func listenJournald(t *testing.T) *net.UnixConn {
+	path := filepath.Join(t.TempDir(), "journal.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	require.NoError(t, err)
+	return conn
+}
+
+func logToJournald(t *testing.T, params *targets.JournaldOptions, log func(logger logr.Logger)) {
+	opt := logr.OnLoggerError(func(err error) {
+		t.Error(err)
+	})
+	lgr, err := logr.New(opt)
+	require.NoError(t, err)
+
+	target, err := targets.NewJournaldTarget(params)
+	require.NoError(t, err)
+
+	filter := &logr.CustomFilter{}
+	filter.Add(logr.Warn, logr.Error, logr.Level{ID: 100, Name: "audit"})
+	formatter := &formatters.Plain{DisableTimestamp: true, DisableLevel: true, DisableFields: true, EnableCaller: true}
+	err = lgr.AddTarget(target, "journald", filter, formatter, 100)
+	require.NoError(t, err)
+
+	log(lgr.NewLogger())
+	require.NoError(t, lgr.Shutdown())
+}
+
+// readJournald reads one entry from the listener, either inline or via a passed file descriptor.
+func readJournald(t *testing.T, conn *net.UnixConn) map[string]string {
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+
+	buf := make([]byte, 256*1024)
+	oob := make([]byte, syscall.CmsgSpace(4))
+	n, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+	require.NoError(t, err)
+	data := buf[:n]
+
+	if oobn > 0 {
+		msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+		require.NoError(t, err)
+		require.Len(t, msgs, 1)
+		fds, err := syscall.ParseUnixRights(&msgs[0])
+		require.NoError(t, err)
+		require.Len(t, fds, 1)
+
+		f := os.NewFile(uintptr(fds[0]), "journal-entry")
+		defer f.Close()
+		data, err = io.ReadAll(io.NewSectionReader(f, 0, 1<<30))
+		require.NoError(t, err)
+	}
+	return parseJournaldEntry(t, data)
+}
+
+// parseJournaldEntry parses the journal export format.
+func parseJournaldEntry(t *testing.T, data []byte) map[string]string {
+	entry := make(map[string]string)
+	for len(data) > 0 {
+		nl := bytes.IndexByte(data, '\n')
+		require.GreaterOrEqual(t, nl, 0)
+		line := data[:nl]
+		data = data[nl+1:]
+
+		if eq := bytes.IndexByte(line, '='); eq >= 0 {
+			entry[string(line[:eq])] = string(line[eq+1:])
+			continue
+		}
+		require.GreaterOrEqual(t, len(data), 8)
+		size := int(binary.LittleEndian.Uint64(data))
+		require.GreaterOrEqual(t, len(data), 8+size+1)
+		entry[string(line)] = string(data[8 : 8+size])
+		data = data[8+size+1:]
+	}
+	return entry
+}
@@ -0,0 +1,58 @@
+//go:build !linux
+// +build !linux
+
+package targets
+
+import (
+	"errors"
+
+	"github.com/mattermost/logr/v2"
+)
+
+const (
+	unsupportedJournald = "Journald target is not supported on this platform."
+)
+
+// Journald outputs log records to the systemd journal.
+type Journald struct {
+	params *JournaldOptions
+}
+
+// JournaldOptions provides parameters for writing to the systemd journal.
+type JournaldOptions struct {
+	SocketPath       string                  `json:"socket_path,omitempty"`
+	SyslogIdentifier string                  `json:"syslog_identifier,omitempty"`
+	FieldPrefix      string                  `json:"field_prefix,omitempty"`
+	FormattedMessage bool                    `json:"formatted_message,omitempty"`
+	Severities       map[logr.LevelID]string `json:"severities,omitempty"`
+	DisableCaller    bool                    `json:"disable_caller,omitempty"`
+}
+
+func (jo JournaldOptions) CheckValid() error {
+	return errors.New(unsupportedJournald)
+}
+
+// NewJournaldTarget creates a target capable of outputting log records to the systemd journal.
+func NewJournaldTarget(params *JournaldOptions) (*Journald, error) {
+	return nil, errors.New(unsupportedJournald)
+}
+
+// SetRedactor sets a Redactor applied to each record before it is sent to the journal.
+func (j *Journald) SetRedactor(redactor *logr.Redactor) {
+}
+
+// Init is called once to initialize the target.
+func (j *Journald) Init() error {
+	return errors.New(unsupportedJournald)
+}
+
+// Write outputs a log record to the journal.
+func (j *Journald) Write(p []byte, rec *logr.LogRec) (int, error) {
+	return 0, errors.New(unsupportedJournald)
+}
+
+// Shutdown is called once to free/close any resources.
+// Target queue is already drained when this is called.
+func (j *Journald) Shutdown() error {
+	return errors.New(unsupportedJournald)
+}
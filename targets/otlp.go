@@ -0,0 +1,202 @@
+package targets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/mattermost/logr/v2"
+	"github.com/mattermost/logr/v2/formatters"
+)
+
+const (
+	ContentTypeProtobuf = "application/x-protobuf"
+
+	// DefaultOTLPBatchSize is the default maximum number of log records per export request.
+	DefaultOTLPBatchSize = 100
+
+	// OTLPScopeName is the instrumentation scope name sent with every batch of log records.
+	OTLPScopeName = "github.com/mattermost/logr/v2"
+)
+
+var errOTLPFormat = errors.New("log record is not an OTLP log record with matching encoding; use the otlp formatter")
+
+// OTLP exports log records to an OpenTelemetry collector via OTLP/HTTP
+// (https://opentelemetry.io/docs/specs/otlp/#otlphttp). Records must be formatted with
+// the `formatters.OTLP` formatter using the same encoding. Batches of records are sent
+// in a single export request along with the resource attributes, with retries and
+// optional gzip compression provided by the `Http` target.
+type OTLP struct {
+	*Http
+	options *OTLPOptions
+
+	prefix []byte // JSON encoding: request up to the first log record.
+	suffix []byte // JSON encoding: request after the last log record.
+
+	resource []byte // protobuf encoding: the encoded Resource message.
+}
+
+// OTLPOptions provides parameters for exporting log records to an OpenTelemetry collector.
+// The embedded `HttpOptions` configure the endpoint, e.g. "http://localhost:4318/v1/logs",
+// headers, gzip, batching, retries and TLS. `ContentType` is set from `Encoding`.
+type OTLPOptions struct {
+	HttpOptions
+
+	// Encoding is one of "json" (default) or "protobuf", and must match the encoding
+	// of the `formatters.OTLP` formatter.
+	Encoding string `json:"encoding"`
+
+	// ServiceName is output as the `service.name` resource attribute. Defaults to
+	// "unknown_service:" followed by the executable name.
+	ServiceName string `json:"service_name"`
+
+	// Hostname is output as the `host.name` resource attribute. Defaults to os.Hostname.
+	Hostname string `json:"hostname"`
+
+	// ResourceAttributes are additional resource attributes, such as `service.version`
+	// or `deployment.environment`.
+	ResourceAttributes map[string]string `json:"resource_attributes,omitempty"`
+}
+
+func (oo OTLPOptions) CheckValid() error {
+	switch oo.Encoding {
+	case "", formatters.OTLPEncodingJSON, formatters.OTLPEncodingProtobuf:
+	default:
+		return fmt.Errorf("invalid encoding '%s'", oo.Encoding)
+	}
+	return oo.HttpOptions.CheckValid()
+}
+
+// NewOTLPTarget creates a target capable of exporting log records to an OpenTelemetry
+// collector via OTLP/HTTP, with or without TLS.
+func NewOTLPTarget(options *OTLPOptions) *OTLP {
+	opts := *options
+	if opts.Encoding == "" {
+		opts.Encoding = formatters.OTLPEncodingJSON
+	}
+
+	httpOpts := opts.HttpOptions
+	httpOpts.ContentType = ContentTypeJSON
+	if opts.Encoding == formatters.OTLPEncodingProtobuf {
+		httpOpts.ContentType = ContentTypeProtobuf
+	}
+	if httpOpts.BatchSize == 0 {
+		httpOpts.BatchSize = DefaultOTLPBatchSize
+	}
+
+	o := &OTLP{
+		Http:    NewHttpTarget(&httpOpts),
+		options: &opts,
+	}
+	o.Http.marshal = o.marshal
+	return o
+}
+
+// Encoding returns the encoding of export requests, either "json" or "protobuf".
+func (o *OTLP) Encoding() string {
+	return o.options.Encoding
+}
+
+// Init is called once to initialize the target.
+func (o *OTLP) Init() error {
+	if err := o.options.CheckValid(); err != nil {
+		return err
+	}
+
+	attrs := o.resourceAttributes()
+	if o.options.Encoding == formatters.OTLPEncodingProtobuf {
+		o.resource = formatters.OTLPProtoResource(attrs)
+	} else {
+		attrsJSON, err := json.Marshal(jsonAttributes(attrs))
+		if err != nil {
+			return err
+		}
+		o.prefix = fmt.Appendf(nil, `{"resourceLogs":[{"resource":{"attributes":%s},"scopeLogs":[{"scope":{"name":%q},"logRecords":[`, attrsJSON, OTLPScopeName)
+		o.suffix = []byte(`]}]}]}`)
+	}
+	return o.Http.Init()
+}
+
+// String returns a string representation of this target.
+func (o *OTLP) String() string {
+	return fmt.Sprintf("OTLPTarget[%s]", o.options.URL)
+}
+
+// marshal writes an OTLP `ExportLogsServiceRequest` containing the formatted log records.
+func (o *OTLP) marshal(w io.Writer, bufs [][]byte) error {
+	for _, b := range bufs {
+		isJSON := len(b) > 0 && b[0] == '{'
+		if len(b) == 0 || isJSON != (o.options.Encoding == formatters.OTLPEncodingJSON) {
+			return errOTLPFormat
+		}
+	}
+
+	if o.options.Encoding == formatters.OTLPEncodingProtobuf {
+		req := formatters.AppendOTLPProtoRequest(nil, o.resource, OTLPScopeName, bufs)
+		_, err := w.Write(req)
+		return err
+	}
+
+	if _, err := w.Write(o.prefix); err != nil {
+		return err
+	}
+	for i, b := range bufs {
+		if i > 0 {
+			if _, err := w.Write(logr.Comma); err != nil {
+				return err
+			}
+		}
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	_, err := w.Write(o.suffix)
+	return err
+}
+
+type otlpAttr struct {
+	Key   string `json:"key"`
+	Value struct {
+		StringValue string `json:"stringValue"`
+	} `json:"value"`
+}
+
+// resourceAttributes returns the resource attributes.
+func (o *OTLP) resourceAttributes() map[string]string {
+	m := make(map[string]string, len(o.options.ResourceAttributes)+2)
+	for k, v := range o.options.ResourceAttributes {
+		m[k] = v
+	}
+
+	if o.options.ServiceName != "" {
+		m["service.name"] = o.options.ServiceName
+	} else if _, ok := m["service.name"]; !ok {
+		m["service.name"] = "unknown_service:" + filepath.Base(os.Args[0])
+	}
+
+	if o.options.Hostname != "" {
+		m["host.name"] = o.options.Hostname
+	} else if _, ok := m["host.name"]; !ok {
+		if h, err := os.Hostname(); err == nil {
+			m["host.name"] = h
+		}
+	}
+
+	return m
+}
+
+// jsonAttributes returns the resource attributes in OTLP/JSON form, sorted by key.
+func jsonAttributes(m map[string]string) []otlpAttr {
+	attrs := make([]otlpAttr, 0, len(m))
+	for k, v := range m {
+		attr := otlpAttr{Key: k}
+		attr.Value.StringValue = v
+		attrs = append(attrs, attr)
+	}
+	sort.Slice(attrs, func(i, j int) bool { return attrs[i].Key < attrs[j].Key })
+	return attrs
+}
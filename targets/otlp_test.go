@@ -0,0 +1,252 @@
+package targets
+
+import (
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mattermost/logr/v2"
+	"github.com/mattermost/logr/v2/formatters"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// otlpCollector is a stand-in for an OpenTelemetry collector's OTLP/HTTP logs endpoint.
+type otlpCollector struct {
+	mux     sync.Mutex
+	bodies  [][]byte
+	headers []http.Header
+}
+
+func (c *otlpCollector) handler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			zr, err := gzip.NewReader(r.Body)
+			require.NoError(t, err)
+			body = zr
+		}
+		data, err := io.ReadAll(body)
+		require.NoError(t, err)
+
+		c.mux.Lock()
+		defer c.mux.Unlock()
+		c.bodies = append(c.bodies, data)
+		c.headers = append(c.headers, r.Header.Clone())
+	}
+}
+
+func (c *otlpCollector) get() ([][]byte, []http.Header) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return append([][]byte{}, c.bodies...), c.headers
+}
+
+func newOTLPTestLogr(t *testing.T, opts *OTLPOptions, formatter logr.Formatter) *logr.Logr {
+	lgr, err := logr.New(logr.OnLoggerError(func(err error) {
+		t.Log("OnLoggerError", err)
+	}))
+	require.NoError(t, err)
+
+	err = lgr.AddTarget(NewOTLPTarget(opts), "otlp", &logr.StdFilter{Lvl: logr.Info}, formatter, 1000)
+	require.NoError(t, err)
+	return lgr
+}
+
+func TestOTLPTarget(t *testing.T) {
+	t.Run("gzip JSON batches", func(t *testing.T) {
+		collector := &otlpCollector{}
+		server := httptest.NewServer(collector.handler(t))
+		defer server.Close()
+
+		opts := &OTLPOptions{
+			HttpOptions: HttpOptions{
+				URL:                server.URL,
+				Gzip:               true,
+				BatchSize:          5,
+				BatchLatencyMillis: 60000,
+			},
+			ServiceName:        "test-service",
+			Hostname:           "test-host",
+			ResourceAttributes: map[string]string{"service.version": "1.2.3"},
+		}
+		lgr := newOTLPTestLogr(t, opts, &formatters.OTLP{})
+
+		logger := lgr.NewLogger()
+		for i := 0; i < 10; i++ {
+			logger.Info("exported", logr.Int("i", i))
+		}
+		require.NoError(t, lgr.Shutdown())
+
+		bodies, headers := collector.get()
+		require.Len(t, bodies, 2)
+		assert.Equal(t, ContentTypeJSON, headers[0].Get("Content-Type"))
+		assert.Equal(t, "gzip", headers[0].Get("Content-Encoding"))
+
+		var req struct {
+			ResourceLogs []struct {
+				Resource struct {
+					Attributes []struct {
+						Key   string
+						Value struct{ StringValue string }
+					}
+				}
+				ScopeLogs []struct {
+					Scope      struct{ Name string }
+					LogRecords []map[string]any
+				}
+			}
+		}
+		require.NoError(t, json.Unmarshal(bodies[0], &req))
+		require.Len(t, req.ResourceLogs, 1)
+
+		resource := make(map[string]string)
+		for _, attr := range req.ResourceLogs[0].Resource.Attributes {
+			resource[attr.Key] = attr.Value.StringValue
+		}
+		assert.Equal(t, map[string]string{
+			"service.name":    "test-service",
+			"host.name":       "test-host",
+			"service.version": "1.2.3",
+		}, resource)
+
+		require.Len(t, req.ResourceLogs[0].ScopeLogs, 1)
+		scopeLogs := req.ResourceLogs[0].ScopeLogs[0]
+		assert.Equal(t, OTLPScopeName, scopeLogs.Scope.Name)
+		require.Len(t, scopeLogs.LogRecords, 5)
+		assert.Equal(t, map[string]any{"stringValue": "exported"}, scopeLogs.LogRecords[0]["body"])
+	})
+
+	t.Run("protobuf", func(t *testing.T) {
+		collector := &otlpCollector{}
+		server := httptest.NewServer(collector.handler(t))
+		defer server.Close()
+
+		opts := &OTLPOptions{
+			HttpOptions: HttpOptions{URL: server.URL, BatchSize: 3, BatchLatencyMillis: 60000},
+			Encoding:    formatters.OTLPEncodingProtobuf,
+			ServiceName: "test-service",
+		}
+		lgr := newOTLPTestLogr(t, opts, &formatters.OTLP{Encoding: formatters.OTLPEncodingProtobuf})
+
+		logger := lgr.NewLogger()
+		logger.Info("first", logr.Int("n", 7), logr.String("trace_id", "4bf92f3577b34da6a3ce929d0e0e4736"))
+		logger.Warn("second")
+		logger.Error("third")
+		require.NoError(t, lgr.Shutdown())
+
+		bodies, headers := collector.get()
+		require.Len(t, bodies, 1)
+		assert.Equal(t, ContentTypeProtobuf, headers[0].Get("Content-Type"))
+
+		resourceLogs := pbFields(t, bodies[0])[1] // ExportLogsServiceRequest.resource_logs
+		require.Len(t, resourceLogs, 1)
+		rl := pbFields(t, resourceLogs[0])
+
+		resourceAttrs := pbFields(t, rl[1][0])[1]
+		assert.Contains(t, pbStringAttrs(t, resourceAttrs), "service.name=test-service")
+
+		scopeLogs := pbFields(t, rl[2][0])
+		assert.Equal(t, OTLPScopeName, string(pbFields(t, scopeLogs[1][0])[1][0]))
+
+		records := scopeLogs[2]
+		require.Len(t, records, 3)
+
+		first := pbFields(t, records[0])
+		assert.Equal(t, "info", string(first[3][0]))                                         // severity_text
+		assert.Equal(t, "first", string(pbFields(t, first[5][0])[1][0]))                     // body.string_value
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", hex.EncodeToString(first[9][0])) // trace_id
+
+		attr := pbFields(t, first[6][0])
+		assert.Equal(t, "n", string(attr[1][0]))
+		intValue, _ := binary.Uvarint(pbFields(t, attr[2][0])[3][0])
+		assert.EqualValues(t, 7, intValue)
+
+		assert.Equal(t, "third", string(pbFields(t, pbFields(t, records[2])[5][0])[1][0]))
+	})
+
+	t.Run("mismatched formatter", func(t *testing.T) {
+		collector := &otlpCollector{}
+		server := httptest.NewServer(collector.handler(t))
+		defer server.Close()
+
+		var errCount int32
+		lgr, err := logr.New(logr.OnLoggerError(func(err error) {
+			if strings.Contains(err.Error(), "otlp formatter") {
+				atomic.AddInt32(&errCount, 1)
+			}
+		}))
+		require.NoError(t, err)
+
+		opts := &OTLPOptions{HttpOptions: HttpOptions{URL: server.URL, BatchSize: 1}}
+		err = lgr.AddTarget(NewOTLPTarget(opts), "otlp", &logr.StdFilter{Lvl: logr.Info}, &formatters.Plain{}, 1000)
+		require.NoError(t, err)
+
+		lgr.NewLogger().Info("plain text")
+		require.NoError(t, lgr.Shutdown())
+
+		bodies, _ := collector.get()
+		assert.Empty(t, bodies)
+		assert.EqualValues(t, 1, atomic.LoadInt32(&errCount))
+	})
+}
+
+func TestOTLPOptions_CheckValid(t *testing.T) {
+	assert.NoError(t, OTLPOptions{HttpOptions: HttpOptions{URL: "http://localhost:4318/v1/logs"}}.CheckValid())
+	assert.Error(t, OTLPOptions{}.CheckValid())
+	assert.Error(t, OTLPOptions{
+		HttpOptions: HttpOptions{URL: "http://localhost:4318/v1/logs"},
+		Encoding:    "xml",
+	}.CheckValid())
+}
+
+// pbFields decodes a protobuf message into field number -> values. Varint and fixed64
+// values are returned as their raw encoded bytes.
+func pbFields(t *testing.T, b []byte) map[int][][]byte {
+	fields := make(map[int][][]byte)
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		require.Greater(t, n, 0, "invalid tag")
+		b = b[n:]
+
+		var v []byte
+		switch tag & 7 {
+		case 0:
+			_, n = binary.Uvarint(b)
+			require.Greater(t, n, 0, "invalid varint")
+			v, b = b[:n], b[n:]
+		case 1:
+			require.GreaterOrEqual(t, len(b), 8)
+			v, b = b[:8], b[8:]
+		case 2:
+			size, n := binary.Uvarint(b)
+			require.Greater(t, n, 0, "invalid length")
+			b = b[n:]
+			require.GreaterOrEqual(t, uint64(len(b)), size)
+			v, b = b[:size], b[size:]
+		default:
+			require.Fail(t, "unexpected wire type", "%d", tag&7)
+		}
+		fields[int(tag>>3)] = append(fields[int(tag>>3)], v)
+	}
+	return fields
+}
+
+// pbStringAttrs decodes KeyValue messages with string values as "key=value".
+func pbStringAttrs(t *testing.T, kvs [][]byte) []string {
+	var attrs []string
+	for _, kv := range kvs {
+		f := pbFields(t, kv)
+		value := pbFields(t, f[2][0])
+		attrs = append(attrs, string(f[1][0])+"="+string(value[1][0]))
+	}
+	return attrs
+}
@@ -0,0 +1,167 @@
+package targets
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultReopenCheckMillis is the default interval for checking whether the file
+	// was moved, removed or truncated when using external rotation.
+	DefaultReopenCheckMillis = 1000
+)
+
+// reopeningFile is an io.WriteCloser that writes to a file rotated by an external tool
+// such as logrotate. The file is reopened on request, when a signal is received, or when
+// the path no longer refers to the open file or the file was truncated.
+type reopeningFile struct {
+	opts          FileOptions
+	checkInterval time.Duration
+	now           func() time.Time
+
+	mux       sync.Mutex
+	file      *os.File
+	info      os.FileInfo
+	size      int64
+	lastCheck time.Time
+	closed    bool
+
+	sigCh chan os.Signal
+	quit  chan struct{}
+	done  chan struct{}
+}
+
+func newReopeningFile(opts FileOptions) *reopeningFile {
+	interval := time.Duration(opts.ReopenCheckMillis) * time.Millisecond
+	if opts.ReopenCheckMillis == 0 {
+		interval = DefaultReopenCheckMillis * time.Millisecond
+	}
+	return &reopeningFile{
+		opts:          opts,
+		checkInterval: interval,
+		now:           time.Now,
+	}
+}
+
+// init starts listening for the reopen signal, if any.
+func (r *reopeningFile) init() error {
+	if r.opts.ReopenSignal == "" {
+		return nil
+	}
+	sig, err := parseReopenSignal(r.opts.ReopenSignal)
+	if err != nil {
+		return err
+	}
+
+	r.sigCh = make(chan os.Signal, 1)
+	r.quit = make(chan struct{})
+	r.done = make(chan struct{})
+	signal.Notify(r.sigCh, sig)
+
+	go func() {
+		defer close(r.done)
+		for {
+			select {
+			case <-r.sigCh:
+				// on failure the file is left closed; the next Write retries and
+				// returns the error.
+				_ = r.Reopen()
+			case <-r.quit:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// Write writes p to the file, reopening it first if it was moved, removed or truncated.
+func (r *reopeningFile) Write(p []byte) (int, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if r.file == nil {
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	} else if r.checkInterval > 0 && r.now().Sub(r.lastCheck) >= r.checkInterval {
+		if r.changed() {
+			if err := r.reopen(); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Reopen closes the file and opens it again at its path.
+func (r *reopeningFile) Reopen() error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if r.closed {
+		return nil
+	}
+	return r.reopen()
+}
+
+// Close stops listening for signals and closes the file.
+func (r *reopeningFile) Close() error {
+	if r.sigCh != nil {
+		signal.Stop(r.sigCh)
+		close(r.quit)
+		<-r.done
+		r.sigCh = nil
+	}
+
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.closed = true
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+func (r *reopeningFile) reopen() error {
+	if r.file != nil {
+		_ = r.file.Close()
+		r.file = nil
+	}
+	return r.open()
+}
+
+func (r *reopeningFile) open() error {
+	f, err := openLogFile(r.opts.Filename, r.opts)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("cannot stat log file: %w", err)
+	}
+	r.file = f
+	r.info = fi
+	r.size = fi.Size()
+	r.lastCheck = r.now()
+	return nil
+}
+
+// changed returns true if the path no longer refers to the open file, e.g. after being
+// renamed or removed, or if the file was truncated, e.g. by logrotate's copytruncate.
+func (r *reopeningFile) changed() bool {
+	r.lastCheck = r.now()
+
+	fi, err := os.Stat(r.opts.Filename)
+	if err != nil {
+		return true
+	}
+	return !os.SameFile(fi, r.info) || fi.Size() < r.size
+}
@@ -0,0 +1,25 @@
+//go:build !windows && !nacl && !plan9
+// +build !windows,!nacl,!plan9
+
+package targets
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+)
+
+// parseReopenSignal returns the signal named "SIGHUP" or "SIGUSR1", with or without the
+// "SIG" prefix.
+func parseReopenSignal(name string) (os.Signal, error) {
+	switch strings.TrimPrefix(strings.ToUpper(name), "SIG") {
+	case "HUP":
+		return syscall.SIGHUP, nil
+	case "USR1":
+		return syscall.SIGUSR1, nil
+	case "USR2":
+		return syscall.SIGUSR2, nil
+	}
+	return nil, fmt.Errorf("unsupported reopen signal '%s'", name)
+}
@@ -0,0 +1,43 @@
+//go:build !windows && !nacl && !plan9
+// +build !windows,!nacl,!plan9
+
+package targets
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReopeningFileSignal(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	opts := FileOptions{Filename: path, ExternalRotation: true, ReopenSignal: "SIGUSR1", ReopenCheckMillis: -1}
+	require.NoError(t, opts.CheckValid())
+
+	target := NewFileTarget(opts)
+	require.NoError(t, target.Init())
+	_, err := target.Write([]byte("one\n"), nil)
+	require.NoError(t, err)
+
+	require.NoError(t, os.Rename(path, path+".1"))
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGUSR1))
+
+	// the reopened file is created immediately.
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	_, err = target.Write([]byte("two\n"), nil)
+	require.NoError(t, err)
+	require.NoError(t, target.Shutdown())
+
+	assert.Equal(t, "one\n", readFile(t, path+".1"))
+	assert.Equal(t, "two\n", readFile(t, path))
+}
@@ -0,0 +1,14 @@
+//go:build windows || nacl || plan9
+// +build windows nacl plan9
+
+package targets
+
+import (
+	"errors"
+	"os"
+)
+
+// parseReopenSignal returns an error since reopen signals are not supported on this platform.
+func parseReopenSignal(name string) (os.Signal, error) {
+	return nil, errors.New("reopen signals are not supported on this platform")
+}
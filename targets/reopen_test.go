@@ -0,0 +1,147 @@
+package targets
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr/v2"
+	"github.com/mattermost/logr/v2/formatters"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReopeningFileRenamed(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	opts := FileOptions{Filename: path, ExternalRotation: true}
+	require.NoError(t, opts.CheckValid())
+
+	r, clock := newTestReopeningFile(opts)
+	writeString(t, r, "one\n")
+
+	// logrotate's default "create" mode renames the file.
+	require.NoError(t, os.Rename(path, path+".1"))
+	writeString(t, r, "two\n")
+
+	clock.set(clock.now().Add(DefaultReopenCheckMillis * time.Millisecond))
+	writeString(t, r, "three\n")
+	require.NoError(t, r.Close())
+
+	assert.Equal(t, "one\ntwo\n", readFile(t, path+".1"))
+	assert.Equal(t, "three\n", readFile(t, path))
+}
+
+func TestReopeningFileTruncated(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	opts := FileOptions{Filename: path, ExternalRotation: true, ReopenCheckMillis: 10}
+
+	r, clock := newTestReopeningFile(opts)
+	writeString(t, r, "one\n")
+
+	// logrotate's "copytruncate" mode copies then truncates the file.
+	require.NoError(t, os.Truncate(path, 0))
+	assert.True(t, r.changed())
+
+	clock.set(clock.now().Add(time.Second))
+	writeString(t, r, "two\n")
+	require.NoError(t, r.Close())
+
+	assert.Equal(t, "two\n", readFile(t, path))
+}
+
+func TestLogrReopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	lgr, err := logr.New()
+	require.NoError(t, err)
+
+	opts := FileOptions{Filename: path, ExternalRotation: true, ReopenCheckMillis: -1}
+	require.NoError(t, opts.CheckValid())
+	target := NewFileTarget(opts)
+	formatter := &formatters.Plain{DisableTimestamp: true, DisableLevel: true}
+	err = lgr.AddTarget(target, "file", &logr.StdFilter{Lvl: logr.Info}, formatter, 100)
+	require.NoError(t, err)
+
+	logger := lgr.NewLogger()
+	logger.Info("before")
+	require.NoError(t, lgr.Flush())
+
+	require.NoError(t, os.Rename(path, path+".1"))
+	logger.Info("still old")
+	require.NoError(t, lgr.Flush())
+
+	require.NoError(t, lgr.Reopen())
+	logger.Info("after")
+	require.NoError(t, lgr.Shutdown())
+
+	assert.Equal(t, "before \nstill old \n", readFile(t, path+".1"))
+	assert.Equal(t, "after \n", readFile(t, path))
+}
+
+func TestFileMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file mode and ownership are not supported on windows")
+	}
+	dir := t.TempDir()
+	owner := strconv.Itoa(os.Getuid())
+	group := strconv.Itoa(os.Getgid())
+
+	tests := map[string]FileOptions{
+		"external": {Filename: filepath.Join(dir, "external.log"), ExternalRotation: true},
+		"pattern":  {FilenamePattern: filepath.Join(dir, "pattern-%Y%m%d.log")},
+		"size":     {Filename: filepath.Join(dir, "size.log")},
+	}
+	for name, opts := range tests {
+		t.Run(name, func(t *testing.T) {
+			opts.FileMode = "0640"
+			opts.Owner = owner
+			opts.Group = group
+			require.NoError(t, opts.CheckValid())
+
+			target := NewFileTarget(opts)
+			require.NoError(t, target.Init())
+			_, err := target.Write([]byte("hello\n"), nil)
+			require.NoError(t, err)
+			require.NoError(t, target.Shutdown())
+
+			matches, err := filepath.Glob(filepath.Join(dir, name+"*.log"))
+			require.NoError(t, err)
+			require.Len(t, matches, 1)
+			fi, err := os.Stat(matches[0])
+			require.NoError(t, err)
+			assert.Equal(t, os.FileMode(0640), fi.Mode().Perm())
+		})
+	}
+}
+
+func TestFileOptionsCheckValidExternal(t *testing.T) {
+	tests := map[string]FileOptions{
+		"missing filename":  {ExternalRotation: true},
+		"with pattern":      {Filename: "app.log", ExternalRotation: true, FilenamePattern: "app-%Y%m%d.log"},
+		"invalid signal":    {Filename: "app.log", ExternalRotation: true, ReopenSignal: "SIGKILL"},
+		"signal w/o extern": {Filename: "app.log", ReopenSignal: "SIGHUP"},
+		"invalid mode":      {Filename: "app.log", FileMode: "0999"},
+		"mode too large":    {Filename: "app.log", FileMode: "10644"},
+		"unknown owner":     {Filename: "app.log", Owner: "no-such-user-logr"},
+		"unknown group":     {Filename: "app.log", Group: "no-such-group-logr"},
+	}
+	for name, opts := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Error(t, opts.CheckValid())
+		})
+	}
+}
+
+func newTestReopeningFile(opts FileOptions) (*reopeningFile, *testClock) {
+	clock := &testClock{}
+	clock.set(time.Now())
+	r := newReopeningFile(opts)
+	r.now = clock.now
+	return r, clock
+}
@@ -0,0 +1,332 @@
+package targets
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mattermost/logr/v2"
+	"github.com/mattermost/logr/v2/formatters"
+)
+
+const (
+	// DefaultRingMaxRecords is the number of records retained when neither MaxRecords
+	// nor MaxBytes are specified.
+	DefaultRingMaxRecords = 10000
+
+	RingExportJSON  = "json"
+	RingExportPlain = "plain"
+)
+
+// RingOptions provides parameters for an in-memory ring buffer target.
+type RingOptions struct {
+	// MaxRecords is the maximum number of log records retained. Zero means no limit
+	// unless MaxBytes is also zero, in which case DefaultRingMaxRecords is used.
+	MaxRecords int `json:"max_records"`
+
+	// MaxBytes is the maximum total size of log records retained, measured as their
+	// formatted size. Zero means no limit. The newest record is always retained.
+	MaxBytes int64 `json:"max_bytes"`
+}
+
+// CheckValid returns an error if the RingOptions are invalid.
+func (ro RingOptions) CheckValid() error {
+	if ro.MaxRecords < 0 {
+		return fmt.Errorf("max_records is invalid(%d)", ro.MaxRecords)
+	}
+	if ro.MaxBytes < 0 {
+		return fmt.Errorf("max_bytes is invalid(%d)", ro.MaxBytes)
+	}
+	return nil
+}
+
+// RingRecord is a log record retained by a Ring.
+type RingRecord struct {
+	// Seq is the sequence number of the record, starting at 1 and increasing by one
+	// for each record written to the Ring. Use it as a cursor for pagination.
+	Seq uint64
+
+	Rec *logr.LogRec
+
+	size int
+}
+
+// RingQuery selects log records retained by a Ring. The zero value selects all records.
+type RingQuery struct {
+	// FromLevel and ToLevel, when not nil, select records whose level ID is within the
+	// inclusive range. Level IDs increase with verbosity, so `logr.Panic` to `logr.Error`
+	// selects errors and worse.
+	FromLevel *logr.Level
+	ToLevel   *logr.Level
+
+	// Since and Until, when not zero, select records logged at or after Since and
+	// before Until.
+	Since time.Time
+	Until time.Time
+
+	// Contains, when not empty, selects records whose message contains the substring.
+	Contains string
+
+	// IgnoreCase makes Contains case-insensitive.
+	IgnoreCase bool
+
+	// Fields selects records containing a field for each key whose value, formatted
+	// as a string, equals the value.
+	Fields map[string]string
+
+	// Matcher, when not nil, selects records it matches.
+	Matcher logr.RecordMatcher
+
+	// After and Before, when not zero, select records with a sequence number greater
+	// than After and less than Before. Pass the `Seq` of the last record of a page
+	// to fetch the next page.
+	After  uint64
+	Before uint64
+
+	// Limit is the maximum number of records returned. Zero means no limit.
+	Limit int
+
+	// Reverse returns the newest records first. Combined with Limit, the newest
+	// matching records are returned.
+	Reverse bool
+}
+
+// RingResult contains the records selected by a RingQuery.
+type RingResult struct {
+	Records []RingRecord
+
+	// More is true if more records matched than Limit allowed.
+	More bool
+}
+
+// Ring is a target that retains the most recent log records in memory, up to a
+// maximum number of records or bytes, so they can be queried from a running process.
+// Records are retained as structured `LogRec`s rather than formatted bytes, so a
+// redacting formatter does not apply to them; use `SetRedactor` to retain redacted
+// records instead.
+type Ring struct {
+	maxRecords int
+	maxBytes   int64
+	redactor   atomic.Pointer[logr.Redactor]
+
+	mux     sync.RWMutex
+	records []RingRecord // oldest first.
+	size    int64
+	seq     uint64
+}
+
+// NewRingTarget creates a target that retains recent log records in memory.
+func NewRingTarget(opts RingOptions) *Ring {
+	maxRecords := opts.MaxRecords
+	if maxRecords == 0 && opts.MaxBytes == 0 {
+		maxRecords = DefaultRingMaxRecords
+	}
+	return &Ring{
+		maxRecords: maxRecords,
+		maxBytes:   opts.MaxBytes,
+	}
+}
+
+// Init is called once to initialize the target.
+func (r *Ring) Init() error {
+	return nil
+}
+
+// SetRedactor sets a Redactor applied to each record before it is retained, so queries
+// and exports never return the original values. Records already retained are unchanged.
+func (r *Ring) SetRedactor(redactor *logr.Redactor) {
+	r.redactor.Store(redactor)
+}
+
+// Write retains the log record, discarding the oldest records as needed.
+func (r *Ring) Write(p []byte, rec *logr.LogRec) (int, error) {
+	if rec == nil {
+		return 0, errors.New("ring target requires a log record")
+	}
+	if redactor := r.redactor.Load(); redactor != nil {
+		rec = redactor.Redact(rec)
+	}
+
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	r.seq++
+	r.records = append(r.records, RingRecord{Seq: r.seq, Rec: rec, size: len(p)})
+	r.size += int64(len(p))
+
+	for len(r.records) > 1 &&
+		((r.maxRecords > 0 && len(r.records) > r.maxRecords) || (r.maxBytes > 0 && r.size > r.maxBytes)) {
+		r.size -= int64(r.records[0].size)
+		r.records[0] = RingRecord{} // release the record for garbage collection.
+		r.records = r.records[1:]
+	}
+	return len(p), nil
+}
+
+// Shutdown is called once to free/close any resources.
+// Retained records remain available for queries.
+func (r *Ring) Shutdown() error {
+	return nil
+}
+
+// Len returns the number of records retained and their total formatted size.
+func (r *Ring) Len() (count int, size int64) {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	return len(r.records), r.size
+}
+
+// Clear discards all retained records. Sequence numbers are not reset.
+func (r *Ring) Clear() {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.records = nil
+	r.size = 0
+}
+
+// Query returns the retained records selected by q, oldest first unless q.Reverse is set.
+func (r *Ring) Query(q RingQuery) RingResult {
+	matcher := q.matcher()
+
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+
+	var res RingResult
+	n := len(r.records)
+	for i := 0; i < n; i++ {
+		rr := r.records[i]
+		if q.Reverse {
+			rr = r.records[n-1-i]
+		}
+		if !q.inRange(rr.Seq) {
+			continue
+		}
+		if !matcher(rr.Rec) {
+			continue
+		}
+		if q.Limit > 0 && len(res.Records) == q.Limit {
+			res.More = true
+			break
+		}
+		res.Records = append(res.Records, rr)
+	}
+	return res
+}
+
+func (q RingQuery) inRange(seq uint64) bool {
+	return (q.After == 0 || seq > q.After) && (q.Before == 0 || seq < q.Before)
+}
+
+// matcher returns a function that matches the selection criteria of q other than
+// sequence numbers.
+func (q RingQuery) matcher() func(rec *logr.LogRec) bool {
+	contains := q.Contains
+	if q.IgnoreCase {
+		contains = strings.ToLower(contains)
+	}
+
+	fields := make([]logr.RecordMatcher, 0, len(q.Fields))
+	for key, val := range q.Fields {
+		fields = append(fields, logr.FieldEquals(key, val))
+	}
+
+	return func(rec *logr.LogRec) bool {
+		id := rec.Level().ID
+		if q.FromLevel != nil && id < q.FromLevel.ID {
+			return false
+		}
+		if q.ToLevel != nil && id > q.ToLevel.ID {
+			return false
+		}
+
+		t := rec.Time()
+		if !q.Since.IsZero() && t.Before(q.Since) {
+			return false
+		}
+		if !q.Until.IsZero() && !t.Before(q.Until) {
+			return false
+		}
+
+		if contains != "" {
+			msg := rec.Msg()
+			if q.IgnoreCase {
+				msg = strings.ToLower(msg)
+			}
+			if !strings.Contains(msg, contains) {
+				return false
+			}
+		}
+
+		for _, m := range fields {
+			if !m.Match(rec) {
+				return false
+			}
+		}
+		return q.Matcher == nil || q.Matcher.Match(rec)
+	}
+}
+
+// Export writes the records to w in the specified format. "json" writes a JSON array
+// of records formatted by `formatters.JSON`; "plain" writes one line per record
+// formatted by `formatters.Plain`.
+func (res RingResult) Export(w io.Writer, format string) error {
+	switch format {
+	case RingExportJSON:
+		if len(res.Records) == 0 {
+			_, err := io.WriteString(w, "[]\n")
+			return err
+		}
+		return res.export(w, &formatters.JSON{}, []byte("[\n"), []byte(",\n"), []byte("\n]\n"))
+	case RingExportPlain:
+		return res.export(w, &formatters.Plain{}, nil, nil, nil)
+	}
+	return fmt.Errorf("invalid export format '%s'", format)
+}
+
+// ExportWithFormatter writes the records to w using formatter, one after another.
+func (res RingResult) ExportWithFormatter(w io.Writer, formatter logr.Formatter) error {
+	return res.export(w, formatter, nil, nil, nil)
+}
+
+// export writes the records to w using formatter. prefix and suffix are written before
+// and after the records, and sep between records. Trailing newlines output by the
+// formatter are removed when sep or suffix are provided.
+func (res RingResult) export(w io.Writer, formatter logr.Formatter, prefix, sep, suffix []byte) error {
+	trim := sep != nil || suffix != nil
+	buf := &bytes.Buffer{}
+	buf.Write(prefix)
+
+	for i, rr := range res.Records {
+		if i > 0 {
+			buf.Write(sep)
+		}
+		// output a stack trace if one was captured when the record was logged.
+		level := rr.Rec.Level()
+		level.Stacktrace = len(rr.Rec.StackFrames()) > 0
+
+		start := buf.Len()
+		if _, err := formatter.Format(rr.Rec, level, buf); err != nil {
+			return fmt.Errorf("cannot format record %d: %w", rr.Seq, err)
+		}
+		if trim {
+			buf.Truncate(start + len(bytes.TrimRight(buf.Bytes()[start:], "\n")))
+		}
+
+		// keep memory bounded when exporting many records.
+		if buf.Len() > 64*1024 {
+			if _, err := w.Write(buf.Bytes()); err != nil {
+				return err
+			}
+			buf.Reset()
+		}
+	}
+
+	buf.Write(suffix)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,210 @@
+package targets_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr/v2"
+	"github.com/mattermost/logr/v2/formatters"
+	"github.com/mattermost/logr/v2/targets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRingMaxRecords(t *testing.T) {
+	ring := targets.NewRingTarget(targets.RingOptions{MaxRecords: 3})
+	lgr := newRingLogr(t, ring)
+	logger := lgr.NewLogger()
+
+	for i := 1; i <= 5; i++ {
+		logger.Info("msg", logr.Int("i", i))
+	}
+	require.NoError(t, lgr.Shutdown())
+
+	res := ring.Query(targets.RingQuery{})
+	require.Len(t, res.Records, 3)
+	assert.Equal(t, []uint64{3, 4, 5}, ringSeqs(res))
+	assert.False(t, res.More)
+
+	count, _ := ring.Len()
+	assert.Equal(t, 3, count)
+
+	assert.Same(t, ring, lgr.Target("ring"))
+	assert.Nil(t, lgr.Target("missing"))
+}
+
+func TestRingMaxBytes(t *testing.T) {
+	ring := targets.NewRingTarget(targets.RingOptions{MaxBytes: 25})
+	lgr := newRingLogr(t, ring)
+	logger := lgr.NewLogger()
+
+	logger.Info("0123456789") // 12 bytes with the trailing delimiter and newline.
+	logger.Info("abcdefghij")
+	logger.Info("ABCDEFGHIJ")
+	require.NoError(t, lgr.Flush())
+
+	count, size := ring.Len()
+	assert.Equal(t, 2, count)
+	assert.EqualValues(t, 24, size)
+
+	// the newest record is always retained.
+	logger.Info(strings.Repeat("x", 100))
+	require.NoError(t, lgr.Shutdown())
+
+	res := ring.Query(targets.RingQuery{})
+	require.Len(t, res.Records, 1)
+	assert.Equal(t, uint64(4), res.Records[0].Seq)
+
+	ring.Clear()
+	count, size = ring.Len()
+	assert.Zero(t, count)
+	assert.Zero(t, size)
+}
+
+func TestRingQuery(t *testing.T) {
+	ring := targets.NewRingTarget(targets.RingOptions{})
+	lgr := newRingLogr(t, ring)
+	logger := lgr.NewLogger()
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	logAt := func(minute int, lvl logr.Level, msg string, fields ...logr.Field) {
+		logger.LogWithCallers(start.Add(time.Duration(minute)*time.Minute), lvl, msg, nil, fields...)
+	}
+	logAt(0, logr.Info, "server started")
+	logAt(1, logr.Error, "Login failed", logr.String("user", "sarah"))
+	logAt(2, logr.Debug, "cache miss", logr.String("user", "sarah"))
+	logAt(3, logr.Warn, "slow query", logr.Int("ms", 1500))
+	logAt(4, logr.Error, "login failed", logr.String("user", "wiggin"))
+	logAt(5, logr.Error, "out of disk")
+	require.NoError(t, lgr.Shutdown())
+
+	tests := map[string]struct {
+		query targets.RingQuery
+		want  []uint64
+		more  bool
+	}{
+		"all":           {query: targets.RingQuery{}, want: []uint64{1, 2, 3, 4, 5, 6}},
+		"errors+":       {query: targets.RingQuery{FromLevel: &logr.Panic, ToLevel: &logr.Error}, want: []uint64{2, 5, 6}},
+		"warn to debug": {query: targets.RingQuery{FromLevel: &logr.Warn, ToLevel: &logr.Debug}, want: []uint64{1, 3, 4}},
+		"since":         {query: targets.RingQuery{Since: start.Add(4 * time.Minute)}, want: []uint64{5, 6}},
+		"until":         {query: targets.RingQuery{Until: start.Add(2 * time.Minute)}, want: []uint64{1, 2}},
+		"contains":      {query: targets.RingQuery{Contains: "login"}, want: []uint64{5}},
+		"ignore case":   {query: targets.RingQuery{Contains: "LOGIN", IgnoreCase: true}, want: []uint64{2, 5}},
+		"field":         {query: targets.RingQuery{Fields: map[string]string{"user": "sarah"}}, want: []uint64{2, 3}},
+		"field int":     {query: targets.RingQuery{Fields: map[string]string{"ms": "1500"}}, want: []uint64{4}},
+		"fields+level":  {query: targets.RingQuery{ToLevel: &logr.Error, Fields: map[string]string{"user": "sarah"}}, want: []uint64{2}},
+		"matcher":       {query: targets.RingQuery{Matcher: logr.FieldExists("ms")}, want: []uint64{4}},
+		"limit":         {query: targets.RingQuery{Limit: 2}, want: []uint64{1, 2}, more: true},
+		"limit exact":   {query: targets.RingQuery{Limit: 6}, want: []uint64{1, 2, 3, 4, 5, 6}},
+		"reverse":       {query: targets.RingQuery{Limit: 2, Reverse: true}, want: []uint64{6, 5}, more: true},
+		"after":         {query: targets.RingQuery{After: 2, Limit: 2}, want: []uint64{3, 4}, more: true},
+		"before":        {query: targets.RingQuery{Before: 5, Limit: 2, Reverse: true}, want: []uint64{4, 3}, more: true},
+		"no match":      {query: targets.RingQuery{Contains: "nothing"}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			res := ring.Query(tt.query)
+			assert.Equal(t, tt.want, ringSeqs(res))
+			assert.Equal(t, tt.more, res.More)
+		})
+	}
+}
+
+func TestRingExport(t *testing.T) {
+	ring := targets.NewRingTarget(targets.RingOptions{})
+	lgr := newRingLogr(t, ring)
+	logger := lgr.NewLogger()
+
+	logger.Info("first", logr.String("k", "v"))
+	logger.Error("second")
+	require.NoError(t, lgr.Shutdown())
+
+	res := ring.Query(targets.RingQuery{})
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, res.Export(buf, targets.RingExportJSON))
+	var docs []map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &docs), buf.String())
+	require.Len(t, docs, 2)
+	assert.Equal(t, "first", docs[0]["msg"])
+	assert.Equal(t, "v", docs[0]["k"])
+	assert.Equal(t, "error", docs[1]["level"])
+
+	buf.Reset()
+	require.NoError(t, res.Export(buf, targets.RingExportPlain))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], "first")
+	assert.Contains(t, lines[0], "k=v")
+	assert.Contains(t, lines[1], "second")
+
+	buf.Reset()
+	require.NoError(t, targets.RingResult{}.Export(buf, targets.RingExportJSON))
+	assert.Equal(t, "[]\n", buf.String())
+
+	assert.Error(t, res.Export(buf, "xml"))
+}
+
+func TestRingRedactor(t *testing.T) {
+	redactor, err := logr.NewRedactor(logr.RedactOptions{
+		MaskKeys:  []string{"*password*"},
+		DropKeys:  []string{"token"},
+		Scrubbers: []logr.Scrubber{logr.ScrubEmails},
+	})
+	require.NoError(t, err)
+
+	ring := targets.NewRingTarget(targets.RingOptions{})
+	ring.SetRedactor(redactor)
+	lgr := newRingLogr(t, ring)
+
+	lgr.NewLogger().Info("reset for sarah@example.com",
+		logr.String("password", "hunter2"),
+		logr.String("token", "abc123"),
+		logr.String("user", "sarah"),
+	)
+	require.NoError(t, lgr.Shutdown())
+
+	res := ring.Query(targets.RingQuery{})
+	require.Len(t, res.Records, 1)
+	assert.NotContains(t, res.Records[0].Rec.Msg(), "sarah@example.com")
+
+	// queries see the redacted record, so exports do too.
+	buf := &bytes.Buffer{}
+	require.NoError(t, res.Export(buf, targets.RingExportJSON))
+	out := buf.String()
+	assert.NotContains(t, out, "hunter2")
+	assert.NotContains(t, out, "abc123")
+	assert.NotContains(t, out, "sarah@example.com")
+	assert.Contains(t, out, logr.RedactedValue)
+	assert.Contains(t, out, `"user":"sarah"`)
+
+	assert.Empty(t, ring.Query(targets.RingQuery{Fields: map[string]string{"password": "hunter2"}}).Records)
+}
+
+func TestRingOptionsCheckValid(t *testing.T) {
+	assert.NoError(t, targets.RingOptions{}.CheckValid())
+	assert.Error(t, targets.RingOptions{MaxRecords: -1}.CheckValid())
+	assert.Error(t, targets.RingOptions{MaxBytes: -1}.CheckValid())
+}
+
+func newRingLogr(t *testing.T, ring *targets.Ring) *logr.Logr {
+	t.Helper()
+
+	lgr, err := logr.New()
+	require.NoError(t, err)
+	filter := &logr.StdFilter{Lvl: logr.Trace}
+	formatter := &formatters.Plain{DisableTimestamp: true, DisableLevel: true, DisableFields: true}
+	require.NoError(t, lgr.AddTarget(ring, "ring", filter, formatter, 1000))
+	return lgr
+}
+
+func ringSeqs(res targets.RingResult) []uint64 {
+	var seqs []uint64
+	for _, rr := range res.Records {
+		seqs = append(seqs, rr.Seq)
+	}
+	return seqs
+}
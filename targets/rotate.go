@@ -0,0 +1,574 @@
+package targets
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	RotationDaily  = "daily"
+	RotationHourly = "hourly"
+
+	megabyte = 1024 * 1024
+)
+
+// RotateHook is called, in the background, with the path of a log file after it has
+// been rotated out, and after any compression. It returns the path of the file to be
+// considered for retention, which may differ if the hook renamed or converted the file,
+// or an empty string if the file was removed.
+type RotateHook func(path string) (string, error)
+
+// compression is a registered method of compressing rotated files.
+type compression struct {
+	ext       string
+	newWriter func(w io.Writer) (io.WriteCloser, error)
+}
+
+var (
+	compressionsMux sync.RWMutex
+	compressions    = map[string]compression{
+		"gzip": {ext: ".gz", newWriter: func(w io.Writer) (io.WriteCloser, error) {
+			return gzip.NewWriter(w), nil
+		}},
+	}
+)
+
+// RegisterCompression registers a compression method that can be used to compress rotated
+// files via the `compression` file option. `ext` is appended to compressed filenames.
+// For example, to enable zstd using github.com/klauspost/compress/zstd:
+//
+//	targets.RegisterCompression("zstd", ".zst", func(w io.Writer) (io.WriteCloser, error) {
+//		return zstd.NewWriter(w)
+//	})
+func RegisterCompression(name string, ext string, newWriter func(w io.Writer) (io.WriteCloser, error)) {
+	compressionsMux.Lock()
+	defer compressionsMux.Unlock()
+	compressions[name] = compression{ext: ext, newWriter: newWriter}
+}
+
+func getCompression(name string) (compression, bool) {
+	compressionsMux.RLock()
+	defer compressionsMux.RUnlock()
+	c, ok := compressions[name]
+	return c, ok
+}
+
+// CompressHook returns a RotateHook that compresses rotated files using a registered
+// compression method, removing the uncompressed file.
+func CompressHook(name string) (RotateHook, error) {
+	c, ok := getCompression(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown compression '%s'", name)
+	}
+	return func(path string) (string, error) {
+		return compressFile(path, c)
+	}, nil
+}
+
+func compressFile(path string, c compression) (string, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return path, err
+	}
+	defer src.Close()
+
+	fi, err := src.Stat()
+	if err != nil {
+		return path, err
+	}
+
+	dstPath := path + c.ext
+	tmpPath := dstPath + ".tmp"
+	dst, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, fi.Mode())
+	if err != nil {
+		return path, err
+	}
+
+	err = func() error {
+		w, err := c.newWriter(dst)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(w, src); err != nil {
+			return err
+		}
+		if err := w.Close(); err != nil {
+			return err
+		}
+		return dst.Close()
+	}()
+	if err != nil {
+		dst.Close()
+		os.Remove(tmpPath)
+		return path, fmt.Errorf("cannot compress %s: %w", path, err)
+	}
+
+	// keep the modification time so retention still sees the file's age.
+	_ = os.Chtimes(tmpPath, fi.ModTime(), fi.ModTime())
+	if err := os.Rename(tmpPath, dstPath); err != nil {
+		os.Remove(tmpPath)
+		return path, err
+	}
+	if err := os.Remove(path); err != nil {
+		return dstPath, err
+	}
+	return dstPath, nil
+}
+
+// strftimeSpecs maps supported strftime conversion specifications to Go time layouts and
+// to regular expressions matching their output.
+var strftimeSpecs = map[byte]struct{ layout, rx string }{
+	'Y': {"2006", `\d{4}`},
+	'y': {"06", `\d{2}`},
+	'm': {"01", `\d{2}`},
+	'd': {"02", `\d{2}`},
+	'H': {"15", `\d{2}`},
+	'M': {"04", `\d{2}`},
+	'S': {"05", `\d{2}`},
+	'j': {"002", `\d{3}`},
+	'b': {"Jan", `[A-Za-z]{3}`},
+	'a': {"Mon", `[A-Za-z]{3}`},
+}
+
+// checkPattern validates a strftime filename pattern for the rotation schedule.
+func checkPattern(pattern string, rotation string) error {
+	dir, base := filepath.Split(pattern)
+	if strings.Contains(dir, "%") {
+		return errors.New("filename_pattern cannot contain conversion specifications in the directory")
+	}
+	used := make(map[byte]bool)
+	for i := 0; i < len(base); i++ {
+		if base[i] != '%' {
+			continue
+		}
+		if i == len(base)-1 {
+			return errors.New("filename_pattern ends with '%'")
+		}
+		i++
+		if _, ok := strftimeSpecs[base[i]]; !ok && base[i] != '%' {
+			return fmt.Errorf("unsupported conversion specification '%%%c' in filename_pattern", base[i])
+		}
+		used[base[i]] = true
+	}
+
+	day := used['d'] || used['j']
+	switch rotation {
+	case RotationDaily, "":
+		if !day {
+			return errors.New("daily rotation requires %d or %j in filename_pattern")
+		}
+	case RotationHourly:
+		if !day || !used['H'] {
+			return errors.New("hourly rotation requires %H and %d or %j in filename_pattern")
+		}
+	default:
+		return fmt.Errorf("invalid rotation '%s'", rotation)
+	}
+	return nil
+}
+
+// strftime expands the conversion specifications in pattern using t.
+func strftime(pattern string, t time.Time) string {
+	var sb strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		if c != '%' || i == len(pattern)-1 {
+			sb.WriteByte(c)
+			continue
+		}
+		i++
+		if spec, ok := strftimeSpecs[pattern[i]]; ok {
+			sb.WriteString(t.Format(spec.layout))
+		} else {
+			sb.WriteByte(pattern[i])
+		}
+	}
+	return sb.String()
+}
+
+// patternRegexp returns a regular expression matching the base names of all files
+// produced from the filename pattern, including sequence numbers and compression
+// extensions.
+func patternRegexp(pattern string) *regexp.Regexp {
+	base := filepath.Base(pattern)
+	ext := filepath.Ext(base)
+	if strings.Contains(ext, "%") {
+		ext = ""
+	}
+	stem := strings.TrimSuffix(base, ext)
+
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i := 0; i < len(stem); i++ {
+		c := stem[i]
+		if c == '%' && i < len(stem)-1 {
+			i++
+			if spec, ok := strftimeSpecs[stem[i]]; ok {
+				sb.WriteString(spec.rx)
+				continue
+			}
+			c = stem[i]
+		}
+		sb.WriteString(regexp.QuoteMeta(string(c)))
+	}
+	sb.WriteString(`(\.\d+)?`)
+	sb.WriteString(regexp.QuoteMeta(ext))
+	sb.WriteString(`(\.[A-Za-z0-9]+)?$`)
+	return regexp.MustCompile(sb.String())
+}
+
+// seqName returns the filename for the nth file within a rotation period, inserting the
+// sequence number before the extension, e.g. "app-20240101.2.log".
+func seqName(name string, seq int) string {
+	if seq == 0 {
+		return name
+	}
+	ext := filepath.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + strconv.Itoa(seq) + ext
+}
+
+// rotatingFile is an io.WriteCloser that writes to files named by a strftime pattern,
+// rotating on a daily or hourly schedule and optionally by size.
+type rotatingFile struct {
+	opts  FileOptions
+	hooks []RotateHook
+	rx    *regexp.Regexp
+	now   func() time.Time
+	err   error // from creating the compression hook; returned by `File.Init`.
+
+	file   *os.File
+	name   string
+	period time.Time
+	seq    int
+	size   int64
+
+	millCh   chan millReq
+	millDone chan struct{}
+
+	errMux  sync.Mutex
+	millErr error
+}
+
+func newRotatingFile(opts FileOptions) *rotatingFile {
+	var hooks []RotateHook
+	var hookErr error
+	compressionName := opts.Compression
+	if compressionName == "" && opts.Compress {
+		compressionName = "gzip"
+	}
+	if compressionName != "" {
+		var hook RotateHook
+		if hook, hookErr = CompressHook(compressionName); hookErr == nil {
+			hooks = append(hooks, hook)
+		}
+	}
+	hooks = append(hooks, opts.RotateHooks...)
+
+	return &rotatingFile{
+		opts:  opts,
+		hooks: hooks,
+		rx:    patternRegexp(opts.FilenamePattern),
+		now:   time.Now,
+		err:   hookErr,
+	}
+}
+
+// Write writes p to the current file, rotating first if the period has ended or the
+// file would exceed MaxSize. Errors from post-rotation processing are not returned;
+// see takeMillErr.
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	period := r.periodStart(r.now())
+
+	switch {
+	case r.file == nil:
+		if err := r.open(period); err != nil {
+			return 0, err
+		}
+	case !period.Equal(r.period):
+		if err := r.rotate(period, 0); err != nil {
+			return 0, err
+		}
+	case r.opts.MaxSize > 0 && r.size > 0 && r.size+int64(len(p)) > int64(r.opts.MaxSize)*megabyte:
+		if err := r.rotate(period, r.seq+1); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// takeMillErr returns and clears the first error from symlink updates, compression,
+// retention or post-rotation hooks since the last call.
+func (r *rotatingFile) takeMillErr() error {
+	r.errMux.Lock()
+	defer r.errMux.Unlock()
+	err := r.millErr
+	r.millErr = nil
+	return err
+}
+
+// Close closes the current file and waits for post-rotation processing to complete.
+// Returns any post-rotation error not yet taken via takeMillErr.
+func (r *rotatingFile) Close() error {
+	var err error
+	if r.file != nil {
+		err = r.file.Close()
+		r.file = nil
+	}
+	if r.millCh != nil {
+		close(r.millCh)
+		<-r.millDone
+		r.millCh = nil
+	}
+	if err == nil {
+		err = r.takeMillErr()
+	}
+	return err
+}
+
+// periodStart returns the start of the rotation period containing t.
+func (r *rotatingFile) periodStart(t time.Time) time.Time {
+	if r.opts.UTC {
+		t = t.UTC()
+	}
+	hour := 0
+	if r.opts.Rotation == RotationHourly {
+		hour = t.Hour()
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), hour, 0, 0, 0, t.Location())
+}
+
+// open opens the file for the period, appending to an existing file unless it has
+// already reached MaxSize.
+func (r *rotatingFile) open(period time.Time) error {
+	name := strftime(r.opts.FilenamePattern, period)
+
+	seq := 0
+	for ; ; seq++ {
+		fi, err := os.Stat(seqName(name, seq))
+		if err != nil {
+			if r.compressedExists(seqName(name, seq)) {
+				continue
+			}
+			break
+		}
+		if r.opts.MaxSize <= 0 || fi.Size() < int64(r.opts.MaxSize)*megabyte {
+			break
+		}
+	}
+	if err := r.openSeq(period, name, seq); err != nil {
+		return err
+	}
+
+	// apply retention to files left by previous runs.
+	r.mill("")
+	return nil
+}
+
+func (r *rotatingFile) openSeq(period time.Time, name string, seq int) error {
+	path := seqName(name, seq)
+	f, err := openLogFile(path, r.opts)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("cannot stat log file: %w", err)
+	}
+
+	r.file = f
+	r.name = name
+	r.period = period
+	r.seq = seq
+	r.size = fi.Size()
+
+	if r.opts.Symlink != "" {
+		if err := r.updateSymlink(path); err != nil {
+			r.setMillErr(err)
+		}
+	}
+	return nil
+}
+
+// rotate closes the current file, hands it off for post-rotation processing, and opens
+// the next file.
+func (r *rotatingFile) rotate(period time.Time, seq int) error {
+	prev := r.file.Name()
+	if err := r.file.Close(); err != nil {
+		r.setMillErr(err)
+	}
+	r.file = nil
+
+	name := r.name
+	if !period.Equal(r.period) {
+		name = strftime(r.opts.FilenamePattern, period)
+	}
+	if err := r.openSeq(period, name, seq); err != nil {
+		return err
+	}
+	if prev != r.file.Name() {
+		r.mill(prev)
+	}
+	return nil
+}
+
+func (r *rotatingFile) compressedExists(path string) bool {
+	matches, _ := filepath.Glob(path + ".*")
+	return len(matches) > 0
+}
+
+// updateSymlink atomically points the symlink at the current file.
+func (r *rotatingFile) updateSymlink(path string) error {
+	target := path
+	if rel, err := filepath.Rel(filepath.Dir(r.opts.Symlink), path); err == nil {
+		target = rel
+	}
+	tmp := r.opts.Symlink + ".tmp"
+	_ = os.Remove(tmp)
+	if err := os.Symlink(target, tmp); err != nil {
+		return fmt.Errorf("cannot create symlink: %w", err)
+	}
+	if err := os.Rename(tmp, r.opts.Symlink); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("cannot update symlink: %w", err)
+	}
+	return nil
+}
+
+// millReq is a request for post-rotation processing of a rotated file.
+type millReq struct {
+	rotated string
+	current string
+}
+
+// mill queues a rotated file for post-rotation processing and retention. An empty path
+// applies retention only.
+func (r *rotatingFile) mill(path string) {
+	if r.millCh == nil {
+		r.millCh = make(chan millReq, 16)
+		r.millDone = make(chan struct{})
+		go r.millRun()
+	}
+	r.millCh <- millReq{rotated: path, current: r.file.Name()}
+}
+
+func (r *rotatingFile) millRun() {
+	defer close(r.millDone)
+	for req := range r.millCh {
+		if req.rotated != "" {
+			r.postRotate(req.rotated)
+		}
+		// wait until queued files have been processed before removing any.
+		if len(r.millCh) > 0 {
+			continue
+		}
+		if err := r.applyRetention(req.current); err != nil {
+			r.setMillErr(err)
+		}
+	}
+}
+
+func (r *rotatingFile) postRotate(path string) {
+	for _, hook := range r.hooks {
+		next, err := hook(path)
+		if err != nil {
+			r.setMillErr(fmt.Errorf("post-rotation hook failed for %s: %w", path, err))
+		}
+		if next == "" {
+			return
+		}
+		path = next
+	}
+}
+
+// applyRetention removes rotated files exceeding MaxBackups, MaxAge or MaxTotalSize,
+// oldest first.
+func (r *rotatingFile) applyRetention(current string) error {
+	if r.opts.MaxBackups <= 0 && r.opts.MaxAge <= 0 && r.opts.MaxTotalSize <= 0 {
+		return nil
+	}
+
+	backups, err := r.backups(current)
+	if err != nil {
+		return err
+	}
+
+	cutoff := r.now().Add(-time.Duration(r.opts.MaxAge) * 24 * time.Hour)
+	var total int64
+	var errs []string
+	for i, fi := range backups {
+		total += fi.Size()
+		remove := (r.opts.MaxBackups > 0 && i >= r.opts.MaxBackups) ||
+			(r.opts.MaxAge > 0 && fi.ModTime().Before(cutoff)) ||
+			(r.opts.MaxTotalSize > 0 && total > int64(r.opts.MaxTotalSize)*megabyte)
+		if !remove {
+			continue
+		}
+		path := filepath.Join(filepath.Dir(r.opts.FilenamePattern), fi.Name())
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("cannot remove old log files: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// backups returns the rotated files matching the filename pattern, newest first,
+// excluding the current file and the symlink.
+func (r *rotatingFile) backups(current string) ([]os.FileInfo, error) {
+	dir := filepath.Dir(r.opts.FilenamePattern)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read log directory: %w", err)
+	}
+
+	current = filepath.Base(current)
+	symlink := filepath.Base(r.opts.Symlink)
+	var backups []os.FileInfo
+	for _, entry := range entries {
+		name := entry.Name()
+		if name == current || (r.opts.Symlink != "" && name == symlink) || !r.rx.MatchString(name) {
+			continue
+		}
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		fi, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, fi)
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		ti, tj := backups[i].ModTime(), backups[j].ModTime()
+		if ti.Equal(tj) {
+			return backups[i].Name() > backups[j].Name()
+		}
+		return ti.After(tj)
+	})
+	return backups, nil
+}
+
+func (r *rotatingFile) setMillErr(err error) {
+	r.errMux.Lock()
+	defer r.errMux.Unlock()
+	if r.millErr == nil {
+		r.millErr = err
+	}
+}
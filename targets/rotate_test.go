@@ -0,0 +1,325 @@
+package targets
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr/v2"
+	"github.com/mattermost/logr/v2/formatters"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotatingFileDaily(t *testing.T) {
+	dir := t.TempDir()
+	opts := FileOptions{
+		FilenamePattern: filepath.Join(dir, "app-%Y%m%d.log"),
+		Symlink:         filepath.Join(dir, "current"),
+		UTC:             true,
+	}
+	require.NoError(t, opts.CheckValid())
+
+	r, clock := newTestRotatingFile(opts, time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC))
+	writeString(t, r, "one\n")
+	clock.set(time.Date(2024, 1, 1, 23, 59, 59, 0, time.UTC))
+	writeString(t, r, "two\n")
+	clock.set(time.Date(2024, 1, 2, 0, 0, 1, 0, time.UTC))
+	writeString(t, r, "three\n")
+
+	target, err := os.Readlink(opts.Symlink)
+	require.NoError(t, err)
+	assert.Equal(t, "app-20240102.log", target)
+
+	require.NoError(t, r.Close())
+
+	assert.Equal(t, []string{"app-20240101.log", "app-20240102.log", "current"}, listDir(t, dir))
+	assert.Equal(t, "one\ntwo\n", readFile(t, filepath.Join(dir, "app-20240101.log")))
+	assert.Equal(t, "three\n", readFile(t, filepath.Join(dir, "current")))
+
+	// a restart during the same period appends to the existing file.
+	r, _ = newTestRotatingFile(opts, time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC))
+	writeString(t, r, "four\n")
+	require.NoError(t, r.Close())
+	assert.Equal(t, "three\nfour\n", readFile(t, filepath.Join(dir, "app-20240102.log")))
+}
+
+func TestRotatingFileHourlyMaxSize(t *testing.T) {
+	dir := t.TempDir()
+	opts := FileOptions{
+		FilenamePattern: filepath.Join(dir, "app-%Y%m%d-%H.log"),
+		Rotation:        RotationHourly,
+		MaxSize:         1,
+		UTC:             true,
+	}
+	require.NoError(t, opts.CheckValid())
+
+	chunk := strings.Repeat("x", 600*1024)
+	r, clock := newTestRotatingFile(opts, time.Date(2024, 1, 1, 10, 5, 0, 0, time.UTC))
+	writeString(t, r, chunk)
+	writeString(t, r, chunk)
+	writeString(t, r, chunk)
+	clock.set(time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC))
+	writeString(t, r, "next hour")
+	require.NoError(t, r.Close())
+
+	assert.Equal(t, []string{"app-20240101-10.1.log", "app-20240101-10.2.log", "app-20240101-10.log", "app-20240101-11.log"},
+		listDir(t, dir))
+	assert.Equal(t, "next hour", readFile(t, filepath.Join(dir, "app-20240101-11.log")))
+}
+
+func TestRotatingFileCompressionAndHooks(t *testing.T) {
+	dir := t.TempDir()
+
+	var mux sync.Mutex
+	var hooked []string
+	opts := FileOptions{
+		FilenamePattern: filepath.Join(dir, "app-%Y-%m-%d.log"),
+		Compression:     "gzip",
+		MaxBackups:      2,
+		UTC:             true,
+		RotateHooks: []RotateHook{func(path string) (string, error) {
+			mux.Lock()
+			defer mux.Unlock()
+			hooked = append(hooked, filepath.Base(path))
+			return path, nil
+		}},
+	}
+	require.NoError(t, opts.CheckValid())
+
+	r, clock := newTestRotatingFile(opts, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	for day := 1; day <= 4; day++ {
+		clock.set(time.Date(2024, 1, day, 12, 0, 0, 0, time.UTC))
+		writeString(t, r, "day\n")
+	}
+	require.NoError(t, r.Close())
+
+	assert.Equal(t, []string{"app-2024-01-02.log.gz", "app-2024-01-03.log.gz", "app-2024-01-04.log"}, listDir(t, dir))
+	assert.Equal(t, []string{"app-2024-01-01.log.gz", "app-2024-01-02.log.gz", "app-2024-01-03.log.gz"}, hooked)
+
+	f, err := os.Open(filepath.Join(dir, "app-2024-01-03.log.gz"))
+	require.NoError(t, err)
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	data, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	assert.Equal(t, "day\n", string(data))
+}
+
+func TestFileRotateHookError(t *testing.T) {
+	dir := t.TempDir()
+	opts := FileOptions{
+		FilenamePattern: filepath.Join(dir, "app-%Y%m%d.log"),
+		UTC:             true,
+		RotateHooks: []RotateHook{func(path string) (string, error) {
+			return "", errors.New("hook failed")
+		}},
+	}
+	require.NoError(t, opts.CheckValid())
+
+	var mux sync.Mutex
+	var reported []error
+	lgr, err := logr.New(logr.OnLoggerError(func(err error) {
+		mux.Lock()
+		defer mux.Unlock()
+		reported = append(reported, err)
+	}))
+	require.NoError(t, err)
+	defer lgr.Shutdown()
+
+	target := NewFileTarget(opts)
+	clock := &testClock{}
+	clock.set(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	target.out.(*rotatingFile).now = clock.now
+	require.NoError(t, lgr.AddTarget(target, "file", &logr.StdFilter{Lvl: logr.Info}, &formatters.Plain{}, 100))
+
+	logger := lgr.NewLogger()
+	logger.Info("day one")
+	require.NoError(t, lgr.Flush())
+	clock.set(time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC))
+
+	// the hook runs asynchronously, so its error is reported by a later write.
+	require.Eventually(t, func() bool {
+		logger.Info("day two")
+		require.NoError(t, lgr.Flush())
+		mux.Lock()
+		defer mux.Unlock()
+		return len(reported) > 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	mux.Lock()
+	assert.Contains(t, reported[0].Error(), "hook failed")
+	mux.Unlock()
+
+	status := lgr.TargetStatuses()[0]
+	assert.Zero(t, status.Errors, "writes succeeded")
+	assert.True(t, status.Healthy)
+	assert.GreaterOrEqual(t, status.Logged, uint64(2))
+}
+
+func TestRotatingFileRegisteredCompression(t *testing.T) {
+	RegisterCompression("test-upper", ".up", func(w io.Writer) (io.WriteCloser, error) {
+		return &upperWriter{w: w}, nil
+	})
+
+	dir := t.TempDir()
+	opts := FileOptions{
+		FilenamePattern: filepath.Join(dir, "app-%Y%m%d.log"),
+		Compression:     "test-upper",
+		UTC:             true,
+	}
+	require.NoError(t, opts.CheckValid())
+
+	r, clock := newTestRotatingFile(opts, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	writeString(t, r, "hello")
+	clock.set(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+	writeString(t, r, "world")
+	require.NoError(t, r.Close())
+
+	assert.Equal(t, "HELLO", readFile(t, filepath.Join(dir, "app-20240101.log.up")))
+}
+
+func TestRotatingFileRetention(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+
+	// files left by previous runs; the unrelated file must survive.
+	old := []struct {
+		name string
+		size int
+		age  time.Duration
+	}{
+		{"app-20240101.log", 400 * 1024, 100 * time.Hour},
+		{"app-20240102.log", 400 * 1024, 50 * time.Hour},
+		{"app-20240103.log.gz", 400 * 1024, 30 * time.Hour},
+		{"app-20240104.log", 400 * 1024, 20 * time.Hour},
+		{"other.log", 10, 100 * time.Hour},
+	}
+	for _, f := range old {
+		path := filepath.Join(dir, f.name)
+		require.NoError(t, os.WriteFile(path, bytes.Repeat([]byte("x"), f.size), 0644))
+		require.NoError(t, os.Chtimes(path, now.Add(-f.age), now.Add(-f.age)))
+	}
+
+	opts := FileOptions{
+		FilenamePattern: filepath.Join(dir, "app-%Y%m%d.log"),
+		MaxAge:          3,
+		MaxTotalSize:    1,
+	}
+	require.NoError(t, opts.CheckValid())
+
+	r := newRotatingFile(opts)
+	writeString(t, r, "now")
+	require.NoError(t, r.Close())
+
+	// 01 is older than 3 days; 02 exceeds the total size of the newer backups.
+	assert.Equal(t, []string{"app-20240103.log.gz", "app-20240104.log", filepath.Base(strftime(opts.FilenamePattern, now)), "other.log"},
+		listDir(t, dir))
+}
+
+func TestFileOptionsCheckValidPattern(t *testing.T) {
+	tests := map[string]FileOptions{
+		"no day":              {FilenamePattern: "app-%Y%m.log"},
+		"hourly without hour": {FilenamePattern: "app-%Y%m%d.log", Rotation: RotationHourly},
+		"invalid rotation":    {FilenamePattern: "app-%Y%m%d.log", Rotation: "weekly"},
+		"unsupported spec":    {FilenamePattern: "app-%Y%m%d-%Q.log"},
+		"trailing percent":    {FilenamePattern: "app-%Y%m%d%"},
+		"spec in directory":   {FilenamePattern: "%Y/app-%m%d.log"},
+		"unknown compression": {FilenamePattern: "app-%Y%m%d.log", Compression: "lz4"},
+		"negative max":        {FilenamePattern: "app-%Y%m%d.log", MaxTotalSize: -1},
+	}
+	for name, opts := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Error(t, opts.CheckValid())
+		})
+	}
+	assert.NoError(t, FileOptions{FilenamePattern: "app-%j-%H%%.log", Rotation: RotationHourly}.CheckValid())
+
+	err := FileOptions{Filename: "app.log", Compression: "gzip"}.CheckValid()
+	assert.EqualError(t, err, "compression requires filename_pattern")
+}
+
+func TestFileInitUnknownCompression(t *testing.T) {
+	f := NewFileTarget(FileOptions{FilenamePattern: filepath.Join(t.TempDir(), "app-%Y%m%d.log"), Compression: "lz4"})
+	assert.EqualError(t, f.Init(), "unknown compression 'lz4'")
+	assert.NoError(t, f.Shutdown())
+}
+
+func TestStrftime(t *testing.T) {
+	ts := time.Date(2024, 3, 5, 7, 8, 9, 0, time.UTC)
+	assert.Equal(t, "2024-24-03-05-07-08-09-065-Mar-Tue-%", strftime("%Y-%y-%m-%d-%H-%M-%S-%j-%b-%a-%%", ts))
+
+	rx := patternRegexp("logs/app-%Y%m%d.log")
+	assert.True(t, rx.MatchString("app-20240305.log"))
+	assert.True(t, rx.MatchString("app-20240305.2.log"))
+	assert.True(t, rx.MatchString("app-20240305.log.gz"))
+	assert.False(t, rx.MatchString("app-2024030.log"))
+	assert.False(t, rx.MatchString("other.log"))
+}
+
+type testClock struct {
+	t atomic.Value
+}
+
+func (c *testClock) set(t time.Time) {
+	c.t.Store(t)
+}
+
+func (c *testClock) now() time.Time {
+	return c.t.Load().(time.Time)
+}
+
+func newTestRotatingFile(opts FileOptions, start time.Time) (*rotatingFile, *testClock) {
+	clock := &testClock{}
+	clock.set(start)
+	r := newRotatingFile(opts)
+	r.now = clock.now
+	return r, clock
+}
+
+func writeString(t *testing.T, w io.Writer, s string) {
+	t.Helper()
+	_, err := io.WriteString(w, s)
+	require.NoError(t, err)
+}
+
+func listDir(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(data)
+}
+
+type upperWriter struct {
+	w io.Writer
+}
+
+func (u *upperWriter) Write(p []byte) (int, error) {
+	return u.w.Write(bytes.ToUpper(p))
+}
+
+func (u *upperWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,492 @@
+package targets
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mattermost/logr/v2"
+)
+
+const (
+	// DefaultSpillMaxBytes is the default maximum size of a spill queue on disk.
+	DefaultSpillMaxBytes = 100 * 1024 * 1024
+
+	// DefaultSpillSegmentBytes is the default size of each spill queue segment file.
+	DefaultSpillSegmentBytes = 4 * 1024 * 1024
+
+	// spillShutdownWait is how long Shutdown waits for an in-flight write to the
+	// wrapped target to complete before persisting it to disk.
+	spillShutdownWait = time.Second
+)
+
+// SpillOptions provides parameters for a spill queue.
+type SpillOptions struct {
+	// Dir is the directory where spill queue segment files are stored. Each spill
+	// target requires its own directory.
+	Dir string `json:"dir"`
+
+	// MaxBytes is the maximum total size of the spill queue. Records are discarded
+	// once the queue is full. Defaults to DefaultSpillMaxBytes.
+	MaxBytes int64 `json:"max_bytes,omitempty"`
+
+	// SegmentBytes is the size at which a new segment file is started. Fully replayed
+	// segment files are deleted. Defaults to DefaultSpillSegmentBytes.
+	SegmentBytes int64 `json:"segment_bytes,omitempty"`
+}
+
+func (so SpillOptions) CheckValid() error {
+	if so.Dir == "" {
+		return errors.New("missing dir")
+	}
+	if so.MaxBytes < 0 {
+		return errors.New("max_bytes cannot be less than zero")
+	}
+	if so.SegmentBytes < 0 {
+		return errors.New("segment_bytes cannot be less than zero")
+	}
+	return nil
+}
+
+// SpillStats provides counts of records spilled to disk, replayed from disk, and
+// discarded because the spill queue was full.
+type SpillStats struct {
+	Spilled   uint64
+	Replayed  uint64
+	Discarded uint64
+	Queued    int
+}
+
+// SpillMetricsCollector is an optional interface a `logr.MetricsCollector` can implement
+// to receive metrics from spill targets.
+type SpillMetricsCollector interface {
+	// SpilledCounter returns a Counter incremented for each record written to disk.
+	SpilledCounter(target string) (logr.Counter, error)
+	// ReplayedCounter returns a Counter incremented for each record replayed from disk.
+	ReplayedCounter(target string) (logr.Counter, error)
+	// DiscardedCounter returns a Counter incremented for each record discarded because
+	// the spill queue was full.
+	DiscardedCounter(target string) (logr.Counter, error)
+}
+
+type spillItem struct {
+	data []byte
+	rec  *logr.LogRec
+}
+
+// Spill wraps another target, typically a network target such as TCP or syslog, with a
+// persistent queue on disk. Records are passed to the wrapped target by a dedicated
+// goroutine; while the wrapped target is blocked or failing (e.g. the remote server is
+// down) new records are appended to the spill queue instead of filling the target queue.
+// Spilled records are replayed in order once the wrapped target recovers, including
+// after a restart.
+//
+// Delivery is at-least-once: a record in-flight during shutdown may be replayed again.
+// Replayed records retain their formatted bytes, level and time only.
+type Spill struct {
+	inner logr.Target
+	name  string
+	opts  SpillOptions
+
+	mux      sync.Mutex
+	queue    *spillQueue
+	logger   *logr.Logger // used to create log records for replay.
+	inflight *spillItem   // item taken from mem being written to the wrapped target.
+
+	mem  chan *spillItem
+	wake chan struct{}
+	quit chan struct{}
+	done chan struct{}
+
+	spilled   uint64
+	replayed  uint64
+	discarded uint64
+
+	spilledCounter   logr.Counter
+	replayedCounter  logr.Counter
+	discardedCounter logr.Counter
+}
+
+// NewSpillTarget creates a target that wraps another target with a persistent spill
+// queue. The name is used for metrics and should match the name the target is added with.
+func NewSpillTarget(inner logr.Target, name string, opts SpillOptions) (*Spill, error) {
+	if inner == nil {
+		return nil, errors.New("inner target cannot be nil")
+	}
+	if err := opts.CheckValid(); err != nil {
+		return nil, err
+	}
+	if opts.MaxBytes == 0 {
+		opts.MaxBytes = DefaultSpillMaxBytes
+	}
+	if opts.SegmentBytes == 0 {
+		opts.SegmentBytes = DefaultSpillSegmentBytes
+	}
+
+	return &Spill{
+		inner: inner,
+		name:  name,
+		opts:  opts,
+		mem:   make(chan *spillItem, 1),
+		wake:  make(chan struct{}, 1),
+		quit:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}, nil
+}
+
+// Init is called once to initialize the target.
+func (s *Spill) Init() error {
+	queue, err := openSpillQueue(s.opts.Dir, s.opts.MaxBytes, s.opts.SegmentBytes)
+	if err != nil {
+		return fmt.Errorf("cannot open spill queue: %w", err)
+	}
+	s.queue = queue
+
+	if err := s.inner.Init(); err != nil {
+		_ = queue.close()
+		return err
+	}
+
+	go s.start()
+	return nil
+}
+
+// IsCallerNeeded returns true if the wrapped target implements `logr.CallerTarget` and
+// needs the caller of each record.
+func (s *Spill) IsCallerNeeded() bool {
+	if ct, ok := s.inner.(logr.CallerTarget); ok {
+		return ct.IsCallerNeeded()
+	}
+	return false
+}
+
+// EnableMetrics enables spill metrics if the collector implements `SpillMetricsCollector`,
+// and passes the collector to the wrapped target if it supports metrics.
+func (s *Spill) EnableMetrics(collector logr.MetricsCollector, updateFreqMillis int64) error {
+	if twm, ok := s.inner.(logr.TargetWithMetrics); ok {
+		if err := twm.EnableMetrics(collector, updateFreqMillis); err != nil {
+			return err
+		}
+	}
+
+	smc, ok := collector.(SpillMetricsCollector)
+	if !ok {
+		return nil
+	}
+
+	var err error
+	if s.spilledCounter, err = smc.SpilledCounter(s.name); err != nil {
+		return err
+	}
+	if s.replayedCounter, err = smc.ReplayedCounter(s.name); err != nil {
+		return err
+	}
+	if s.discardedCounter, err = smc.DiscardedCounter(s.name); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Write passes the record to the wrapped target, or appends it to the spill
+// queue if the wrapped target is busy or records are already spilled.
+func (s *Spill) Write(p []byte, rec *logr.LogRec) (int, error) {
+	// p is only valid until Write returns.
+	item := &spillItem{data: append([]byte(nil), p...), rec: rec}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if s.logger == nil {
+		logger := rec.Logger()
+		s.logger = &logger
+		s.signal()
+	}
+
+	// Records only bypass the spill queue when it is empty, which preserves ordering.
+	if s.queue.empty() {
+		select {
+		case s.mem <- item:
+			return len(p), nil
+		default:
+		}
+	}
+
+	err := s.queue.append(spillEntry{time: rec.Time(), level: rec.Level(), data: item.data})
+	if err != nil {
+		atomic.AddUint64(&s.discarded, 1)
+		incCounter(s.discardedCounter)
+		if err == errSpillFull {
+			return 0, fmt.Errorf("%s discarded record: %w", s.String(), err)
+		}
+		return 0, fmt.Errorf("%s discarded record: spill queue error: %w", s.String(), err)
+	}
+	atomic.AddUint64(&s.spilled, 1)
+	incCounter(s.spilledCounter)
+	s.signal()
+	return len(p), nil
+}
+
+// Shutdown is called once to free/close any resources. Records not yet written to the
+// wrapped target are persisted in the spill queue for replay after a restart.
+func (s *Spill) Shutdown() error {
+	close(s.quit)
+
+	var errs []error
+
+	select {
+	case <-s.done:
+	case <-time.After(spillShutdownWait):
+		// the wrapped target is blocked writing; persist whatever it is holding.
+		s.mux.Lock()
+		var items []*spillItem
+		if s.inflight != nil {
+			items = append(items, s.inflight)
+			s.inflight = nil
+		}
+		select {
+		case item := <-s.mem:
+			items = append(items, item)
+		default:
+		}
+		if err := s.persistFront(items...); err != nil {
+			errs = append(errs, err)
+		}
+		s.mux.Unlock()
+	}
+
+	if err := s.inner.Shutdown(); err != nil {
+		errs = append(errs, err)
+	}
+
+	select {
+	case <-s.done:
+	case <-time.After(spillShutdownWait):
+		errs = append(errs, fmt.Errorf("%s timed out waiting for wrapped target", s.String()))
+	}
+
+	s.mux.Lock()
+	if err := s.queue.close(); err != nil {
+		errs = append(errs, err)
+	}
+	s.mux.Unlock()
+
+	return errors.Join(errs...)
+}
+
+// Stats returns counts of records spilled, replayed and discarded since this target
+// was created, and the number of records currently in the spill queue.
+func (s *Spill) Stats() SpillStats {
+	s.mux.Lock()
+	queued := s.queue.entries
+	s.mux.Unlock()
+
+	return SpillStats{
+		Spilled:   atomic.LoadUint64(&s.spilled),
+		Replayed:  atomic.LoadUint64(&s.replayed),
+		Discarded: atomic.LoadUint64(&s.discarded),
+		Queued:    queued,
+	}
+}
+
+// Reopen reopens the wrapped target's file if it implements logr.Reopener.
+func (s *Spill) Reopen() error {
+	if r, ok := s.inner.(logr.Reopener); ok {
+		return r.Reopen()
+	}
+	return nil
+}
+
+// String returns a string representation of this target.
+func (s *Spill) String() string {
+	return fmt.Sprintf("Spill[%v]", s.inner)
+}
+
+// start writes records to the wrapped target until shutdown. Records handed over
+// directly are always older than records in the spill queue so they are written first.
+func (s *Spill) start() {
+	defer close(s.done)
+
+	for {
+		select {
+		case <-s.quit:
+			s.drainMem()
+			return
+		case item := <-s.mem:
+			s.writeItem(item)
+			continue
+		default:
+		}
+
+		if s.replayNext() {
+			continue
+		}
+
+		select {
+		case <-s.quit:
+			s.drainMem()
+			return
+		case item := <-s.mem:
+			s.writeItem(item)
+		case <-s.wake:
+		}
+	}
+}
+
+// writeItem writes a record that bypassed the spill queue, retrying until success or
+// shutdown. If shutdown occurs first the record is persisted to the front of the queue.
+func (s *Spill) writeItem(item *spillItem) {
+	s.mux.Lock()
+	s.inflight = item
+	s.mux.Unlock()
+
+	ok := s.writeWithRetry(item.data, item.rec, true)
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if s.inflight != item {
+		// already persisted by Shutdown.
+		return
+	}
+	s.inflight = nil
+
+	if !ok {
+		// shutting down; persist this record and any newer record waiting behind it.
+		items := []*spillItem{item}
+		select {
+		case next := <-s.mem:
+			items = append(items, next)
+		default:
+		}
+		if err := s.persistFront(items...); err != nil {
+			item.rec.Logger().Logr().ReportError(err)
+		}
+	}
+}
+
+// drainMem makes a single attempt to write a record waiting to bypass the spill
+// queue, persisting it on failure.
+func (s *Spill) drainMem() {
+	select {
+	case item := <-s.mem:
+		s.mux.Lock()
+		s.inflight = item
+		s.mux.Unlock()
+
+		ok := s.writeWithRetry(item.data, item.rec, false)
+
+		s.mux.Lock()
+		if s.inflight == item {
+			s.inflight = nil
+			if !ok {
+				if err := s.persistFront(item); err != nil {
+					item.rec.Logger().Logr().ReportError(err)
+				}
+			}
+		}
+		s.mux.Unlock()
+	default:
+	}
+}
+
+// replayNext writes the oldest record in the spill queue to the wrapped target. Returns
+// false if there is nothing to replay.
+func (s *Spill) replayNext() bool {
+	s.mux.Lock()
+	logger := s.logger
+	if logger == nil || s.queue.empty() {
+		s.mux.Unlock()
+		return false
+	}
+	entry, n, err := s.queue.peek()
+	s.mux.Unlock()
+
+	if err == io.EOF {
+		return false
+	}
+	if err != nil {
+		logger.Logr().ReportError(fmt.Errorf("%s replay error: %w", s.String(), err))
+		return true
+	}
+
+	rec := logr.NewLogRec(entry.level, *logger, "", nil, false).WithTime(entry.time)
+	if !s.writeWithRetry(entry.data, rec, true) {
+		return false // shutdown; the record remains queued.
+	}
+
+	s.mux.Lock()
+	err = s.queue.commit(n)
+	s.mux.Unlock()
+
+	if err != nil {
+		logger.Logr().ReportError(fmt.Errorf("%s replay commit error: %w", s.String(), err))
+	}
+	atomic.AddUint64(&s.replayed, 1)
+	incCounter(s.replayedCounter)
+	return true
+}
+
+// writeWithRetry writes to the wrapped target, optionally retrying with backoff until
+// success or shutdown. Returns true if the write succeeded.
+func (s *Spill) writeWithRetry(p []byte, rec *logr.LogRec, retry bool) bool {
+	backoff := RetryBackoffMillis
+	for {
+		_, err := s.inner.Write(p, rec)
+		if err == nil {
+			return true
+		}
+		rec.Logger().Logr().ReportError(fmt.Errorf("%s write error: %w", s.String(), err))
+
+		if !retry {
+			return false
+		}
+
+		select {
+		case <-s.quit:
+			return false
+		case <-time.After(time.Millisecond * time.Duration(backoff)):
+		}
+
+		backoff += backoff >> 1
+		if backoff > MaxRetryBackoffMillis {
+			backoff = MaxRetryBackoffMillis
+		}
+	}
+}
+
+// persistFront adds records, oldest first, to the front of the spill queue.
+// Caller must hold the mutex.
+func (s *Spill) persistFront(items ...*spillItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+	entries := make([]spillEntry, 0, len(items))
+	for _, item := range items {
+		entries = append(entries, spillEntry{time: item.rec.Time(), level: item.rec.Level(), data: item.data})
+	}
+	if err := s.queue.prepend(entries...); err != nil {
+		return fmt.Errorf("%s cannot persist records: %w", s.String(), err)
+	}
+	atomic.AddUint64(&s.spilled, uint64(len(items)))
+	if s.spilledCounter != nil {
+		s.spilledCounter.Add(float64(len(items)))
+	}
+	return nil
+}
+
+// signal wakes the writer goroutine without blocking.
+func (s *Spill) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func incCounter(c logr.Counter) {
+	if c != nil {
+		c.Inc()
+	}
+}
@@ -0,0 +1,247 @@
+package targets
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr/v2"
+	"github.com/mattermost/logr/v2/formatters"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpillQueue(t *testing.T) {
+	dir := t.TempDir()
+
+	q, err := openSpillQueue(dir, 0, 64)
+	require.NoError(t, err)
+	require.True(t, q.empty())
+
+	now := time.Now()
+	for i := 0; i < 10; i++ {
+		err = q.append(spillEntry{time: now, level: logr.Info, data: []byte(fmt.Sprintf("rec %d", i))})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 10, q.entries)
+	assert.Greater(t, len(q.segs), 1, "should have rolled segments")
+
+	for i := 0; i < 4; i++ {
+		entry, n, err := q.peek()
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("rec %d", i), string(entry.data))
+		assert.Equal(t, logr.Info, entry.level)
+		assert.Equal(t, now.UnixNano(), entry.time.UnixNano())
+		require.NoError(t, q.commit(n))
+	}
+	require.NoError(t, q.close())
+
+	// reopen and ensure consumed entries are not replayed.
+	q, err = openSpillQueue(dir, 0, 64)
+	require.NoError(t, err)
+	assert.Equal(t, 6, q.entries)
+
+	err = q.prepend(
+		spillEntry{time: now, level: logr.Error, data: []byte("first")},
+		spillEntry{time: now, level: logr.Error, data: []byte("second")},
+	)
+	require.NoError(t, err)
+
+	var got []string
+	for {
+		entry, n, err := q.peek()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		got = append(got, string(entry.data))
+		require.NoError(t, q.commit(n))
+	}
+	assert.Equal(t, []string{"first", "second", "rec 4", "rec 5", "rec 6", "rec 7", "rec 8", "rec 9"}, got)
+	assert.True(t, q.empty())
+	assert.Empty(t, q.segs)
+	require.NoError(t, q.close())
+}
+
+func TestSpillQueueFull(t *testing.T) {
+	q, err := openSpillQueue(t.TempDir(), 100, 1024)
+	require.NoError(t, err)
+	defer q.close()
+
+	entry := spillEntry{time: time.Now(), level: logr.Info, data: make([]byte, 40)}
+	require.NoError(t, q.append(entry))
+	assert.Equal(t, errSpillFull, q.append(entry))
+	assert.Equal(t, 1, q.entries)
+}
+
+func TestSpillTarget(t *testing.T) {
+	lgr, err := logr.New(logr.OnLoggerError(func(error) {}))
+	require.NoError(t, err)
+
+	inner := newOutageTarget()
+	inner.setDown(true)
+
+	spill, err := NewSpillTarget(inner, "spill_test", SpillOptions{Dir: t.TempDir()})
+	require.NoError(t, err)
+
+	filter := &logr.StdFilter{Lvl: logr.Info}
+	formatter := &formatters.Plain{DisableTimestamp: true, DisableLevel: true}
+	err = lgr.AddTarget(spill, "spill_test", filter, formatter, 10)
+	require.NoError(t, err)
+
+	logger := lgr.NewLogger()
+	const count = 50
+	for i := 0; i < count; i++ {
+		logger.Info(fmt.Sprintf("msg %d", i))
+	}
+	require.NoError(t, lgr.Flush())
+
+	stats := spill.Stats()
+	assert.Greater(t, stats.Spilled, uint64(0))
+	assert.Zero(t, stats.Discarded)
+
+	// recover the outage and wait for replay.
+	inner.setDown(false)
+	require.Eventually(t, func() bool { return inner.count() == count }, time.Second*10, time.Millisecond*20)
+
+	lines := inner.lines()
+	for i := 0; i < count; i++ {
+		assert.Equal(t, fmt.Sprintf("msg %d", i), lines[i])
+	}
+
+	stats = spill.Stats()
+	assert.Equal(t, stats.Spilled, stats.Replayed)
+	assert.Zero(t, stats.Queued)
+
+	require.NoError(t, lgr.Shutdown())
+}
+
+func TestSpillPersistFrontCounter(t *testing.T) {
+	lgr, err := logr.New()
+	require.NoError(t, err)
+	defer lgr.Shutdown()
+
+	spill, err := NewSpillTarget(newOutageTarget(), "spill_test", SpillOptions{Dir: t.TempDir()})
+	require.NoError(t, err)
+	spill.queue, err = openSpillQueue(spill.opts.Dir, spill.opts.MaxBytes, spill.opts.SegmentBytes)
+	require.NoError(t, err)
+	defer spill.queue.close()
+
+	counter := &sumCounter{}
+	spill.spilledCounter = counter
+
+	logger := lgr.NewLogger()
+	items := []*spillItem{
+		{data: []byte("one"), rec: logr.NewLogRec(logr.Info, logger, "one", nil, false)},
+		{data: []byte("two"), rec: logr.NewLogRec(logr.Info, logger, "two", nil, false)},
+	}
+	require.NoError(t, spill.persistFront(items...))
+
+	assert.Equal(t, uint64(2), spill.Stats().Spilled)
+	assert.Equal(t, float64(2), counter.sum)
+}
+
+func TestSpillTargetRestart(t *testing.T) {
+	dir := t.TempDir()
+
+	// first run: target is down for the entire run.
+	lgr, err := logr.New(logr.OnLoggerError(func(error) {}))
+	require.NoError(t, err)
+
+	inner := newOutageTarget()
+	inner.setDown(true)
+	spill, err := NewSpillTarget(inner, "spill_test", SpillOptions{Dir: dir})
+	require.NoError(t, err)
+
+	formatter := &formatters.Plain{DisableTimestamp: true, DisableLevel: true}
+	err = lgr.AddTarget(spill, "spill_test", &logr.StdFilter{Lvl: logr.Info}, formatter, 10)
+	require.NoError(t, err)
+
+	logger := lgr.NewLogger()
+	for i := 0; i < 5; i++ {
+		logger.Info(fmt.Sprintf("msg %d", i))
+	}
+	require.NoError(t, lgr.Flush())
+	_ = lgr.Shutdown()
+	assert.Zero(t, inner.count())
+
+	// second run: target is up; spilled records are replayed before new ones.
+	lgr, err = logr.New(logr.OnLoggerError(func(error) {}))
+	require.NoError(t, err)
+
+	inner = newOutageTarget()
+	spill, err = NewSpillTarget(inner, "spill_test", SpillOptions{Dir: dir})
+	require.NoError(t, err)
+	assert.NoError(t, lgr.AddTarget(spill, "spill_test", &logr.StdFilter{Lvl: logr.Info}, formatter, 10))
+
+	lgr.NewLogger().Info("msg 5")
+	require.Eventually(t, func() bool { return inner.count() == 6 }, time.Second*10, time.Millisecond*20)
+
+	// the records in-flight at shutdown are persisted at the front of the queue.
+	assert.Equal(t, []string{"msg 0", "msg 1", "msg 2", "msg 3", "msg 4", "msg 5"}, inner.lines())
+
+	require.NoError(t, lgr.Shutdown())
+}
+
+// outageTarget is a target that fails all writes while down.
+type outageTarget struct {
+	mux  sync.Mutex
+	down bool
+	recs []string
+}
+
+func newOutageTarget() *outageTarget {
+	return &outageTarget{}
+}
+
+func (ot *outageTarget) setDown(down bool) {
+	ot.mux.Lock()
+	defer ot.mux.Unlock()
+	ot.down = down
+}
+
+func (ot *outageTarget) count() int {
+	ot.mux.Lock()
+	defer ot.mux.Unlock()
+	return len(ot.recs)
+}
+
+func (ot *outageTarget) lines() []string {
+	ot.mux.Lock()
+	defer ot.mux.Unlock()
+	return append([]string(nil), ot.recs...)
+}
+
+func (ot *outageTarget) Init() error { return nil }
+
+func (ot *outageTarget) Write(p []byte, rec *logr.LogRec) (int, error) {
+	ot.mux.Lock()
+	defer ot.mux.Unlock()
+	if ot.down {
+		return 0, errors.New("target down")
+	}
+	ot.recs = append(ot.recs, string(trimNewline(p)))
+	return len(p), nil
+}
+
+func (ot *outageTarget) Shutdown() error { return nil }
+
+func (ot *outageTarget) String() string { return "outageTarget" }
+
+func trimNewline(p []byte) []byte {
+	for len(p) > 0 && (p[len(p)-1] == '\n' || p[len(p)-1] == ' ') {
+		p = p[:len(p)-1]
+	}
+	return p
+}
+
+// sumCounter is a logr.Counter that sums its increments.
+type sumCounter struct {
+	sum float64
+}
+
+func (c *sumCounter) Inc()            { c.sum++ }
+func (c *sumCounter) Add(val float64) { c.sum += val }
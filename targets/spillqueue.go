@@ -0,0 +1,419 @@
+package targets
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattermost/logr/v2"
+)
+
+const (
+	spillSegmentExt  = ".seg"
+	spillOffsetFile  = "head.offset"
+	spillInitialSeq  = uint64(1) << 32   // leaves room for prepending segments.
+	spillEntryHeader = 8                 // 4 byte length + 4 byte CRC32.
+	spillMetaLen     = 8 + 4 + 1 + 1 + 1 // time, level id, stacktrace flag, color, level name length.
+)
+
+var errSpillFull = errors.New("spill queue full")
+
+// spillEntry is a log record persisted to a spill queue.
+type spillEntry struct {
+	time  time.Time
+	level logr.Level
+	data  []byte
+}
+
+// spillQueue is a FIFO queue of log records stored in segment files on disk.
+// Entries are appended to the tail segment and read from the head segment. The
+// read offset within the head segment is persisted so records are not replayed
+// twice after a restart. Not safe for concurrent use.
+type spillQueue struct {
+	dir          string
+	maxBytes     int64
+	segmentBytes int64
+
+	segs       []uint64 // sequence numbers of segment files, oldest first.
+	size       int64    // unconsumed bytes across all segments.
+	entries    int      // unconsumed entry count.
+	headOffset int64
+
+	tail     *os.File
+	tailSize int64
+}
+
+func openSpillQueue(dir string, maxBytes, segmentBytes int64) (*spillQueue, error) {
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, err
+	}
+	q := &spillQueue{dir: dir, maxBytes: maxBytes, segmentBytes: segmentBytes}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		name := f.Name()
+		if f.IsDir() || !strings.HasSuffix(name, spillSegmentExt) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, spillSegmentExt), 16, 64)
+		if err != nil {
+			continue
+		}
+		q.segs = append(q.segs, seq)
+	}
+	sort.Slice(q.segs, func(i, j int) bool { return q.segs[i] < q.segs[j] })
+
+	q.headOffset = q.readOffset()
+
+	// count the unconsumed entries.
+	for i, seq := range q.segs {
+		var offset int64
+		if i == 0 {
+			offset = q.headOffset
+		}
+		count, size, err := countSpillEntries(q.segPath(seq), offset)
+		if err != nil {
+			return nil, err
+		}
+		q.entries += count
+		q.size += size
+	}
+
+	if q.entries == 0 {
+		if err := q.reset(); err != nil {
+			return nil, err
+		}
+	}
+	return q, nil
+}
+
+// empty returns true if there are no unconsumed entries.
+func (q *spillQueue) empty() bool {
+	return q.entries == 0
+}
+
+// append adds an entry to the tail of the queue.
+func (q *spillQueue) append(entry spillEntry) error {
+	b := encodeSpillEntry(entry)
+	if q.maxBytes > 0 && q.size+int64(len(b)) > q.maxBytes {
+		return errSpillFull
+	}
+
+	if q.tail == nil || q.tailSize >= q.segmentBytes {
+		seq := spillInitialSeq
+		if len(q.segs) > 0 {
+			seq = q.segs[len(q.segs)-1] + 1
+		}
+		if err := q.openTail(seq, true); err != nil {
+			return err
+		}
+		q.segs = append(q.segs, seq)
+	}
+
+	n, err := q.tail.Write(b)
+	q.tailSize += int64(n)
+	if err != nil {
+		return err
+	}
+	q.size += int64(n)
+	q.entries++
+	return nil
+}
+
+// prepend adds entries to the head of the queue, ahead of any existing entries.
+// The remainder of the current head segment is copied after the new entries so
+// a single read offset remains valid.
+func (q *spillQueue) prepend(entries ...spillEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	seq := spillInitialSeq
+	if len(q.segs) > 0 {
+		seq = q.segs[0] - 1
+	}
+
+	f, err := os.OpenFile(q.segPath(seq), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0640)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var added int64
+	for _, entry := range entries {
+		n, err := f.Write(encodeSpillEntry(entry))
+		added += int64(n)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(q.segs) == 0 {
+		q.segs = []uint64{seq}
+	} else {
+		oldHead := q.segs[0]
+		wasTail := len(q.segs) == 1
+		if err := copySpillSegment(f, q.segPath(oldHead), q.headOffset); err != nil {
+			return err
+		}
+		if wasTail && q.tail != nil {
+			q.tail.Close()
+			q.tail = nil
+		}
+		if err := os.Remove(q.segPath(oldHead)); err != nil {
+			return err
+		}
+		q.segs[0] = seq
+		if wasTail {
+			if err := q.openTail(seq, false); err != nil {
+				return err
+			}
+		}
+	}
+
+	q.size += added
+	q.entries += len(entries)
+	q.headOffset = 0
+	return q.writeOffset()
+}
+
+// peek returns the entry at the head of the queue without removing it, along with
+// the number of bytes the entry occupies.
+func (q *spillQueue) peek() (spillEntry, int64, error) {
+	for q.entries > 0 && len(q.segs) > 0 {
+		entry, n, err := readSpillEntry(q.segPath(q.segs[0]), q.headOffset)
+		if err == io.EOF && len(q.segs) > 1 {
+			// head segment fully consumed; move to next.
+			if err := q.removeHead(); err != nil {
+				return spillEntry{}, 0, err
+			}
+			continue
+		}
+		if err != nil && err != io.EOF {
+			// corrupt segment; discard it.
+			count, size, _ := countSpillEntries(q.segPath(q.segs[0]), q.headOffset)
+			q.entries -= count
+			q.size -= size
+			if len(q.segs) == 1 {
+				return spillEntry{}, 0, errors.Join(err, q.reset())
+			}
+			return spillEntry{}, 0, errors.Join(err, q.removeHead())
+		}
+		return entry, n, err
+	}
+	return spillEntry{}, 0, io.EOF
+}
+
+// commit removes the entry returned by the previous call to peek.
+func (q *spillQueue) commit(n int64) error {
+	q.headOffset += n
+	q.size -= n
+	q.entries--
+
+	if q.entries <= 0 {
+		return q.reset()
+	}
+	return q.writeOffset()
+}
+
+// close closes the tail segment. The queue contents remain on disk.
+func (q *spillQueue) close() error {
+	if q.tail != nil {
+		err := q.tail.Close()
+		q.tail = nil
+		return err
+	}
+	return nil
+}
+
+// reset removes all segments once every entry has been consumed.
+func (q *spillQueue) reset() error {
+	if err := q.close(); err != nil {
+		return err
+	}
+	for _, seq := range q.segs {
+		if err := os.Remove(q.segPath(seq)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	q.segs = nil
+	q.size = 0
+	q.entries = 0
+	q.headOffset = 0
+	q.tailSize = 0
+	return q.writeOffset()
+}
+
+func (q *spillQueue) removeHead() error {
+	if err := os.Remove(q.segPath(q.segs[0])); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	q.segs = q.segs[1:]
+	q.headOffset = 0
+	return q.writeOffset()
+}
+
+func (q *spillQueue) openTail(seq uint64, create bool) error {
+	if err := q.close(); err != nil {
+		return err
+	}
+	flags := os.O_WRONLY | os.O_APPEND
+	if create {
+		flags |= os.O_CREATE
+	}
+	f, err := os.OpenFile(q.segPath(seq), flags, 0640)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	q.tail = f
+	q.tailSize = info.Size()
+	return nil
+}
+
+func (q *spillQueue) segPath(seq uint64) string {
+	return filepath.Join(q.dir, fmt.Sprintf("%016x%s", seq, spillSegmentExt))
+}
+
+func (q *spillQueue) readOffset() int64 {
+	b, err := os.ReadFile(filepath.Join(q.dir, spillOffsetFile))
+	if err != nil || len(b) != 8 {
+		return 0
+	}
+	return int64(binary.BigEndian.Uint64(b))
+}
+
+func (q *spillQueue) writeOffset() error {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(q.headOffset))
+	return os.WriteFile(filepath.Join(q.dir, spillOffsetFile), b[:], 0640)
+}
+
+func encodeSpillEntry(entry spillEntry) []byte {
+	name := entry.level.Name
+	if len(name) > 255 {
+		name = name[:255]
+	}
+	bodyLen := spillMetaLen + len(name) + len(entry.data)
+	b := make([]byte, spillEntryHeader+bodyLen)
+
+	body := b[spillEntryHeader:]
+	binary.BigEndian.PutUint64(body[0:8], uint64(entry.time.UnixNano()))
+	binary.BigEndian.PutUint32(body[8:12], uint32(entry.level.ID))
+	if entry.level.Stacktrace {
+		body[12] = 1
+	}
+	body[13] = byte(entry.level.Color)
+	body[14] = byte(len(name))
+	copy(body[spillMetaLen:], name)
+	copy(body[spillMetaLen+len(name):], entry.data)
+
+	binary.BigEndian.PutUint32(b[0:4], uint32(bodyLen))
+	binary.BigEndian.PutUint32(b[4:8], crc32.ChecksumIEEE(body))
+	return b
+}
+
+// readSpillEntry reads the entry at offset within a segment file. io.EOF is returned
+// when no entry exists at the offset.
+func readSpillEntry(path string, offset int64) (spillEntry, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return spillEntry{}, 0, err
+	}
+	defer f.Close()
+	return readSpillEntryAt(f, path, offset)
+}
+
+func readSpillEntryAt(f io.ReaderAt, path string, offset int64) (spillEntry, int64, error) {
+	var hdr [spillEntryHeader]byte
+	if _, err := f.ReadAt(hdr[:], offset); err != nil {
+		if err == io.EOF {
+			return spillEntry{}, 0, io.EOF
+		}
+		return spillEntry{}, 0, err
+	}
+	bodyLen := binary.BigEndian.Uint32(hdr[0:4])
+	if bodyLen < spillMetaLen {
+		return spillEntry{}, 0, fmt.Errorf("invalid spill entry in %s at offset %d", path, offset)
+	}
+
+	body := make([]byte, bodyLen)
+	if _, err := f.ReadAt(body, offset+spillEntryHeader); err != nil {
+		return spillEntry{}, 0, fmt.Errorf("truncated spill entry in %s at offset %d: %w", path, offset, err)
+	}
+	if crc32.ChecksumIEEE(body) != binary.BigEndian.Uint32(hdr[4:8]) {
+		return spillEntry{}, 0, fmt.Errorf("corrupt spill entry in %s at offset %d", path, offset)
+	}
+
+	nameLen := int(body[14])
+	if spillMetaLen+nameLen > len(body) {
+		return spillEntry{}, 0, fmt.Errorf("invalid spill entry in %s at offset %d", path, offset)
+	}
+	entry := spillEntry{
+		time: time.Unix(0, int64(binary.BigEndian.Uint64(body[0:8]))),
+		level: logr.Level{
+			ID:         logr.LevelID(binary.BigEndian.Uint32(body[8:12])),
+			Name:       string(body[spillMetaLen : spillMetaLen+nameLen]),
+			Stacktrace: body[12] == 1,
+			Color:      logr.Color(body[13]),
+		},
+		data: body[spillMetaLen+nameLen:],
+	}
+	return entry, int64(spillEntryHeader + bodyLen), nil
+}
+
+// countSpillEntries returns the number of valid entries and their total size in a
+// segment file, starting at offset.
+func countSpillEntries(path string, offset int64) (int, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	var count int
+	var size int64
+	for {
+		_, n, err := readSpillEntryAt(f, path, offset)
+		if err == io.EOF {
+			return count, size, nil
+		}
+		if err != nil {
+			// ignore a partially written trailing entry.
+			return count, size, nil
+		}
+		count++
+		size += n
+		offset += n
+	}
+}
+
+// copySpillSegment appends the contents of the segment at path, starting at offset, to w.
+func copySpillSegment(w io.Writer, path string, offset int64) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	_, err = io.Copy(w, f)
+	return err
+}
@@ -7,38 +7,160 @@ import (
 	"crypto/tls"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/mattermost/logr/v2"
 	syslog "github.com/wiggin77/srslog"
 )
 
+const (
+	SyslogNetworkUDP  = "udp"
+	SyslogNetworkTCP  = "tcp"
+	SyslogNetworkTLS  = "tls"
+	SyslogNetworkUnix = "unix"
+
+	SyslogFormatRFC3164 = "rfc3164"
+	SyslogFormatRFC5424 = "rfc5424"
+
+	// SyslogTimestamp5424 is the RFC 5424 timestamp format.
+	SyslogTimestamp5424 = "2006-01-02T15:04:05.000000Z07:00"
+)
+
+// SyslogFacilities maps facility names to syslog facility codes.
+var SyslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+	"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// SyslogSeverities maps severity names to syslog severity codes.
+var SyslogSeverities = map[string]int{
+	"emerg": 0, "alert": 1, "crit": 2, "err": 3, "error": 3,
+	"warning": 4, "warn": 4, "notice": 5, "info": 6, "debug": 7,
+}
+
 // Syslog outputs log records to local or remote syslog.
 type Syslog struct {
-	params *SyslogOptions
-	writer *syslog.Writer
+	params   *SyslogOptions
+	writer   *syslog.Writer
+	redactor atomic.Pointer[logr.Redactor]
+
+	facility   int
+	severities map[logr.LevelID]int
+	hostname   string
+	appName    string
+	procID     string
 }
 
-// SyslogOptions provides parameters for dialing a syslog daemon.
+// SyslogOptions provides parameters for dialing a syslog daemon and formatting messages.
 type SyslogOptions struct {
 	IP       string `json:"ip,omitempty"` // deprecated (use Host instead)
 	Host     string `json:"host"`
 	Port     int    `json:"port"`
-	TLS      bool   `json:"tls"`
+	TLS      bool   `json:"tls"` // deprecated (use Network "tls" instead)
 	Cert     string `json:"cert"`
 	Insecure bool   `json:"insecure"`
-	Tag      string `json:"tag"`
+
+	// Tag is the RFC 3164 TAG or RFC 5424 APP-NAME. Defaults to the executable name.
+	Tag string `json:"tag"`
+
+	// Network is one of "udp", "tcp", "tls" or "unix". Defaults to "tls" if TLS is set,
+	// "unix" if no host or port is provided, otherwise "tcp".
+	Network string `json:"network,omitempty"`
+
+	// SocketPath is the path of the local syslog socket for the "unix" network. Defaults
+	// to the first of /dev/log, /var/run/syslog or /var/run/log that accepts a connection.
+	SocketPath string `json:"socket_path,omitempty"`
+
+	// Facility is the syslog facility name, e.g. "user", "daemon" or "local0". Defaults to "user".
+	Facility string `json:"facility,omitempty"`
+
+	// Format is one of "rfc3164" (default) or "rfc5424".
+	Format string `json:"format,omitempty"`
+
+	// OctetCounting prefixes each message with its length (RFC 6587 / RFC 5425) instead
+	// of terminating it with a newline. Only applies to "tcp" and "tls" networks.
+	OctetCounting bool `json:"octet_counting,omitempty"`
+
+	// Hostname overrides the HOSTNAME in each message. Defaults to os.Hostname.
+	Hostname string `json:"hostname,omitempty"`
+
+	// ProcID overrides the PROCID (RFC 5424) or PID (RFC 3164). Defaults to the process ID.
+	ProcID string `json:"procid,omitempty"`
+
+	// SDID enables output of log record fields as the SD-PARAMs of a STRUCTURED-DATA
+	// element with this SD-ID, e.g. "logr@32473". Requires the "rfc5424" format.
+	SDID string `json:"sd_id,omitempty"`
+
+	// Severities maps level IDs to syslog severity names ("emerg", "alert", "crit", "err",
+	// "warning", "notice", "info", "debug"). Standard levels not in the map use the
+	// default mapping; custom levels not in the map use "info".
+	Severities map[logr.LevelID]string `json:"severities,omitempty"`
 }
 
 func (so SyslogOptions) CheckValid() error {
-	if so.Host == "" && so.IP == "" {
-		return errors.New("missing host")
+	network := so.network()
+	switch network {
+	case SyslogNetworkUDP, SyslogNetworkTCP, SyslogNetworkTLS:
+		if so.Host == "" && so.IP == "" {
+			return errors.New("missing host")
+		}
+		if so.Port == 0 {
+			return errors.New("missing port")
+		}
+	case SyslogNetworkUnix:
+	default:
+		return fmt.Errorf("invalid network '%s'", so.Network)
+	}
+
+	if so.Facility != "" {
+		if _, ok := SyslogFacilities[so.Facility]; !ok {
+			return fmt.Errorf("invalid facility '%s'", so.Facility)
+		}
 	}
-	if so.Port == 0 {
-		return errors.New("missing port")
+
+	switch so.Format {
+	case "", SyslogFormatRFC3164, SyslogFormatRFC5424:
+	default:
+		return fmt.Errorf("invalid format '%s'", so.Format)
+	}
+
+	if so.SDID != "" {
+		if so.Format != SyslogFormatRFC5424 {
+			return errors.New("sd_id requires the rfc5424 format")
+		}
+		if sdName(so.SDID) != so.SDID {
+			return fmt.Errorf("invalid sd_id '%s'", so.SDID)
+		}
+	}
+
+	for id, sev := range so.Severities {
+		if _, ok := SyslogSeverities[sev]; !ok {
+			return fmt.Errorf("invalid severity '%s' for level %d", sev, id)
+		}
 	}
 	return nil
 }
 
+// network returns the network to dial, applying defaults.
+func (so SyslogOptions) network() string {
+	switch {
+	case so.Network != "":
+		return so.Network
+	case so.TLS:
+		return SyslogNetworkTLS
+	case so.Host == "" && so.IP == "" && so.Port == 0:
+		return SyslogNetworkUnix
+	}
+	return SyslogNetworkTCP
+}
+
 // NewSyslogTarget creates a target capable of outputting log records to remote or local syslog, with or without TLS.
 func NewSyslogTarget(params *SyslogOptions) (*Syslog, error) {
 	if params == nil {
@@ -53,67 +175,229 @@ func NewSyslogTarget(params *SyslogOptions) (*Syslog, error) {
 
 // Init is called once to initialize the target.
 func (s *Syslog) Init() error {
-	network := "tcp"
-	var config *tls.Config
+	if err := s.params.CheckValid(); err != nil {
+		return err
+	}
+
+	s.facility = SyslogFacilities["user"]
+	if s.params.Facility != "" {
+		s.facility = SyslogFacilities[s.params.Facility]
+	}
+
+	s.severities = map[logr.LevelID]int{
+		logr.Panic.ID: SyslogSeverities["crit"],
+		logr.Fatal.ID: SyslogSeverities["crit"],
+		logr.Error.ID: SyslogSeverities["err"],
+		logr.Warn.ID:  SyslogSeverities["warning"],
+		logr.Info.ID:  SyslogSeverities["info"],
+		logr.Debug.ID: SyslogSeverities["debug"],
+		logr.Trace.ID: SyslogSeverities["debug"],
+	}
+	for id, sev := range s.params.Severities {
+		s.severities[id] = SyslogSeverities[sev]
+	}
+
+	s.hostname = s.params.Hostname
+	if s.hostname == "" {
+		s.hostname, _ = os.Hostname()
+	}
+	s.appName = s.params.Tag
+	if s.appName == "" {
+		s.appName = filepath.Base(os.Args[0])
+	}
+	s.procID = s.params.ProcID
+	if s.procID == "" {
+		s.procID = strconv.Itoa(os.Getpid())
+	}
+
+	var err error
+	s.writer, err = s.dial()
+	if err != nil {
+		return err
+	}
 
+	// messages are fully formatted by this target. The writer appends a newline to each
+	// message, which is only needed when octet counting is not used.
+	network := s.params.network()
+	octetCounting := s.params.OctetCounting && (network == SyslogNetworkTCP || network == SyslogNetworkTLS)
+	s.writer.SetFormatter(func(p syslog.Priority, hostname, tag, content string) string {
+		if octetCounting {
+			return strings.TrimSuffix(content, "\n")
+		}
+		return content
+	})
+	if octetCounting {
+		s.writer.SetFramer(syslog.RFC5425MessageLengthFramer)
+	}
+	return nil
+}
+
+// dial connects to the syslog daemon.
+func (s *Syslog) dial() (*syslog.Writer, error) {
 	host := s.params.Host
 	if host == "" {
 		host = s.params.IP
 	}
+	raddr := fmt.Sprintf("%s:%d", host, s.params.Port)
 
-	if s.params.TLS {
-		network = "tcp+tls"
-		config = &tls.Config{InsecureSkipVerify: s.params.Insecure}
-
+	switch s.params.network() {
+	case SyslogNetworkTLS:
+		config := &tls.Config{InsecureSkipVerify: s.params.Insecure}
 		pool, err := GetCertPoolOrNil(s.params.Cert)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		if pool != nil {
 			config.RootCAs = pool
 		}
+		return syslog.DialWithTLSConfig("tcp+tls", raddr, syslog.LOG_INFO, s.appName, config)
+
+	case SyslogNetworkUnix:
+		if s.params.SocketPath == "" {
+			// srslog searches the standard socket paths.
+			return syslog.Dial("", "", syslog.LOG_INFO, s.appName)
+		}
+		w, err := syslog.Dial("unixgram", s.params.SocketPath, syslog.LOG_INFO, s.appName)
+		if err != nil {
+			w, err = syslog.Dial("unix", s.params.SocketPath, syslog.LOG_INFO, s.appName)
+		}
+		return w, err
 	}
-	raddr := fmt.Sprintf("%s:%d", host, s.params.Port)
-	if raddr == ":0" {
-		// If no IP:port provided then connect to local syslog.
-		raddr = ""
-		network = ""
-	}
+	return syslog.Dial(s.params.network(), raddr, syslog.LOG_INFO, s.appName)
+}
 
-	var err error
-	s.writer, err = syslog.DialWithTLSConfig(network, raddr, syslog.LOG_INFO, s.params.Tag, config)
-	return err
+// SetRedactor sets a Redactor applied to each record's fields before they are output as
+// STRUCTURED-DATA. The message is redacted by the target's formatter.
+func (s *Syslog) SetRedactor(redactor *logr.Redactor) {
+	s.redactor.Store(redactor)
 }
 
-// Write outputs bytes to this file target.
+// Write outputs a log record to syslog.
 func (s *Syslog) Write(p []byte, rec *logr.LogRec) (int, error) {
-	txt := string(p)
-	n := len(txt)
-	var err error
+	if redactor := s.redactor.Load(); redactor != nil {
+		rec = redactor.Redact(rec) // fields are output as STRUCTURED-DATA without the formatter.
+	}
+	severity, ok := s.severities[rec.Level().ID]
+	if !ok {
+		severity = SyslogSeverities["info"]
+	}
+	pri := s.facility*8 + severity
 
-	switch rec.Level() {
-	case logr.Panic, logr.Fatal:
-		err = s.writer.Crit(txt)
-	case logr.Error:
-		err = s.writer.Err(txt)
-	case logr.Warn:
-		err = s.writer.Warning(txt)
-	case logr.Debug, logr.Trace:
-		err = s.writer.Debug(txt)
-	default:
-		// logr.Info plus all custom levels.
-		err = s.writer.Info(txt)
+	msg := strings.TrimRight(string(p), " \t\r\n")
+
+	var sb strings.Builder
+	if s.params.Format == SyslogFormatRFC5424 {
+		s.format5424(&sb, pri, rec, msg)
+	} else {
+		s.format3164(&sb, pri, rec, msg)
 	}
 
-	if err != nil {
-		n = 0
-		// syslog writer will try to reconnect.
+	// syslog writer will try to reconnect.
+	if _, err := s.writer.WriteWithPriority(syslog.Priority(pri), []byte(sb.String())); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// format3164 formats a message as `<PRI>Mmm dd hh:mm:ss HOSTNAME TAG[PID]: MSG`. The
+// hostname is omitted for local syslog.
+func (s *Syslog) format3164(sb *strings.Builder, pri int, rec *logr.LogRec, msg string) {
+	fmt.Fprintf(sb, "<%d>%s ", pri, rec.Time().Format(time.Stamp))
+	if s.params.network() != SyslogNetworkUnix && s.hostname != "" {
+		sb.WriteString(s.hostname)
+		sb.WriteByte(' ')
 	}
-	return n, err
+	fmt.Fprintf(sb, "%s[%s]: %s", s.appName, s.procID, msg)
+}
+
+// format5424 formats a message as `<PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID SD MSG`.
+func (s *Syslog) format5424(sb *strings.Builder, pri int, rec *logr.LogRec, msg string) {
+	fmt.Fprintf(sb, "<%d>1 %s %s %s %s - ",
+		pri,
+		rec.Time().Format(SyslogTimestamp5424),
+		headerField(s.hostname, 255),
+		headerField(s.appName, 48),
+		headerField(s.procID, 128),
+	)
+	if !s.writeStructuredData(sb, rec) {
+		sb.WriteByte('-')
+	}
+	if msg != "" {
+		sb.WriteByte(' ')
+		sb.WriteString(msg)
+	}
+}
+
+// writeStructuredData writes the record's fields as an SD-ELEMENT. Returns false if
+// nothing was written.
+func (s *Syslog) writeStructuredData(sb *strings.Builder, rec *logr.LogRec) bool {
+	if s.params.SDID == "" {
+		return false
+	}
+	fields := rec.Fields()
+	if len(fields) == 0 {
+		return false
+	}
+
+	sb.WriteByte('[')
+	sb.WriteString(s.params.SDID)
+	for _, field := range fields {
+		name := sdName(field.Key)
+		if name == "" {
+			continue
+		}
+		var val strings.Builder
+		if err := field.ValueString(&val, nil); err != nil {
+			val.Reset()
+			fmt.Fprintf(&val, "<error encoding field: %v>", err)
+		}
+		fmt.Fprintf(sb, ` %s="%s"`, name, sdEscaper.Replace(val.String()))
+	}
+	sb.WriteByte(']')
+	return true
 }
 
 // Shutdown is called once to free/close any resources.
 // Target queue is already drained when this is called.
 func (s *Syslog) Shutdown() error {
+	if s.writer == nil {
+		return nil
+	}
 	return s.writer.Close()
 }
+
+// sdEscaper escapes SD-PARAM values as required by RFC 5424.
+var sdEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+// sdName converts s to a valid SD-NAME: at most 32 printable US-ASCII characters
+// excluding '=', ' ', ']' and '"'. Invalid characters are replaced with '_'.
+func sdName(s string) string {
+	b := []byte(s)
+	if len(b) > 32 {
+		b = b[:32]
+	}
+	for i, c := range b {
+		if c < 33 || c > 126 || c == '=' || c == ']' || c == '"' {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+// headerField converts s to a valid RFC 5424 header field of printable US-ASCII
+// characters, truncated to max, or NILVALUE if empty.
+func headerField(s string, max int) string {
+	if s == "" {
+		return "-"
+	}
+	b := []byte(s)
+	if len(b) > max {
+		b = b[:max]
+	}
+	for i, c := range b {
+		if c < 33 || c > 126 {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
@@ -5,7 +5,12 @@ package targets_test
 
 import (
 	"fmt"
+	"io"
+	"net"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -13,6 +18,7 @@ import (
 	"github.com/mattermost/logr/v2/formatters"
 	"github.com/mattermost/logr/v2/targets"
 	"github.com/mattermost/logr/v2/test"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -83,3 +89,165 @@ func syslogger(t *testing.T, formatter logr.Formatter) {
 	err = lgr.Shutdown()
 	require.NoError(t, err)
 }
+
+func TestSyslogRFC5424UDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	params := &targets.SyslogOptions{
+		Network:  targets.SyslogNetworkUDP,
+		Host:     "127.0.0.1",
+		Port:     conn.LocalAddr().(*net.UDPAddr).Port,
+		Tag:      "logrtest",
+		Facility: "local3",
+		Format:   targets.SyslogFormatRFC5424,
+		Hostname: "myhost",
+		ProcID:   "42",
+		SDID:     "logr@32473",
+	}
+	logToSyslog(t, params, func(logger logr.Logger) {
+		logger.Warn("disk almost full", logr.String("path", `C:\data "main"]`), logr.Int("pct", 95), logr.String("bad key=", "x"))
+	})
+
+	buf := make([]byte, 4096)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	msg := string(buf[:n])
+
+	// local3 (19) * 8 + warning (4) = 156
+	require.Regexp(t, `^<156>1 \d{4}-\d\d-\d\dT\d\d:\d\d:\d\d\.\d{6}(Z|[+-]\d\d:\d\d) myhost logrtest 42 - `, msg)
+	assert.Contains(t, msg, `[logr@32473 path="C:\\data \"main\"\]" pct="95" bad_key_="x"]`)
+	assert.True(t, strings.HasSuffix(strings.TrimRight(msg, "\n"), "disk almost full"), msg)
+}
+
+func TestSyslogOctetCountingTCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			received <- ""
+			return
+		}
+		defer conn.Close()
+		data, _ := io.ReadAll(conn)
+		received <- string(data)
+	}()
+
+	params := &targets.SyslogOptions{
+		Network:       targets.SyslogNetworkTCP,
+		Host:          "127.0.0.1",
+		Port:          l.Addr().(*net.TCPAddr).Port,
+		Tag:           "logrtest",
+		Format:        targets.SyslogFormatRFC5424,
+		OctetCounting: true,
+	}
+	logToSyslog(t, params, func(logger logr.Logger) {
+		logger.Error("first")
+		logger.Error("second")
+	})
+
+	var data string
+	select {
+	case data = <-received:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "timed out waiting for syslog data")
+	}
+
+	var msgs []string
+	for data != "" {
+		sp := strings.IndexByte(data, ' ')
+		require.Greater(t, sp, 0, data)
+		size, err := strconv.Atoi(data[:sp])
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, len(data), sp+1+size)
+		msgs = append(msgs, data[sp+1:sp+1+size])
+		data = data[sp+1+size:]
+	}
+	require.Len(t, msgs, 2)
+	// user (1) * 8 + err (3) = 11
+	assert.True(t, strings.HasPrefix(msgs[0], "<11>1 "), msgs[0])
+	assert.True(t, strings.HasSuffix(msgs[0], "first"), msgs[0])
+	assert.True(t, strings.HasSuffix(msgs[1], "second"), msgs[1])
+}
+
+func TestSyslogRFC3164Unix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.sock")
+	conn, err := net.ListenPacket("unixgram", path)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	audit := logr.Level{ID: 100, Name: "audit"}
+	params := &targets.SyslogOptions{
+		Network:    targets.SyslogNetworkUnix,
+		SocketPath: path,
+		Tag:        "logrtest",
+		Facility:   "auth",
+		Severities: map[logr.LevelID]string{audit.ID: "notice"},
+	}
+	logToSyslog(t, params, func(logger logr.Logger) {
+		logger.Log(audit, "user logged in")
+		logger.Log(logr.Level{ID: 101, Name: "custom"}, "custom level")
+	})
+
+	buf := make([]byte, 4096)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	// auth (4) * 8 + notice (5) = 37
+	assert.Regexp(t, `^<37>[A-Z][a-z]{2} [ \d]\d \d\d:\d\d:\d\d logrtest\[\d+\]: user logged in\n?$`, string(buf[:n]))
+
+	n, _, err = conn.ReadFrom(buf)
+	require.NoError(t, err)
+	// custom levels default to info: auth (4) * 8 + info (6) = 38
+	assert.Regexp(t, `^<38>.* logrtest\[\d+\]: custom level\n?$`, string(buf[:n]))
+}
+
+func TestSyslogOptions_CheckValid(t *testing.T) {
+	tests := map[string]targets.SyslogOptions{
+		"missing host":     {Network: targets.SyslogNetworkUDP, Port: 514},
+		"missing port":     {Network: targets.SyslogNetworkTCP, Host: "localhost"},
+		"invalid network":  {Network: "sctp", Host: "localhost", Port: 514},
+		"invalid facility": {Facility: "local9"},
+		"invalid format":   {Format: "rfc9999"},
+		"sd_id w/ 3164":    {SDID: "logr@32473"},
+		"invalid sd_id":    {Format: targets.SyslogFormatRFC5424, SDID: "logr 32473"},
+		"invalid severity": {Severities: map[logr.LevelID]string{100: "loud"}},
+	}
+	for name, so := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Error(t, so.CheckValid())
+		})
+	}
+
+	assert.NoError(t, targets.SyslogOptions{}.CheckValid(), "local syslog")
+	assert.NoError(t, targets.SyslogOptions{Host: "localhost", Port: 514, Facility: "daemon",
+		Format: targets.SyslogFormatRFC5424, SDID: "logr@32473"}.CheckValid())
+}
+
+func logToSyslog(t *testing.T, params *targets.SyslogOptions, log func(logger logr.Logger)) {
+	opt := logr.OnLoggerError(func(err error) {
+		t.Error(err)
+	})
+	lgr, err := logr.New(opt)
+	require.NoError(t, err)
+
+	target, err := targets.NewSyslogTarget(params)
+	require.NoError(t, err)
+
+	filter := &logr.CustomFilter{}
+	filter.Add(logr.Trace, logr.Debug, logr.Info, logr.Warn, logr.Error)
+	filter.Add(logr.Level{ID: 100, Name: "audit"}, logr.Level{ID: 101, Name: "custom"})
+	formatter := &formatters.Plain{DisableTimestamp: true, DisableLevel: true, DisableFields: true}
+	err = lgr.AddTarget(target, "syslog", filter, formatter, 100)
+	require.NoError(t, err)
+
+	log(lgr.NewLogger())
+	require.NoError(t, lgr.Shutdown())
+}
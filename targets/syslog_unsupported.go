@@ -1,3 +1,4 @@
+//go:build windows || nacl || plan9
 // +build windows nacl plan9
 
 package targets
@@ -19,15 +20,24 @@ type Syslog struct {
 	writer *syslog.Writer
 }
 
-// SyslogOptions provides parameters for dialing a syslog daemon.
+// SyslogOptions provides parameters for dialing a syslog daemon and formatting messages.
 type SyslogOptions struct {
-	IP       string `json:"ip,omitempty"` // deprecated
-	Host     string `json:"host"`
-	Port     int    `json:"port"`
-	TLS      bool   `json:"tls"`
-	Cert     string `json:"cert"`
-	Insecure bool   `json:"insecure"`
-	Tag      string `json:"tag"`
+	IP            string                  `json:"ip,omitempty"` // deprecated
+	Host          string                  `json:"host"`
+	Port          int                     `json:"port"`
+	TLS           bool                    `json:"tls"`
+	Cert          string                  `json:"cert"`
+	Insecure      bool                    `json:"insecure"`
+	Tag           string                  `json:"tag"`
+	Network       string                  `json:"network,omitempty"`
+	SocketPath    string                  `json:"socket_path,omitempty"`
+	Facility      string                  `json:"facility,omitempty"`
+	Format        string                  `json:"format,omitempty"`
+	OctetCounting bool                    `json:"octet_counting,omitempty"`
+	Hostname      string                  `json:"hostname,omitempty"`
+	ProcID        string                  `json:"procid,omitempty"`
+	SDID          string                  `json:"sd_id,omitempty"`
+	Severities    map[logr.LevelID]string `json:"severities,omitempty"`
 }
 
 func (so SyslogOptions) CheckValid() error {
@@ -39,6 +49,11 @@ func NewSyslogTarget(params *SyslogOptions) (*Syslog, error) {
 	return nil, errors.New(unsupported)
 }
 
+// SetRedactor sets a Redactor applied to each record's fields before they are output as
+// STRUCTURED-DATA.
+func (s *Syslog) SetRedactor(redactor *logr.Redactor) {
+}
+
 // Init is called once to initialize the target.
 func (s *Syslog) Init() error {
 	return errors.New(unsupported)
@@ -16,10 +16,11 @@ import (
 )
 
 const (
-	DialTimeoutSecs             = 30
-	WriteTimeoutSecs            = 30
-	RetryBackoffMillis    int64 = 100
-	MaxRetryBackoffMillis int64 = 30 * 1000 // 30 seconds
+	DialTimeoutSecs                 = 30
+	WriteTimeoutSecs                = 30
+	RetryBackoffMillis        int64 = 100
+	MaxRetryBackoffMillis     int64 = 30 * 1000 // 30 seconds
+	DefaultBatchLatencyMillis       = 100
 )
 
 // Tcp outputs log records to raw socket server.
@@ -41,6 +42,14 @@ type TcpOptions struct {
 	TLS      bool   `json:"tls"`
 	Cert     string `json:"cert"`
 	Insecure bool   `json:"insecure"`
+
+	// BatchSize is the maximum number of log records written to the socket in a
+	// single write. Batching is disabled when less than 2.
+	BatchSize int `json:"batch_size,omitempty"`
+
+	// BatchLatencyMillis is the maximum time a log record can wait for a batch to
+	// fill before the batch is written. Defaults to DefaultBatchLatencyMillis.
+	BatchLatencyMillis int64 `json:"batch_latency_millis,omitempty"`
 }
 
 func (to TcpOptions) CheckValid() error {
@@ -50,6 +59,12 @@ func (to TcpOptions) CheckValid() error {
 	if to.Port == 0 {
 		return errors.New("missing port")
 	}
+	if to.BatchSize < 0 {
+		return errors.New("batch_size cannot be less than zero")
+	}
+	if to.BatchLatencyMillis < 0 {
+		return errors.New("batch_latency_millis cannot be less than zero")
+	}
 	return nil
 }
 
@@ -166,6 +181,42 @@ func (tcp *Tcp) Shutdown() error {
 // Write converts the log record to bytes, via the Formatter, and outputs to the socket.
 // Called by dedicated target goroutine and will block until success or shutdown.
 func (tcp *Tcp) Write(p []byte, rec *logr.LogRec) (int, error) {
+	return tcp.writeWithRetry(rec, func(conn net.Conn) (int, error) {
+		return conn.Write(p)
+	})
+}
+
+// BatchSize returns the maximum number of records per batch and the maximum amount
+// of time a record can wait for the batch to fill.
+func (tcp *Tcp) BatchSize() (int, time.Duration) {
+	latency := tcp.options.BatchLatencyMillis
+	if latency == 0 {
+		latency = DefaultBatchLatencyMillis
+	}
+	return tcp.options.BatchSize, time.Millisecond * time.Duration(latency)
+}
+
+// WriteBatch outputs multiple formatted log records to the socket using a single
+// vectored write where supported. When a write fails, only the records not written in
+// full are resent after reconnecting; a partially written record is resent in full.
+// Called by dedicated target goroutine and will block until success or shutdown.
+func (tcp *Tcp) WriteBatch(recs []*logr.LogRec, bufs [][]byte) error {
+	remaining := bufs
+	_, err := tcp.writeWithRetry(recs[0], func(conn net.Conn) (int, error) {
+		// WriteTo consumes the buffers written in full, and advances a partially
+		// written buffer, so write a copy.
+		nb := make(net.Buffers, len(remaining))
+		copy(nb, remaining)
+		n, err := nb.WriteTo(conn)
+		remaining = remaining[len(remaining)-len(nb):]
+		return int(n), err
+	})
+	return err
+}
+
+// writeWithRetry calls write with a connected socket, reconnecting and retrying
+// with backoff until success or shutdown.
+func (tcp *Tcp) writeWithRetry(rec *logr.LogRec, write func(conn net.Conn) (int, error)) (int, error) {
 	try := 1
 	backoff := RetryBackoffMillis
 	for {
@@ -189,7 +240,7 @@ func (tcp *Tcp) Write(p []byte, rec *logr.LogRec) (int, error) {
 			reporter(fmt.Errorf("log target %s set write deadline error: %w", tcp.String(), err))
 		}
 
-		count, err := conn.Write(p)
+		count, err := write(conn)
 		if err == nil {
 			return count, nil
 		}
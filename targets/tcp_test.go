@@ -4,12 +4,16 @@
 package targets
 
 import (
+	"errors"
+	"io"
+	"net"
 	"testing"
 	"time"
 
 	"github.com/mattermost/logr/v2"
 	"github.com/mattermost/logr/v2/formatters"
 	"github.com/mattermost/logr/v2/test"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -159,3 +163,60 @@ func TestNewTcpTarget(t *testing.T) {
 		require.NoError(t, err)
 	})
 }
+
+func TestTcpWriteBatchRetry(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			received <- ""
+			return
+		}
+		defer conn.Close()
+		data, _ := io.ReadAll(conn)
+		received <- string(data)
+	}()
+
+	lgr, err := logr.New()
+	require.NoError(t, err)
+	defer lgr.Shutdown()
+	rec := logr.NewLogRec(logr.Info, lgr.NewLogger(), "batch", nil, false)
+
+	tcp := NewTcpTarget(&TcpOptions{IP: "127.0.0.1", Port: l.Addr().(*net.TCPAddr).Port})
+	// the first connection fails part way through the second record.
+	failing := &partialConn{limit: len("one\n") + 2}
+	tcp.conn = failing
+
+	bufs := [][]byte{[]byte("one\n"), []byte("two\n"), []byte("three\n")}
+	require.NoError(t, tcp.WriteBatch([]*logr.LogRec{rec, rec, rec}, bufs))
+	require.NoError(t, tcp.Shutdown())
+
+	assert.Equal(t, "one\ntw", failing.written)
+	assert.Equal(t, "two\nthree\n", <-received, "records written in full are not resent")
+	assert.Equal(t, "one\n", string(bufs[0]), "buffers are not modified")
+}
+
+// partialConn is a net.Conn that fails once limit bytes have been written.
+type partialConn struct {
+	net.Conn
+	limit   int
+	written string
+}
+
+func (c *partialConn) Write(p []byte) (int, error) {
+	if len(c.written)+len(p) <= c.limit {
+		c.written += string(p)
+		return len(p), nil
+	}
+	n := c.limit - len(c.written)
+	c.written += string(p[:n])
+	return n, errors.New("connection reset")
+}
+
+func (c *partialConn) SetWriteDeadline(time.Time) error { return nil }
+
+func (c *partialConn) Close() error { return nil }
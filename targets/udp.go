@@ -0,0 +1,214 @@
+package targets
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/mattermost/logr/v2"
+)
+
+const (
+	// DefaultUdpChunkSize is the default maximum datagram size, suitable for WAN
+	// links. Use up to 8192 for LAN deployments.
+	DefaultUdpChunkSize = 1420
+
+	// GelfChunkHeaderLen is the length of a GELF chunk header: 2 magic bytes,
+	// 8 byte message id, 1 byte sequence number and 1 byte sequence count.
+	GelfChunkHeaderLen = 12
+
+	// GelfMaxChunks is the maximum number of chunks a GELF message can be split into.
+	GelfMaxChunks = 128
+
+	// minimum chunk size allowing room for the header plus some data.
+	minUdpChunkSize = GelfChunkHeaderLen + 64
+)
+
+var gelfChunkMagic = []byte{0x1e, 0x0f}
+
+// Udp outputs log records as UDP datagrams, typically GELF records produced by
+// `formatters.Gelf`. Records larger than the chunk size are split using GELF
+// chunking, and can optionally be compressed. Delivery is fire-and-forget.
+type Udp struct {
+	options *UdpOptions
+	addy    string
+
+	mutex sync.Mutex
+	conn  net.Conn
+}
+
+// UdpOptions provides parameters for sending log records via UDP.
+type UdpOptions struct {
+	Host string `json:"host"`
+	Port int    `json:"port"`
+
+	// ChunkSize is the maximum datagram size in bytes, including the GELF chunk header.
+	// Larger records are split into chunks. Defaults to DefaultUdpChunkSize.
+	ChunkSize int `json:"chunk_size,omitempty"`
+
+	// Compression is one of "none", "gzip" or "zlib". Defaults to "none".
+	Compression string `json:"compression,omitempty"`
+}
+
+func (uo UdpOptions) CheckValid() error {
+	if uo.Host == "" {
+		return errors.New("missing host")
+	}
+	if uo.Port == 0 {
+		return errors.New("missing port")
+	}
+	if uo.ChunkSize != 0 && uo.ChunkSize < minUdpChunkSize {
+		return fmt.Errorf("chunk_size cannot be less than %d", minUdpChunkSize)
+	}
+	switch strings.ToLower(uo.Compression) {
+	case "", "none", "gzip", "zlib":
+	default:
+		return fmt.Errorf("invalid compression '%s'", uo.Compression)
+	}
+	return nil
+}
+
+// NewUdpTarget creates a target capable of outputting log records via UDP datagrams.
+func NewUdpTarget(options *UdpOptions) *Udp {
+	return &Udp{
+		options: options,
+		addy:    net.JoinHostPort(options.Host, fmt.Sprint(options.Port)),
+	}
+}
+
+// Init is called once to initialize the target.
+func (u *Udp) Init() error {
+	if err := u.options.CheckValid(); err != nil {
+		return err
+	}
+
+	conn, err := net.Dial("udp", u.addy)
+	if err != nil {
+		return err
+	}
+
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	u.conn = conn
+	return nil
+}
+
+// Write outputs a formatted log record as one or more datagrams.
+func (u *Udp) Write(p []byte, rec *logr.LogRec) (int, error) {
+	// GELF over UDP does not use the null byte delimiter needed for TCP.
+	p = bytes.TrimRight(p, "\x00")
+
+	data, err := u.compress(p)
+	if err != nil {
+		return 0, err
+	}
+
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	if u.conn == nil {
+		return 0, errors.New("udp target not initialized")
+	}
+
+	chunkSize := u.options.ChunkSize
+	if chunkSize == 0 {
+		chunkSize = DefaultUdpChunkSize
+	}
+
+	if len(data) <= chunkSize {
+		if _, err := u.conn.Write(data); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+
+	chunks, err := gelfChunks(data, chunkSize, rand.Uint64())
+	if err != nil {
+		return 0, err
+	}
+	for _, chunk := range chunks {
+		if _, err := u.conn.Write(chunk); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Shutdown is called once to free/close any resources.
+// Target queue is already drained when this is called.
+func (u *Udp) Shutdown() error {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	var err error
+	if u.conn != nil {
+		err = u.conn.Close()
+		u.conn = nil
+	}
+	return err
+}
+
+// String returns a string representation of this target.
+func (u *Udp) String() string {
+	return fmt.Sprintf("UdpTarget[%s]", u.addy)
+}
+
+// compress applies the configured compression, if any.
+func (u *Udp) compress(p []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	var zw io.WriteCloser
+
+	switch strings.ToLower(u.options.Compression) {
+	case "gzip":
+		zw = gzip.NewWriter(&buf)
+	case "zlib":
+		zw = zlib.NewWriter(&buf)
+	default:
+		return p, nil
+	}
+
+	if _, err := zw.Write(p); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// gelfChunks splits data into GELF chunks no larger than chunkSize, each prefixed
+// with the chunk header.
+func gelfChunks(data []byte, chunkSize int, msgID uint64) ([][]byte, error) {
+	payloadSize := chunkSize - GelfChunkHeaderLen
+	count := (len(data) + payloadSize - 1) / payloadSize
+	if count > GelfMaxChunks {
+		return nil, fmt.Errorf("record too large for GELF chunking (%d bytes requires %d chunks, max %d)",
+			len(data), count, GelfMaxChunks)
+	}
+
+	chunks := make([][]byte, 0, count)
+	for seq := 0; seq < count; seq++ {
+		start := seq * payloadSize
+		end := start + payloadSize
+		if end > len(data) {
+			end = len(data)
+		}
+
+		chunk := make([]byte, GelfChunkHeaderLen, GelfChunkHeaderLen+end-start)
+		copy(chunk, gelfChunkMagic)
+		binary.BigEndian.PutUint64(chunk[2:10], msgID)
+		chunk[10] = byte(seq)
+		chunk[11] = byte(count)
+		chunk = append(chunk, data[start:end]...)
+		chunks = append(chunks, chunk)
+	}
+	return chunks, nil
+}
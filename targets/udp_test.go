@@ -0,0 +1,135 @@
+package targets
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr/v2"
+	"github.com/mattermost/logr/v2/formatters"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readGelfMessage reads datagrams until a complete (possibly chunked) message is received.
+func readGelfMessage(t *testing.T, conn net.PacketConn) []byte {
+	chunks := make(map[byte][]byte)
+	buf := make([]byte, 65536)
+	for {
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+		n, _, err := conn.ReadFrom(buf)
+		require.NoError(t, err)
+		data := append([]byte{}, buf[:n]...)
+
+		if !bytes.HasPrefix(data, gelfChunkMagic) {
+			return data
+		}
+		count := data[11]
+		chunks[data[10]] = data[GelfChunkHeaderLen:]
+		if len(chunks) == int(count) {
+			seqs := make([]int, 0, count)
+			for seq := range chunks {
+				seqs = append(seqs, int(seq))
+			}
+			sort.Ints(seqs)
+			var msg []byte
+			for _, seq := range seqs {
+				msg = append(msg, chunks[byte(seq)]...)
+			}
+			return msg
+		}
+	}
+}
+
+func decompress(t *testing.T, data []byte) []byte {
+	var r io.Reader
+	var err error
+	switch {
+	case bytes.HasPrefix(data, []byte{0x1f, 0x8b}):
+		r, err = gzip.NewReader(bytes.NewReader(data))
+	case data[0] == 0x78:
+		r, err = zlib.NewReader(bytes.NewReader(data))
+	default:
+		return data
+	}
+	require.NoError(t, err)
+	out, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return out
+}
+
+func TestUdpTarget(t *testing.T) {
+	tests := []struct {
+		name        string
+		compression string
+		msgLen      int
+	}{
+		{name: "small", msgLen: 10},
+		{name: "chunked", msgLen: 5000},
+		{name: "gzip chunked", compression: "gzip", msgLen: 5000},
+		{name: "zlib", compression: "zlib", msgLen: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, err := net.ListenPacket("udp4", "127.0.0.1:0")
+			require.NoError(t, err)
+			defer server.Close()
+
+			lgr, err := logr.New()
+			require.NoError(t, err)
+
+			opts := &UdpOptions{
+				Host:        "127.0.0.1",
+				Port:        server.LocalAddr().(*net.UDPAddr).Port,
+				ChunkSize:   500,
+				Compression: tt.compression,
+			}
+			err = lgr.AddTarget(NewUdpTarget(opts), "udp", &logr.StdFilter{Lvl: logr.Info}, &formatters.Gelf{Hostname: "test"}, 1000)
+			require.NoError(t, err)
+
+			// random-ish text that doesn't compress to a single chunk.
+			var sb strings.Builder
+			for sb.Len() < tt.msgLen {
+				sb.WriteString(time.Now().Format(time.RFC3339Nano))
+			}
+			msg := sb.String()[:tt.msgLen]
+			lgr.NewLogger().Info(msg, logr.String("prop", "value"))
+
+			data := decompress(t, readGelfMessage(t, server))
+			require.NoError(t, lgr.Shutdown())
+
+			var rec map[string]any
+			require.NoError(t, json.Unmarshal(data, &rec), string(data))
+			assert.Equal(t, msg, rec["short_message"])
+			assert.Equal(t, "value", rec["_prop"])
+		})
+	}
+}
+
+func TestGelfChunks(t *testing.T) {
+	data := bytes.Repeat([]byte("x"), 1000)
+
+	chunks, err := gelfChunks(data, 112, 42)
+	require.NoError(t, err)
+	require.Len(t, chunks, 10)
+
+	for i, chunk := range chunks {
+		assert.LessOrEqual(t, len(chunk), 112)
+		assert.Equal(t, gelfChunkMagic, chunk[:2])
+		assert.EqualValues(t, 42, binary.BigEndian.Uint64(chunk[2:10]))
+		assert.EqualValues(t, i, chunk[10])
+		assert.EqualValues(t, 10, chunk[11])
+	}
+
+	_, err = gelfChunks(bytes.Repeat([]byte("x"), 129*100), 112, 1)
+	assert.Error(t, err)
+}
@@ -7,38 +7,42 @@ import (
 )
 
 type TestMetrics struct {
-	QueueSize float64
-	Logged    float64
-	Errors    float64
-	Dropped   float64
-	Blocked   float64
+	QueueSize  float64
+	Logged     float64
+	Errors     float64
+	Dropped    float64
+	Blocked    float64
+	Suppressed float64
 }
 
 type TestMetricsCollector struct {
-	queueSizeGauges map[string]*TestGauge
-	loggedCounters  map[string]*TestCounter
-	errorCounters   map[string]*TestCounter
-	droppedCounters map[string]*TestCounter
-	blockedCounters map[string]*TestCounter
+	queueSizeGauges    map[string]*TestGauge
+	loggedCounters     map[string]*TestCounter
+	errorCounters      map[string]*TestCounter
+	droppedCounters    map[string]*TestCounter
+	blockedCounters    map[string]*TestCounter
+	suppressedCounters map[string]*TestCounter
 }
 
 func NewTestMetricsCollector() *TestMetricsCollector {
 	return &TestMetricsCollector{
-		queueSizeGauges: make(map[string]*TestGauge),
-		loggedCounters:  make(map[string]*TestCounter),
-		errorCounters:   make(map[string]*TestCounter),
-		droppedCounters: make(map[string]*TestCounter),
-		blockedCounters: make(map[string]*TestCounter),
+		queueSizeGauges:    make(map[string]*TestGauge),
+		loggedCounters:     make(map[string]*TestCounter),
+		errorCounters:      make(map[string]*TestCounter),
+		droppedCounters:    make(map[string]*TestCounter),
+		blockedCounters:    make(map[string]*TestCounter),
+		suppressedCounters: make(map[string]*TestCounter),
 	}
 }
 
 func (c *TestMetricsCollector) Get(target string) TestMetrics {
 	return TestMetrics{
-		QueueSize: c.queueSizeGauges[target].get(),
-		Logged:    c.loggedCounters[target].get(),
-		Errors:    c.errorCounters[target].get(),
-		Dropped:   c.droppedCounters[target].get(),
-		Blocked:   c.blockedCounters[target].get(),
+		QueueSize:  c.queueSizeGauges[target].get(),
+		Logged:     c.loggedCounters[target].get(),
+		Errors:     c.errorCounters[target].get(),
+		Dropped:    c.droppedCounters[target].get(),
+		Blocked:    c.blockedCounters[target].get(),
+		Suppressed: c.suppressedCounters[target].get(),
 	}
 }
 
@@ -87,6 +91,15 @@ func (c *TestMetricsCollector) BlockedCounter(target string) (logr.Counter, erro
 	return counter, nil
 }
 
+func (c *TestMetricsCollector) SuppressedCounter(target string) (logr.Counter, error) {
+	counter, ok := c.suppressedCounters[target]
+	if !ok {
+		counter = &TestCounter{}
+		c.suppressedCounters[target] = counter
+	}
+	return counter, nil
+}
+
 type TestGauge struct {
 	val float64
 	mux sync.Mutex